@@ -0,0 +1,17 @@
+package api
+
+type AutoscaleMetricType string
+
+const (
+	AutoscaleMetricTypeCPU      AutoscaleMetricType = "cpu"
+	AutoscaleMetricTypeMemory   AutoscaleMetricType = "memory"
+	AutoscaleMetricTypePods     AutoscaleMetricType = "pods"
+	AutoscaleMetricTypeExternal AutoscaleMetricType = "external"
+
+	AutoscaleMetricTypeUnknown AutoscaleMetricType = ""
+)
+
+// IsResource returns true for the metrics targeting the cpu or memory use of the pods
+func (t AutoscaleMetricType) IsResource() bool {
+	return t == AutoscaleMetricTypeCPU || t == AutoscaleMetricTypeMemory
+}
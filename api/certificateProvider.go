@@ -0,0 +1,11 @@
+package api
+
+type CertificateProvider string
+
+const (
+	CertificateProviderLetsEncryptAnnotation CertificateProvider = "letsencrypt-annotation"
+	CertificateProviderCertManager           CertificateProvider = "cert-manager"
+	CertificateProviderGKEManaged            CertificateProvider = "gke-managed"
+
+	CertificateProviderUnknown CertificateProvider = ""
+)
@@ -0,0 +1,37 @@
+package api
+
+import "strings"
+
+type CloudSQLProxyVersion string
+
+const (
+	CloudSQLProxyVersionV1 CloudSQLProxyVersion = "v1"
+	CloudSQLProxyVersionV2 CloudSQLProxyVersion = "v2"
+
+	CloudSQLProxyVersionUnknown CloudSQLProxyVersion = ""
+)
+
+// GetCloudSQLProxyVersion derives the proxy version from the major version in the image tag, or from the repository name if the tag has none
+func GetCloudSQLProxyVersion(image string) CloudSQLProxyVersion {
+	image = strings.Split(image, "@")[0]
+
+	repository, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository, tag = image[:i], image[i+1:]
+	}
+
+	tag = strings.TrimPrefix(tag, "v")
+	switch {
+	case tag == "1" || strings.HasPrefix(tag, "1."):
+		return CloudSQLProxyVersionV1
+	case tag == "2" || strings.HasPrefix(tag, "2."):
+		return CloudSQLProxyVersionV2
+	}
+
+	// the v1 proxy is published as gce-proxy, v2 as cloud-sql-proxy
+	if strings.HasSuffix(repository, "/gce-proxy") {
+		return CloudSQLProxyVersionV1
+	}
+
+	return CloudSQLProxyVersionV2
+}
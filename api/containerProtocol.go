@@ -0,0 +1,17 @@
+package api
+
+type ContainerProtocol string
+
+const (
+	ContainerProtocolHTTP  ContainerProtocol = "http"
+	ContainerProtocolHTTP2 ContainerProtocol = "http2"
+	ContainerProtocolGRPC  ContainerProtocol = "grpc"
+	ContainerProtocolGRPCS ContainerProtocol = "grpcs"
+
+	ContainerProtocolUnknown ContainerProtocol = ""
+)
+
+// UsesTLS returns true if the application terminates tls itself for the protocol
+func (cp ContainerProtocol) UsesTLS() bool {
+	return cp == ContainerProtocolHTTP2 || cp == ContainerProtocolGRPCS
+}
@@ -1,6 +1,38 @@
 package api
 
-// GKECredentials represents the credentials of type kubernetes-engine as defined in the server config and passed to this trusted image
+const (
+	// CredentialTypeKubernetesEngine is a gke cluster whose kube config is retrieved via the gke api
+	CredentialTypeKubernetesEngine = "kubernetes-engine"
+	// CredentialTypeKubernetesCluster is any kubernetes cluster reachable with a server url, ca data and a token or client certificate
+	CredentialTypeKubernetesCluster = "kubernetes-cluster"
+)
+
+// GKEClusterEndpoint sets which endpoint of a gke cluster's control plane the kube config uses
+type GKEClusterEndpoint string
+
+const (
+	GKEClusterEndpointPublic  GKEClusterEndpoint = "public"
+	GKEClusterEndpointPrivate GKEClusterEndpoint = "private"
+	GKEClusterEndpointDNS     GKEClusterEndpoint = "dns"
+
+	GKEClusterEndpointUnknown GKEClusterEndpoint = ""
+)
+
+// GKEClusterAuth sets how kubectl authenticates against a gke cluster
+type GKEClusterAuth string
+
+const (
+	// GKEClusterAuthToken uses a short-lived access token minted from the service account keyfile, which expires after about an hour
+	GKEClusterAuthToken GKEClusterAuth = "token"
+	// GKEClusterAuthExec uses the gke-gcloud-auth-plugin, which needs to be available in the image; it's the default
+	GKEClusterAuthExec GKEClusterAuth = "exec"
+	// GKEClusterAuthGCP uses the deprecated gcp auth provider, which newer kubectl versions no longer support
+	GKEClusterAuthGCP GKEClusterAuth = "gcp"
+
+	GKEClusterAuthUnknown GKEClusterAuth = ""
+)
+
+// GKECredentials represents the credentials of type kubernetes-engine or kubernetes-cluster as defined in the server config and passed to this trusted image
 type GKECredentials struct {
 	Name                 string                            `json:"name,omitempty"`
 	Type                 string                            `json:"type,omitempty"`
@@ -15,6 +47,19 @@ type GKECredentialAdditionalProperties struct {
 	Zone                  string  `json:"zone,omitempty"`
 	ServiceAccountKeyfile string  `json:"serviceAccountKeyfile,omitempty"`
 	Defaults              *Params `json:"defaults,omitempty"`
+
+	// only used by credentials of type kubernetes-engine
+	Endpoint    GKEClusterEndpoint `json:"endpoint,omitempty"`
+	DNSEndpoint string             `json:"dnsEndpoint,omitempty"`
+	Auth        GKEClusterAuth     `json:"auth,omitempty"`
+
+	// only used by credentials of type kubernetes-cluster
+	Server                   string `json:"server,omitempty"`
+	CertificateAuthorityData string `json:"certificateAuthorityData,omitempty"`
+	InsecureSkipTLSVerify    bool   `json:"insecureSkipTLSVerify,omitempty"`
+	Token                    string `json:"token,omitempty"`
+	ClientCertificateData    string `json:"clientCertificateData,omitempty"`
+	ClientKeyData            string `json:"clientKeyData,omitempty"`
 }
 
 func (c *GKECredentials) GetLocation() string {
@@ -24,3 +69,8 @@ func (c *GKECredentials) GetLocation() string {
 
 	return c.AdditionalProperties.Region
 }
+
+// IsKubernetesCluster returns true if the credential is for a non-gke cluster, which needs no calls to gcp apis
+func (c *GKECredentials) IsKubernetesCluster() bool {
+	return c.Type == CredentialTypeKubernetesCluster
+}
@@ -51,34 +51,24 @@ func SanitizeLabels(labels map[string]string) (sanitizedLabels map[string]string
 	return
 }
 
-func httpRequestHeader(method, url string, headers map[string]string, responseHeader string) string {
-	client := pester.New()
-	client.MaxRetries = 3
-	client.Backoff = pester.ExponentialJitterBackoff
-	client.KeepLog = true
-	client.Timeout = time.Second * 5
-	request, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		return ""
-	}
-
-	for k, v := range headers {
-		request.Header.Add(k, v)
+// getNestedString walks nested maps as unmarshalled from json or yaml and returns the string at the end of the path
+func getNestedString(value interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch m := value.(type) {
+		case map[string]interface{}:
+			value = m[key]
+		case map[interface{}]interface{}:
+			value = m[key]
+		default:
+			return ""
+		}
 	}
 
-	// perform actual request
-	response, err := client.Do(request)
-	if err != nil {
-		return ""
-	}
-
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		return ""
+	if s, ok := value.(string); ok {
+		return s
 	}
 
-	return response.Header.Get(responseHeader)
+	return ""
 }
 
 func httpRequestBody(method, url string, headers map[string]string) string {
@@ -0,0 +1,70 @@
+package api
+
+// IngressControllerParams defines an ingress controller profile, usually set via the defaults in the kubernetes-engine credentials
+type IngressControllerParams struct {
+	Name             string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Class            string   `json:"class,omitempty" yaml:"class,omitempty"`
+	AnnotationPrefix string   `json:"annotationprefix,omitempty" yaml:"annotationprefix,omitempty"`
+	Features         []string `json:"features,omitempty" yaml:"features,omitempty"`
+}
+
+// HasFeature returns true if the ingress controller profile supports the feature
+func (ic IngressControllerParams) HasFeature(feature string) bool {
+	for _, f := range ic.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// IngressControllerFeatureBackendProtocol sets the backend protocol to https when the openresty sidecar is used
+	IngressControllerFeatureBackendProtocol = "backend-protocol"
+	// IngressControllerFeatureSSLRedirect disables the redirect to https when allowhttp is set
+	IngressControllerFeatureSSLRedirect = "ssl-redirect"
+	// IngressControllerFeatureProxySettings sets the buffer, body size and timeout annotations from the request params
+	IngressControllerFeatureProxySettings = "proxy-settings"
+	// IngressControllerFeatureWhitelist sets the whitelist for visibility public-whitelist
+	IngressControllerFeatureWhitelist = "whitelist"
+	// IngressControllerFeatureLoadBalance sets the load balancing algorithm from the request params
+	IngressControllerFeatureLoadBalance = "load-balance"
+	// IngressControllerFeatureClientCertificateAuth verifies client certificates against the request authsecret
+	IngressControllerFeatureClientCertificateAuth = "client-certificate-auth"
+	// IngressControllerFeatureDisallowHTTP disables plain http on the load balancer
+	IngressControllerFeatureDisallowHTTP = "disallow-http"
+	// IngressControllerFeatureWildcardPath appends * to the ingress path, for controllers that don't do prefix matching
+	IngressControllerFeatureWildcardPath = "wildcard-path"
+	// IngressControllerFeatureBackendConfig configures the google cloud load balancer from a backendconfig, which makes the gce ingress controller usable for visibility private and public-whitelist
+	IngressControllerFeatureBackendConfig = "backend-config"
+	// IngressControllerFeatureRecreateOnSwitch deletes the ingress when switching from or to this profile, so the controller removes its load balancer, see https://github.com/kubernetes/ingress-gce/issues/481
+	IngressControllerFeatureRecreateOnSwitch = "recreate-on-switch"
+)
+
+// DefaultIngressControllers are the ingress controller profiles available when they're not overridden in the credential defaults
+var DefaultIngressControllers = []IngressControllerParams{
+	{
+		Name:             "nginx-office",
+		Class:            "nginx-office",
+		AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+		Features:         []string{IngressControllerFeatureBackendProtocol, IngressControllerFeatureSSLRedirect, IngressControllerFeatureProxySettings, IngressControllerFeatureWhitelist, IngressControllerFeatureLoadBalance},
+	},
+	{
+		Name:             "nginx-internal",
+		Class:            "nginx-internal",
+		AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+		Features:         []string{IngressControllerFeatureBackendProtocol, IngressControllerFeatureSSLRedirect, IngressControllerFeatureProxySettings, IngressControllerFeatureLoadBalance},
+	},
+	{
+		Name:             "nginx-open",
+		Class:            "nginx-open",
+		AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+		Features:         []string{IngressControllerFeatureBackendProtocol, IngressControllerFeatureProxySettings, IngressControllerFeatureLoadBalance, IngressControllerFeatureClientCertificateAuth},
+	},
+	{
+		Name:             "gce",
+		Class:            "gce",
+		AnnotationPrefix: "kubernetes.io/ingress.",
+		Features:         []string{IngressControllerFeatureDisallowHTTP, IngressControllerFeatureWildcardPath, IngressControllerFeatureBackendConfig, IngressControllerFeatureRecreateOnSwitch},
+	},
+}
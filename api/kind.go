@@ -13,3 +13,6 @@ const (
 
 	KindUnknown Kind = ""
 )
+
+// SharedConfigLabelPrefix is used to label applications consuming configmaps and secrets published by kind config
+const SharedConfigLabelPrefix = "shared-config.estafette.io/"
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type NativeSidecarsMode string
+
+const (
+	NativeSidecarsModeAuto     NativeSidecarsMode = "auto"
+	NativeSidecarsModeEnabled  NativeSidecarsMode = "enabled"
+	NativeSidecarsModeDisabled NativeSidecarsMode = "disabled"
+
+	NativeSidecarsModeUnknown NativeSidecarsMode = ""
+)
+
+var kubernetesVersionRegex = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// UsesNativeSidecars returns true if the sidecars of a job or cronjob get rendered as init containers with restartPolicy Always, which kubernetes enables by default from 1.29
+func (p *Params) UsesNativeSidecars() bool {
+	if p.Kind != KindJob && p.Kind != KindCronJob {
+		return false
+	}
+
+	switch p.NativeSidecars {
+	case NativeSidecarsModeEnabled:
+		return true
+	case NativeSidecarsModeAuto:
+		return isKubernetesVersionAtLeast(p.KubernetesVersion, 1, 29)
+	}
+
+	return false
+}
+
+// GetSidecarsKeepingJobRunning returns the job sidecars that keep running once the job container is done when they're not native sidecars; only a cloudsqlproxy v2 sidecar gets told to quit
+func (p *Params) GetSidecarsKeepingJobRunning() (sidecars []string) {
+	for _, sidecar := range p.Sidecars {
+		if sidecar.Type == SidecarTypeIstio || (sidecar.Type == SidecarTypeCloudSQLProxy && sidecar.SQLProxyVersion == CloudSQLProxyVersionV2) {
+			continue
+		}
+		sidecars = append(sidecars, string(sidecar.Type))
+	}
+	for i := range p.CustomSidecars {
+		sidecars = append(sidecars, fmt.Sprintf("customsidecars[%v]", i))
+	}
+
+	return
+}
+
+// isKubernetesVersionAtLeast compares the major and minor version of a server version like v1.29.4-gke.1043000; an unknown version is never at least anything
+func isKubernetesVersionAtLeast(version string, major, minor int) bool {
+	matches := kubernetesVersionRegex.FindStringSubmatch(version)
+	if len(matches) != 3 {
+		return false
+	}
+
+	versionMajor, _ := strconv.Atoi(matches[1])
+	versionMinor, _ := strconv.Atoi(matches[2])
+
+	return versionMajor > major || (versionMajor == major && versionMinor >= minor)
+}
@@ -2,27 +2,35 @@ package api
 
 import (
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
 )
 
 // Params is used to parameterize the deployment, set from custom properties in the manifest
 type Params struct {
 	// control params
-	Action                  ActionType      `json:"action,omitempty" yaml:"action,omitempty"`
-	Kind                    Kind            `json:"kind,omitempty" yaml:"kind,omitempty"`
-	DryRun                  bool            `json:"dryrun,omitempty" yaml:"dryrun,omitempty"`
-	ProgressDeadlineSeconds int             `json:"progressDeadlineSeconds,omitempty" yaml:"progressDeadlineSeconds,omitempty"`
-	BuildVersion            string          `json:"-" yaml:"-"`
-	ChaosProof              bool            `json:"chaosproof,omitempty" yaml:"chaosproof,omitempty"`
-	OperatingSystem         OperatingSystem `json:"os,omitempty" yaml:"os,omitempty"`
-	Manifests               ManifestsParams `json:"manifests,omitempty" yaml:"manifests,omitempty"`
-	TrustedIPRanges         []string        `json:"trustedips,omitempty" yaml:"trustedips,omitempty"`
+	Action                   ActionType                      `json:"action,omitempty" yaml:"action,omitempty"`
+	Kind                     Kind                            `json:"kind,omitempty" yaml:"kind,omitempty"`
+	DryRun                   bool                            `json:"dryrun,omitempty" yaml:"dryrun,omitempty"`
+	ProgressDeadlineSeconds  int                             `json:"progressDeadlineSeconds,omitempty" yaml:"progressDeadlineSeconds,omitempty"`
+	BuildVersion             string                          `json:"-" yaml:"-"`
+	ChaosProof               bool                            `json:"chaosproof,omitempty" yaml:"chaosproof,omitempty"`
+	OperatingSystem          OperatingSystem                 `json:"os,omitempty" yaml:"os,omitempty"`
+	Manifests                ManifestsParams                 `json:"manifests,omitempty" yaml:"manifests,omitempty"`
+	TrustedIPRanges          []string                        `json:"trustedips,omitempty" yaml:"trustedips,omitempty"`
+	TrustedIPRangesProviders []TrustedIPRangesProviderParams `json:"trustedipproviders,omitempty" yaml:"trustedipproviders,omitempty"`
+	TrustedIPRangesCache     TrustedIPRangesCacheParams      `json:"trustedipcache,omitempty" yaml:"trustedipcache,omitempty"`
+	DigestCache              DigestCacheParams               `json:"digestcache,omitempty" yaml:"digestcache,omitempty"`
 
 	// app params
 	App                                    string                    `json:"app,omitempty" yaml:"app,omitempty"`
@@ -38,6 +46,7 @@ type Params struct {
 	StorageClass                           string                    `json:"storageclass,omitempty" yaml:"storageclass,omitempty"`
 	StorageSize                            string                    `json:"storagesize,omitempty" yaml:"storagesize,omitempty"`
 	StorageMountPath                       string                    `json:"storagemountpath,omitempty" yaml:"storagemountpath,omitempty"`
+	CanaryReplicas                         int                       `json:"canaryreplicas,omitempty" yaml:"canaryreplicas,omitempty"`
 	Labels                                 map[string]string         `json:"labels,omitempty" yaml:"labels,omitempty"`
 	Visibility                             Visibility                `json:"visibility,omitempty" yaml:"visibility,omitempty"`
 	ContainerNativeLoadBalancing           bool                      `json:"containerNativeLoadBalancing,omitempty" yaml:"containerNativeLoadBalancing,omitempty"`
@@ -46,26 +55,41 @@ type Params struct {
 	EspEndpointsProjectID                  string                    `json:"espEndpointsProjectID,omitempty" yaml:"espEndpointsProjectID,omitempty"`
 	EspConfigID                            string                    `json:"espConfigID,omitempty" yaml:"espConfigID,omitempty"`
 	EspOpenAPIYamlPath                     string                    `json:"espOpenapiYamlPath,omitempty" yaml:"espOpenapiYamlPath,omitempty"`
+	EspServiceConfigYamlPath               string                    `json:"espServiceConfigYamlPath,omitempty" yaml:"espServiceConfigYamlPath,omitempty"`
+	EspDescriptorSetPath                   string                    `json:"espDescriptorSetPath,omitempty" yaml:"espDescriptorSetPath,omitempty"`
 	WhitelistedIPS                         []string                  `json:"whitelist,omitempty" yaml:"whitelist,omitempty"`
 	Hosts                                  []string                  `json:"hosts,omitempty" yaml:"hosts,omitempty"`
 	HostsRouteOnly                         []string                  `json:"hostsrouteonly,omitempty" yaml:"hostsrouteonly,omitempty"`
 	InternalHosts                          []string                  `json:"internalhosts,omitempty" yaml:"internalhosts,omitempty"`
 	InternalHostsRouteOnly                 []string                  `json:"internalhostsrouteonly,omitempty" yaml:"internalhostsrouteonly,omitempty"`
 	ApigeeSuffix                           string                    `json:"apigeesuffix,omitempty" yaml:"apigeesuffix,omitempty"`
+	IngressControllers                     []IngressControllerParams `json:"ingresscontrollers,omitempty" yaml:"ingresscontrollers,omitempty"`
+	IngressController                      string                    `json:"ingresscontroller,omitempty" yaml:"ingresscontroller,omitempty"`
+	InternalIngressController              string                    `json:"internalingresscontroller,omitempty" yaml:"internalingresscontroller,omitempty"`
+	ApigeeIngressController                string                    `json:"apigeeingresscontroller,omitempty" yaml:"apigeeingresscontroller,omitempty"`
 	Basepath                               string                    `json:"basepath,omitempty" yaml:"basepath,omitempty"`
 	Autoscale                              AutoscaleParams           `json:"autoscale,omitempty" yaml:"autoscale,omitempty"`
 	VerticalPodAutoscaler                  VPAParams                 `json:"vpa,omitempty" yaml:"vpa,omitempty"`
 	Request                                RequestParams             `json:"request,omitempty" yaml:"request,omitempty"`
+	Routes                                 []RouteParams             `json:"routes,omitempty" yaml:"routes,omitempty"`
+	Gateway                                GatewayParams             `json:"gateway,omitempty" yaml:"gateway,omitempty"`
+	Mesh                                   MeshParams                `json:"mesh,omitempty" yaml:"mesh,omitempty"`
+	NetworkPolicy                          NetworkPolicyParams       `json:"networkPolicy,omitempty" yaml:"networkPolicy,omitempty"`
+	BackendConfig                          BackendConfigParams       `json:"backendConfig,omitempty" yaml:"backendConfig,omitempty"`
 	Secrets                                SecretsParams             `json:"secrets,omitempty" yaml:"secrets,omitempty"`
 	Configs                                ConfigsParams             `json:"configs,omitempty" yaml:"configs,omitempty"`
+	SharedConfigName                       string                    `json:"sharedconfigname,omitempty" yaml:"sharedconfigname,omitempty"`
 	VolumeMounts                           []VolumeMountParams       `json:"volumemounts,omitempty" yaml:"volumemounts,omitempty"`
 	CertificateSecret                      string                    `json:"certificatesecret,omitempty" yaml:"certificatesecret,omitempty"`
+	Certificate                            CertificateParams         `json:"certificate,omitempty" yaml:"certificate,omitempty"`
 	AllowHTTP                              bool                      `json:"allowhttp,omitempty" yaml:"allowhttp,omitempty"`
 	EnablePayloadLogging                   bool                      `json:"enablePayloadLogging,omitempty" yaml:"enablePayloadLogging,omitempty"`
 	UseGoogleCloudCredentials              bool                      `json:"useGoogleCloudCredentials,omitempty" yaml:"useGoogleCloudCredentials,omitempty"`
 	DisableServiceAccountKeyRotation       *bool                     `json:"disableServiceAccountKeyRotation,omitempty" yaml:"disableServiceAccountKeyRotation,omitempty"`
 	LegacyGoogleCloudServiceAccountKeyFile string                    `json:"legacyGoogleCloudServiceAccountKeyFile,omitempty" yaml:"legacyGoogleCloudServiceAccountKeyFile,omitempty"`
 	GoogleCloudCredentialsApp              string                    `json:"googleCloudCredentialsApp,omitempty" yaml:"googleCloudCredentialsApp,omitempty"`
+	WorkloadIdentity                       WorkloadIdentityParams    `json:"workloadIdentity,omitempty" yaml:"workloadIdentity,omitempty"`
+	Tracing                                TracingParams             `json:"tracing,omitempty" yaml:"tracing,omitempty"`
 	ProbeService                           *bool                     `json:"probeService,omitempty" yaml:"probeService,omitempty"`
 	Tolerations                            []*map[string]interface{} `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
 
@@ -76,15 +100,22 @@ type Params struct {
 	Sidecar                SidecarParams             `json:"sidecar,omitempty" yaml:"sidecar,omitempty"`
 	Sidecars               []*SidecarParams          `json:"sidecars,omitempty" yaml:"sidecars,omitempty"`
 	CustomSidecars         []*map[string]interface{} `json:"customsidecars,omitempty" yaml:"customsidecars,omitempty"`
+	SidecarTypes           []*SidecarTypeDefinition  `json:"sidecarTypes,omitempty" yaml:"sidecarTypes,omitempty"`
+	SidecarTypesDirectory  string                    `json:"sidecarTypesDirectory,omitempty" yaml:"sidecarTypesDirectory,omitempty"`
+	NativeSidecars         NativeSidecarsMode        `json:"nativeSidecars,omitempty" yaml:"nativeSidecars,omitempty"`
 	StrategyType           StrategyType              `json:"strategytype,omitempty" yaml:"strategytype,omitempty"`
 	AtomicID               string                    `json:"-" yaml:"-"`
+	ServedAPIVersions      []string                  `json:"-" yaml:"-"`
+	KubernetesVersion      string                    `json:"-" yaml:"-"`
+	SecretManagerVersions  map[string]string         `json:"-" yaml:"-"`
 	RollingUpdate          RollingUpdateParams       `json:"rollingupdate,omitempty" yaml:"rollingupdate,omitempty"`
 
 	// set default image for sidecars
-	DefaultOpenrestySidecarImage     string `json:"defaultOpenrestySidecarImage,omitempty" yaml:"defaultOpenrestySidecarImage,omitempty"`
-	DefaultESPSidecarImage           string `json:"defaultESPSidecarImage,omitempty" yaml:"defaultESPSidecarImage,omitempty"`
-	DefaultESPv2SidecarImage         string `json:"defaultESPv2SidecarImage,omitempty" yaml:"defaultESPv2SidecarImage,omitempty"`
-	DefaultCloudSQLProxySidecarImage string `json:"defaultCloudSQLProxySidecarImage,omitempty" yaml:"defaultCloudSQLProxySidecarImage,omitempty"`
+	DefaultOpenrestySidecarImage       string `json:"defaultOpenrestySidecarImage,omitempty" yaml:"defaultOpenrestySidecarImage,omitempty"`
+	DefaultESPSidecarImage             string `json:"defaultESPSidecarImage,omitempty" yaml:"defaultESPSidecarImage,omitempty"`
+	DefaultESPv2SidecarImage           string `json:"defaultESPv2SidecarImage,omitempty" yaml:"defaultESPv2SidecarImage,omitempty"`
+	DefaultCloudSQLProxySidecarImage   string `json:"defaultCloudSQLProxySidecarImage,omitempty" yaml:"defaultCloudSQLProxySidecarImage,omitempty"`
+	DefaultCloudSQLProxyV2SidecarImage string `json:"defaultCloudSQLProxyV2SidecarImage,omitempty" yaml:"defaultCloudSQLProxyV2SidecarImage,omitempty"`
 
 	// params for image pull secret
 	ImagePullSecretUser     string `json:"imagePullSecretUser,omitempty" yaml:"imagePullSecretUser,omitempty"`
@@ -98,15 +129,18 @@ type ContainerParams struct {
 	ImageTag                   string                 `json:"tag,omitempty" yaml:"tag,omitempty"`
 	ImagePullPolicy            string                 `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy,omitempty"`
 	Port                       int                    `json:"port,omitempty" yaml:"port,omitempty"`
+	Protocol                   ContainerProtocol      `json:"protocol,omitempty" yaml:"protocol,omitempty"`
 	EnvironmentVariables       map[string]interface{} `json:"env,omitempty" yaml:"env,omitempty"`
 	SecretEnvironmentVariables map[string]interface{} `json:"secretEnv,omitempty" yaml:"secretEnv,omitempty"`
 
-	CPU            CPUParams       `json:"cpu,omitempty" yaml:"cpu,omitempty"`
-	Memory         MemoryParams    `json:"memory,omitempty" yaml:"memory,omitempty"`
-	LivenessProbe  ProbeParams     `json:"liveness,omitempty" yaml:"liveness,omitempty"`
-	ReadinessProbe ProbeParams     `json:"readiness,omitempty" yaml:"readiness,omitempty"`
-	Metrics        MetricsParams   `json:"metrics,omitempty" yaml:"metrics,omitempty"`
-	Lifecycle      LifecycleParams `json:"lifecycle,omitempty" yaml:"lifecycle,omitempty"`
+	CPU            CPUParams        `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Memory         MemoryParams     `json:"memory,omitempty" yaml:"memory,omitempty"`
+	LivenessProbe  ProbeParams      `json:"liveness,omitempty" yaml:"liveness,omitempty"`
+	ReadinessProbe ProbeParams      `json:"readiness,omitempty" yaml:"readiness,omitempty"`
+	Metrics        MetricsParams    `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Lifecycle      LifecycleParams  `json:"lifecycle,omitempty" yaml:"lifecycle,omitempty"`
+	ImageCheck     ImageCheckParams `json:"imagecheck,omitempty" yaml:"imagecheck,omitempty"`
+	ImageDigest    string           `json:"-" yaml:"-"`
 
 	AdditionalPorts []*AdditionalPortParams `json:"additionalports,omitempty" yaml:"additionalports,omitempty"`
 }
@@ -133,11 +167,43 @@ type MemoryParams struct {
 
 // AutoscaleParams controls autoscaling
 type AutoscaleParams struct {
-	Enabled       *bool                 `json:"enabled,omitempty" yaml:"enabled,omitempty"`
-	MinReplicas   int                   `json:"min,omitempty" yaml:"min,omitempty"`
-	MaxReplicas   int                   `json:"max,omitempty" yaml:"max,omitempty"`
-	CPUPercentage int                   `json:"cpu,omitempty" yaml:"cpu,omitempty"`
-	Safety        AutoscaleSafetyParams `json:"safety,omitempty" yaml:"safety,omitempty"`
+	Enabled       *bool                    `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	MinReplicas   int                      `json:"min,omitempty" yaml:"min,omitempty"`
+	MaxReplicas   int                      `json:"max,omitempty" yaml:"max,omitempty"`
+	CPUPercentage int                      `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Safety        AutoscaleSafetyParams    `json:"safety,omitempty" yaml:"safety,omitempty"`
+	Metrics       []*AutoscaleMetricParams `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Behavior      AutoscaleBehaviorParams  `json:"behavior,omitempty" yaml:"behavior,omitempty"`
+}
+
+// AutoscaleMetricParams is a metric the horizontal pod autoscaler scales on; cpu and memory target an average utilization or value, pods an average value and external metrics a value or average value
+type AutoscaleMetricParams struct {
+	Type               AutoscaleMetricType `json:"type,omitempty" yaml:"type,omitempty"`
+	Name               string              `json:"name,omitempty" yaml:"name,omitempty"`
+	Selector           map[string]string   `json:"selector,omitempty" yaml:"selector,omitempty"`
+	AverageUtilization int                 `json:"averageutilization,omitempty" yaml:"averageutilization,omitempty"`
+	AverageValue       string              `json:"averagevalue,omitempty" yaml:"averagevalue,omitempty"`
+	Value              string              `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// AutoscaleBehaviorParams configures how fast the horizontal pod autoscaler scales up and down
+type AutoscaleBehaviorParams struct {
+	ScaleUp   *AutoscaleScalingRulesParams `json:"scaleup,omitempty" yaml:"scaleup,omitempty"`
+	ScaleDown *AutoscaleScalingRulesParams `json:"scaledown,omitempty" yaml:"scaledown,omitempty"`
+}
+
+// AutoscaleScalingRulesParams limits scaling in one direction with policies and a stabilization window
+type AutoscaleScalingRulesParams struct {
+	StabilizationWindowSeconds *int                     `json:"stabilizationwindowseconds,omitempty" yaml:"stabilizationwindowseconds,omitempty"`
+	SelectPolicy               string                   `json:"selectpolicy,omitempty" yaml:"selectpolicy,omitempty"`
+	Policies                   []*AutoscalePolicyParams `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// AutoscalePolicyParams allows scaling by a number of pods or a percentage of the current replicas per period
+type AutoscalePolicyParams struct {
+	Type          string `json:"type,omitempty" yaml:"type,omitempty"`
+	Value         int    `json:"value,omitempty" yaml:"value,omitempty"`
+	PeriodSeconds int    `json:"periodseconds,omitempty" yaml:"periodseconds,omitempty"`
 }
 
 type VPAParams struct {
@@ -166,6 +232,127 @@ type RequestParams struct {
 	VerifyDepth          int    `json:"verifydepth,omitempty" yaml:"verifydepth,omitempty"`
 }
 
+// RouteParams routes a path on the hosts to the main or an additional port, with its own request settings in a separate ingress
+type RouteParams struct {
+	Name    string        `json:"name,omitempty" yaml:"name,omitempty"`
+	Hosts   []string      `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+	Path    string        `json:"path,omitempty" yaml:"path,omitempty"`
+	Port    string        `json:"port,omitempty" yaml:"port,omitempty"`
+	Request RequestParams `json:"request,omitempty" yaml:"request,omitempty"`
+}
+
+// GatewayParams configures the shared gateway httproutes attach to for visibility gateway
+type GatewayParams struct {
+	Name                string `json:"name,omitempty" yaml:"name,omitempty"`
+	Namespace           string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	SectionName         string `json:"sectionname,omitempty" yaml:"sectionname,omitempty"`
+	InternalName        string `json:"internalname,omitempty" yaml:"internalname,omitempty"`
+	InternalNamespace   string `json:"internalnamespace,omitempty" yaml:"internalnamespace,omitempty"`
+	InternalSectionName string `json:"internalsectionname,omitempty" yaml:"internalsectionname,omitempty"`
+	CanaryWeight        *int   `json:"canaryweight,omitempty" yaml:"canaryweight,omitempty"`
+}
+
+// MeshParams configures traffic handling by the istio service mesh when an istio sidecar is used
+type MeshParams struct {
+	MTLS             *bool                      `json:"mtls,omitempty" yaml:"mtls,omitempty"`
+	Timeout          string                     `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retries          MeshRetryParams            `json:"retries,omitempty" yaml:"retries,omitempty"`
+	OutlierDetection MeshOutlierDetectionParams `json:"outlierdetection,omitempty" yaml:"outlierdetection,omitempty"`
+	CanaryWeight     int                        `json:"canaryweight,omitempty" yaml:"canaryweight,omitempty"`
+}
+
+// MeshRetryParams configures retries for requests to the application
+type MeshRetryParams struct {
+	Attempts      int    `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	PerTryTimeout string `json:"pertrytimeout,omitempty" yaml:"pertrytimeout,omitempty"`
+	RetryOn       string `json:"retryon,omitempty" yaml:"retryon,omitempty"`
+}
+
+// MeshOutlierDetectionParams configures ejection of misbehaving pods from the load balancing pool
+type MeshOutlierDetectionParams struct {
+	Consecutive5xxErrors int    `json:"consecutive5xxerrors,omitempty" yaml:"consecutive5xxerrors,omitempty"`
+	Interval             string `json:"interval,omitempty" yaml:"interval,omitempty"`
+	BaseEjectionTime     string `json:"baseejectiontime,omitempty" yaml:"baseejectiontime,omitempty"`
+	MaxEjectionPercent   int    `json:"maxejectionpercent,omitempty" yaml:"maxejectionpercent,omitempty"`
+}
+
+// BackendConfigParams configures the google cloud load balancer backend when the gce ingress controller is used
+type BackendConfigParams struct {
+	SecurityPolicy     string                             `json:"securitypolicy,omitempty" yaml:"securitypolicy,omitempty"`
+	CDN                BackendConfigCDNParams             `json:"cdn,omitempty" yaml:"cdn,omitempty"`
+	SessionAffinity    BackendConfigSessionAffinityParams `json:"sessionaffinity,omitempty" yaml:"sessionaffinity,omitempty"`
+	ConnectionDraining int                                `json:"connectiondraining,omitempty" yaml:"connectiondraining,omitempty"`
+	HealthCheck        BackendConfigHealthCheckParams     `json:"healthcheck,omitempty" yaml:"healthcheck,omitempty"`
+	Logging            BackendConfigLoggingParams         `json:"logging,omitempty" yaml:"logging,omitempty"`
+}
+
+// BackendConfigCDNParams enables cloud cdn with its cache policy
+type BackendConfigCDNParams struct {
+	Enabled            *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	CacheMode          string `json:"cachemode,omitempty" yaml:"cachemode,omitempty"`
+	DefaultTTL         int    `json:"defaultttl,omitempty" yaml:"defaultttl,omitempty"`
+	MaxTTL             int    `json:"maxttl,omitempty" yaml:"maxttl,omitempty"`
+	ClientTTL          int    `json:"clientttl,omitempty" yaml:"clientttl,omitempty"`
+	IncludeQueryString *bool  `json:"includequerystring,omitempty" yaml:"includequerystring,omitempty"`
+}
+
+// BackendConfigSessionAffinityParams sets session affinity to either CLIENT_IP or GENERATED_COOKIE
+type BackendConfigSessionAffinityParams struct {
+	Type      string `json:"type,omitempty" yaml:"type,omitempty"`
+	CookieTTL int    `json:"cookiettl,omitempty" yaml:"cookiettl,omitempty"`
+}
+
+// BackendConfigHealthCheckParams overrides the health check the load balancer otherwise derives from the readiness probe
+type BackendConfigHealthCheckParams struct {
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	Port int    `json:"port,omitempty" yaml:"port,omitempty"`
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// BackendConfigLoggingParams sets the fraction of requests logged by the load balancer
+type BackendConfigLoggingParams struct {
+	SampleRate *float64 `json:"samplerate,omitempty" yaml:"samplerate,omitempty"`
+}
+
+// CertificateParams configures what provides the tls certificate for the hosts when no certificatesecret is set
+type CertificateParams struct {
+	Provider   CertificateProvider `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Issuer     string              `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	IssuerKind string              `json:"issuerkind,omitempty" yaml:"issuerkind,omitempty"`
+}
+
+// WorkloadIdentityParams lets the pods act as a google service account without a key, see https://cloud.google.com/kubernetes-engine/docs/how-to/workload-identity
+type WorkloadIdentityParams struct {
+	Enabled           *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	GCPServiceAccount string `json:"gcpServiceAccount,omitempty" yaml:"gcpServiceAccount,omitempty"`
+}
+
+// TracingParams configures the tracing env injected into the application container; for otel without endpoint traces get sent to a collector on the node
+type TracingParams struct {
+	Provider            TracingProvider `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Endpoint            string          `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	SamplingRatio       string          `json:"samplingratio,omitempty" yaml:"samplingratio,omitempty"`
+	CanarySamplingRatio string          `json:"canarysamplingratio,omitempty" yaml:"canarysamplingratio,omitempty"`
+}
+
+// NetworkPolicyParams configures the traffic allowed to and from the pods of the application
+type NetworkPolicyParams struct {
+	Enabled                     *bool                     `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	IngressControllerNamespaces []string                  `json:"ingresscontrollernamespaces,omitempty" yaml:"ingresscontrollernamespaces,omitempty"`
+	MetricsNamespaces           []string                  `json:"metricsnamespaces,omitempty" yaml:"metricsnamespaces,omitempty"`
+	Apps                        []string                  `json:"apps,omitempty" yaml:"apps,omitempty"`
+	Namespaces                  []string                  `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	CIDRs                       []string                  `json:"cidrs,omitempty" yaml:"cidrs,omitempty"`
+	Egress                      NetworkPolicyEgressParams `json:"egress,omitempty" yaml:"egress,omitempty"`
+}
+
+// NetworkPolicyEgressParams configures the destinations the pods are allowed to connect to; if none are set egress isn't restricted
+type NetworkPolicyEgressParams struct {
+	Apps       []string `json:"apps,omitempty" yaml:"apps,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	CIDRs      []string `json:"cidrs,omitempty" yaml:"cidrs,omitempty"`
+}
+
 // ProbeParams sets params for liveness or readiness probe
 type ProbeParams struct {
 	Enabled             *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
@@ -191,19 +378,44 @@ type LifecycleParams struct {
 	PrestopSleepSeconds *int  `json:"prestopsleepseconds,omitempty" yaml:"prestopsleepseconds,omitempty"`
 }
 
+// ImageCheckParams sets whether the image is looked up in its registry before deploying and pinned to its digest
+type ImageCheckParams struct {
+	Verify         *bool `json:"verify,omitempty" yaml:"verify,omitempty"`
+	Pin            *bool `json:"pin,omitempty" yaml:"pin,omitempty"`
+	InitContainers *bool `json:"initcontainers,omitempty" yaml:"initcontainers,omitempty"`
+}
+
+// DigestCacheParams sets where and for how long sidecar image digests are cached; without a directory they're resolved on every release
+type DigestCacheParams struct {
+	Directory string `json:"directory,omitempty" yaml:"directory,omitempty"`
+	TTL       string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
 // SidecarParams sets params for sidecar injection
 type SidecarParams struct {
-	Type                              SidecarType            `json:"type,omitempty" yaml:"type,omitempty"`
-	Image                             string                 `json:"image,omitempty" yaml:"image,omitempty"`
-	EnvironmentVariables              map[string]interface{} `json:"env,omitempty" yaml:"env,omitempty"`
-	SecretEnvironmentVariables        map[string]interface{} `json:"secretEnv,omitempty" yaml:"secretEnv,omitempty"`
-	CPU                               CPUParams              `json:"cpu,omitempty" yaml:"cpu,omitempty"`
-	Memory                            MemoryParams           `json:"memory,omitempty" yaml:"memory,omitempty"`
-	HealthCheckPath                   string                 `json:"healthcheckpath,omitempty" yaml:"healthcheckpath,omitempty"`
-	DbInstanceConnectionName          string                 `json:"dbinstanceconnectionname,omitempty" yaml:"dbinstanceconnectionname,omitempty"`
-	SQLProxyPort                      int                    `json:"sqlproxyport,omitempty" yaml:"sqlproxyport,omitempty"`
-	SQLProxyTerminationTimeoutSeconds int                    `json:"sqlproxyterminationtimeoutseconds,omitempty" yaml:"sqlproxyterminationtimeoutseconds,omitempty"`
-	CustomProperties                  map[string]interface{} `yaml:",inline"`
+	Type                              SidecarType               `json:"type,omitempty" yaml:"type,omitempty"`
+	Image                             string                    `json:"image,omitempty" yaml:"image,omitempty"`
+	EnvironmentVariables              map[string]interface{}    `json:"env,omitempty" yaml:"env,omitempty"`
+	SecretEnvironmentVariables        map[string]interface{}    `json:"secretEnv,omitempty" yaml:"secretEnv,omitempty"`
+	CPU                               CPUParams                 `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Memory                            MemoryParams              `json:"memory,omitempty" yaml:"memory,omitempty"`
+	HealthCheckPath                   string                    `json:"healthcheckpath,omitempty" yaml:"healthcheckpath,omitempty"`
+	DbInstanceConnectionName          string                    `json:"dbinstanceconnectionname,omitempty" yaml:"dbinstanceconnectionname,omitempty"`
+	SQLProxyPort                      int                       `json:"sqlproxyport,omitempty" yaml:"sqlproxyport,omitempty"`
+	SQLProxyTerminationTimeoutSeconds int                       `json:"sqlproxyterminationtimeoutseconds,omitempty" yaml:"sqlproxyterminationtimeoutseconds,omitempty"`
+	SQLProxyVersion                   CloudSQLProxyVersion      `json:"sqlproxyversion,omitempty" yaml:"sqlproxyversion,omitempty"`
+	SQLProxyInstances                 []*SQLProxyInstanceParams `json:"sqlproxyinstances,omitempty" yaml:"sqlproxyinstances,omitempty"`
+	SQLProxyPrivateIP                 bool                      `json:"sqlproxyprivateip,omitempty" yaml:"sqlproxyprivateip,omitempty"`
+	SQLProxyAutoIAMAuthn              bool                      `json:"sqlproxyautoiamauthn,omitempty" yaml:"sqlproxyautoiamauthn,omitempty"`
+	SQLProxyStructuredLogs            bool                      `json:"sqlproxystructuredlogs,omitempty" yaml:"sqlproxystructuredlogs,omitempty"`
+	SQLProxyHealthCheckPort           int                       `json:"sqlproxyhealthcheckport,omitempty" yaml:"sqlproxyhealthcheckport,omitempty"`
+	CustomProperties                  map[string]interface{}    `yaml:",inline"`
+}
+
+// SQLProxyInstanceParams sets a Cloud SQL instance the Cloud SQL proxy sidecar exposes on a local port
+type SQLProxyInstanceParams struct {
+	ConnectionName string `json:"connectionname,omitempty" yaml:"connectionname,omitempty"`
+	Port           int    `json:"port,omitempty" yaml:"port,omitempty"`
 }
 
 // RollingUpdateParams sets params for controlling rolling update speed
@@ -286,6 +498,22 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.GoogleCloudCredentialsApp = p.App
 	}
 
+	// default workload identity to disabled, so existing service account key secrets keep working
+	if p.WorkloadIdentity.Enabled == nil {
+		falseValue := false
+		p.WorkloadIdentity.Enabled = &falseValue
+	}
+
+	if p.Tracing.Provider == TracingProviderUnknown {
+		p.Tracing.Provider = TracingProviderJaeger
+	}
+	if p.Tracing.SamplingRatio == "" {
+		p.Tracing.SamplingRatio = "0.001"
+	}
+	if p.Tracing.CanarySamplingRatio == "" {
+		p.Tracing.CanarySamplingRatio = "0.1"
+	}
+
 	// default image name to estafette app label if no override in stage params
 	if p.Container.ImageName == "" && p.App != "" {
 		p.Container.ImageName = p.App
@@ -301,6 +529,11 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.Container.ImagePullPolicy = "IfNotPresent"
 	}
 
+	// default protocol to http/1.1
+	if p.Container.Protocol == ContainerProtocolUnknown {
+		p.Container.Protocol = ContainerProtocolHTTP
+	}
+
 	// default labels to estafette labels if no override in stage params
 	if p.Labels == nil {
 		p.Labels = map[string]string{}
@@ -385,6 +618,21 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 	if p.Autoscale.CPUPercentage <= 0 {
 		p.Autoscale.CPUPercentage = 80
 	}
+	for _, m := range p.Autoscale.Metrics {
+		if m.Type == AutoscaleMetricTypeCPU && m.AverageUtilization <= 0 && m.AverageValue == "" {
+			m.AverageUtilization = p.Autoscale.CPUPercentage
+		}
+	}
+	for _, rules := range []*AutoscaleScalingRulesParams{p.Autoscale.Behavior.ScaleUp, p.Autoscale.Behavior.ScaleDown} {
+		if rules == nil {
+			continue
+		}
+		for _, policy := range rules.Policies {
+			if policy.PeriodSeconds <= 0 {
+				policy.PeriodSeconds = 60
+			}
+		}
+	}
 
 	if p.Autoscale.Safety.PromQuery == "" {
 		p.Autoscale.Safety.PromQuery = fmt.Sprintf("sum(rate(nginx_http_requests_total{app='%v'}[5m])) by (app)", p.App)
@@ -405,6 +653,68 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.VerticalPodAutoscaler.UpdateMode = UpdateModeOff
 	}
 
+	// set gateway defaults
+	if p.Gateway.InternalName == "" {
+		p.Gateway.InternalName = p.Gateway.Name
+		p.Gateway.InternalNamespace = p.Gateway.Namespace
+		p.Gateway.InternalSectionName = p.Gateway.SectionName
+	}
+	// a pointer, so a canary weight of 0 can keep all traffic on stable
+	if p.Gateway.CanaryWeight == nil {
+		canaryWeight := 10
+		p.Gateway.CanaryWeight = &canaryWeight
+	}
+
+	// set network policy defaults
+	if p.NetworkPolicy.Enabled == nil {
+		falseValue := false
+		p.NetworkPolicy.Enabled = &falseValue
+	}
+	if len(p.NetworkPolicy.IngressControllerNamespaces) == 0 {
+		p.NetworkPolicy.IngressControllerNamespaces = []string{"ingress-nginx"}
+	}
+	if len(p.NetworkPolicy.MetricsNamespaces) == 0 {
+		p.NetworkPolicy.MetricsNamespaces = []string{"monitoring"}
+	}
+
+	// set backend config defaults
+	if p.BackendConfig.CDN.Enabled == nil {
+		falseValue := false
+		p.BackendConfig.CDN.Enabled = &falseValue
+	}
+	if *p.BackendConfig.CDN.Enabled {
+		if p.BackendConfig.CDN.CacheMode == "" {
+			p.BackendConfig.CDN.CacheMode = "CACHE_ALL_STATIC"
+		}
+		// with USE_ORIGIN_HEADERS the origin's cache headers decide the ttls
+		if p.UsesCDNTTLs() {
+			if p.BackendConfig.CDN.DefaultTTL == 0 {
+				p.BackendConfig.CDN.DefaultTTL = 3600
+			}
+			if p.BackendConfig.CDN.MaxTTL == 0 {
+				p.BackendConfig.CDN.MaxTTL = 86400
+			}
+			if p.BackendConfig.CDN.ClientTTL == 0 {
+				p.BackendConfig.CDN.ClientTTL = 3600
+			}
+		}
+		if p.BackendConfig.CDN.IncludeQueryString == nil {
+			trueValue := true
+			p.BackendConfig.CDN.IncludeQueryString = &trueValue
+		}
+	}
+
+	// set certificate defaults
+	if p.Certificate.Provider == CertificateProviderUnknown {
+		p.Certificate.Provider = CertificateProviderLetsEncryptAnnotation
+	}
+	if p.Certificate.Provider == CertificateProviderCertManager && p.Certificate.Issuer == "" {
+		p.Certificate.Issuer = "letsencrypt"
+	}
+	if p.Certificate.Provider == CertificateProviderCertManager && p.Certificate.IssuerKind == "" {
+		p.Certificate.IssuerKind = "ClusterIssuer"
+	}
+
 	// set request defaults
 	if p.Request.Timeout == "" {
 		p.Request.Timeout = "60s"
@@ -423,6 +733,31 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.Request.ClientBodyBufferSize = "8k"
 	}
 
+	// set route defaults, inheriting the request params
+	for i := range p.Routes {
+		if len(p.Routes[i].Hosts) == 0 {
+			p.Routes[i].Hosts = p.Hosts
+		}
+		if p.Routes[i].Request.Timeout == "" {
+			p.Routes[i].Request.Timeout = p.Request.Timeout
+		}
+		if p.Routes[i].Request.MaxBodySize == "" {
+			p.Routes[i].Request.MaxBodySize = p.Request.MaxBodySize
+		}
+		if p.Routes[i].Request.ProxyBufferSize == "" {
+			p.Routes[i].Request.ProxyBufferSize = p.Request.ProxyBufferSize
+		}
+		if p.Routes[i].Request.ProxyBuffersNumber <= 0 {
+			p.Routes[i].Request.ProxyBuffersNumber = p.Request.ProxyBuffersNumber
+		}
+		if p.Routes[i].Request.ClientBodyBufferSize == "" {
+			p.Routes[i].Request.ClientBodyBufferSize = p.Request.ClientBodyBufferSize
+		}
+		if p.Routes[i].Request.LoadBalanceAlgorithm == "" {
+			p.Routes[i].Request.LoadBalanceAlgorithm = p.Request.LoadBalanceAlgorithm
+		}
+	}
+
 	// set liveness probe defaults
 	if p.Container.LivenessProbe.Enabled == nil {
 		trueValue := true
@@ -515,6 +850,20 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.Container.Lifecycle.PrestopSleepSeconds = &defaultSleepValue
 	}
 
+	// set image check defaults
+	if p.Container.ImageCheck.Verify == nil {
+		trueValue := true
+		p.Container.ImageCheck.Verify = &trueValue
+	}
+	if p.Container.ImageCheck.Pin == nil {
+		falseValue := false
+		p.Container.ImageCheck.Pin = &falseValue
+	}
+	if p.Container.ImageCheck.InitContainers == nil {
+		falseValue := false
+		p.Container.ImageCheck.InitContainers = &falseValue
+	}
+
 	if p.InjectHTTPProxySidecar == nil {
 		trueValue := true
 		p.InjectHTTPProxySidecar = &trueValue
@@ -525,6 +874,37 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.Sidecars = append([]*SidecarParams{&p.Sidecar}, p.Sidecars...)
 	}
 
+	// set mesh defaults
+	if p.HasIstioSidecar() {
+		if p.Mesh.MTLS == nil {
+			falseValue := false
+			p.Mesh.MTLS = &falseValue
+		}
+		if p.Mesh.Timeout == "" {
+			p.Mesh.Timeout = p.Request.Timeout
+		}
+		if p.Mesh.Retries.Attempts == 0 {
+			p.Mesh.Retries.Attempts = 2
+		}
+		if p.Mesh.Retries.RetryOn == "" {
+			p.Mesh.Retries.RetryOn = "connect-failure,refused-stream,unavailable,cancelled,retriable-status-codes"
+		}
+		if p.Mesh.OutlierDetection.Consecutive5xxErrors > 0 {
+			if p.Mesh.OutlierDetection.Interval == "" {
+				p.Mesh.OutlierDetection.Interval = "10s"
+			}
+			if p.Mesh.OutlierDetection.BaseEjectionTime == "" {
+				p.Mesh.OutlierDetection.BaseEjectionTime = "30s"
+			}
+			if p.Mesh.OutlierDetection.MaxEjectionPercent == 0 {
+				p.Mesh.OutlierDetection.MaxEjectionPercent = 10
+			}
+		}
+		if p.Mesh.CanaryWeight == 0 {
+			p.Mesh.CanaryWeight = 10
+		}
+	}
+
 	// check if an openresty sidecar is in the list
 	openrestySidecarSpecifiedInList := false
 	for _, sidecar := range p.Sidecars {
@@ -533,8 +913,9 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		}
 	}
 
-	// inject an openresty sidecar in the sidecars list if it isn't there yet for deployments
-	if *p.InjectHTTPProxySidecar && !openrestySidecarSpecifiedInList && p.Kind == KindDeployment {
+	// inject an openresty sidecar in the sidecars list if it isn't there yet for deployments; it only proxies http/1.1, so http2 and grpc go to the container directly
+	// with mutual tls the mesh already encrypts traffic between pods, so the openresty sidecar isn't needed
+	if *p.InjectHTTPProxySidecar && !openrestySidecarSpecifiedInList && p.Kind == KindDeployment && p.Container.Protocol == ContainerProtocolHTTP && !p.UsesMeshMTLS() {
 		openrestySidecar := SidecarParams{Type: SidecarTypeOpenresty}
 		p.initializeSidecarDefaults(&openrestySidecar)
 
@@ -542,7 +923,7 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 	}
 
 	if p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2 {
-		if p.EspOpenAPIYamlPath == "" {
+		if p.EspOpenAPIYamlPath == "" && !p.UsesEspGRPC() {
 			p.EspOpenAPIYamlPath = "openapi.yaml"
 		}
 
@@ -596,6 +977,9 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 	if p.DefaultCloudSQLProxySidecarImage == "" {
 		p.DefaultCloudSQLProxySidecarImage = "eu.gcr.io/cloudsql-docker/gce-proxy:1.21.0"
 	}
+	if p.DefaultCloudSQLProxyV2SidecarImage == "" {
+		p.DefaultCloudSQLProxyV2SidecarImage = "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.14.1"
+	}
 
 	for i := range p.Sidecars {
 		p.initializeSidecarDefaults(p.Sidecars[i])
@@ -631,6 +1015,11 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.Replicas = 1
 	}
 
+	// default the name of the shared configmap and secret published by kind config to the app name
+	if p.Kind == KindConfig && p.SharedConfigName == "" {
+		p.SharedConfigName = p.App
+	}
+
 	// set mountpaths for configs and secrets
 	if p.Configs.MountPath == "" {
 		p.Configs.MountPath = "/configs"
@@ -639,24 +1028,25 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		p.Secrets.MountPath = "/secrets"
 	}
 
-	// default trusted ip ranges to cloudflare's ips from https://www.cloudflare.com/ips-v4
-	if len(p.TrustedIPRanges) == 0 {
-		p.TrustedIPRanges = []string{
-			"103.21.244.0/22",
-			"103.22.200.0/22",
-			"103.31.4.0/22",
-			"104.16.0.0/12",
-			"108.162.192.0/18",
-			"131.0.72.0/22",
-			"141.101.64.0/18",
-			"162.158.0.0/15",
-			"172.64.0.0/13",
-			"173.245.48.0/20",
-			"188.114.96.0/20",
-			"190.93.240.0/20",
-			"197.234.240.0/22",
-			"198.41.128.0/17",
+	// default trusted ip ranges to cloudflare's ips from https://www.cloudflare.com/ips-v4; the bundled ranges are refreshed by ResolveTrustedIPRanges
+	if len(p.TrustedIPRangesProviders) == 0 && len(p.TrustedIPRanges) == 0 {
+		p.TrustedIPRangesProviders = []TrustedIPRangesProviderParams{{Type: TrustedIPRangesProviderCloudflareV4}}
+	}
+	if len(p.TrustedIPRangesProviders) > 0 {
+		if len(p.TrustedIPRanges) > 0 {
+			log.Warn().Msgf("Ignoring trustedips because trustedipproviders are set")
 		}
+		p.TrustedIPRanges = p.getBundledTrustedIPRanges()
+	}
+	// the extension runs in a fresh container each release, so cache in the mounted workspace instead of a temporary directory
+	if p.TrustedIPRangesCache.Directory == "" {
+		p.TrustedIPRangesCache.Directory = filepath.Join(".estafette-extension-gke", "trustedips")
+	}
+	if p.TrustedIPRangesCache.TTL == "" {
+		p.TrustedIPRangesCache.TTL = "24h"
+	}
+	if p.DigestCache.Directory != "" && p.DigestCache.TTL == "" {
+		p.DigestCache.TTL = "24h"
 	}
 
 	if p.Kind == KindCronJob {
@@ -668,6 +1058,9 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 	if p.RestartPolicy == "" {
 		p.RestartPolicy = "OnFailure"
 	}
+	if p.NativeSidecars == NativeSidecarsModeUnknown {
+		p.NativeSidecars = NativeSidecarsModeAuto
+	}
 	if p.Completions <= 0 {
 		p.Completions = 1
 	}
@@ -692,9 +1085,50 @@ func (p *Params) SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersio
 		if p.StorageMountPath == "" {
 			p.StorageMountPath = "/data"
 		}
+		// default to updating only the pod with the highest ordinal for a canary
+		if p.CanaryReplicas <= 0 {
+			p.CanaryReplicas = 1
+		}
 	}
 }
 
+// UsesWorkloadIdentity returns true if the pods get their google credentials from workload identity
+func (p *Params) UsesWorkloadIdentity() bool {
+	return p.WorkloadIdentity.Enabled != nil && *p.WorkloadIdentity.Enabled
+}
+
+// UsesServiceAccountKey returns true if a google service account key secret gets mounted; workload identity replaces it
+func (p *Params) UsesServiceAccountKey() bool {
+	return (p.UseGoogleCloudCredentials || p.LegacyGoogleCloudServiceAccountKeyFile != "") && !p.UsesWorkloadIdentity()
+}
+
+// UsesEspGRPC returns true when a grpc service config and descriptor set get deployed to cloud endpoints instead of an openapi spec
+func (p *Params) UsesEspGRPC() bool {
+	return p.EspServiceConfigYamlPath != ""
+}
+
+// GetEspGRPCServiceName returns the name of the endpoints service from the grpc service config, which esp needs as its service
+func (p *Params) GetEspGRPCServiceName() (string, error) {
+	serviceConfigBytes, err := ioutil.ReadFile(p.EspServiceConfigYamlPath)
+	if err != nil {
+		return "", err
+	}
+
+	var serviceConfig struct {
+		Name string `yaml:"name"`
+	}
+	err = yaml.Unmarshal(serviceConfigBytes, &serviceConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if serviceConfig.Name == "" {
+		return "", fmt.Errorf("The name field in the grpc service config at %v is empty, please set it", p.EspServiceConfigYamlPath)
+	}
+
+	return serviceConfig.Name, nil
+}
+
 func (p *Params) HasSecrets() bool {
 	if len(p.Secrets.Keys) > 0 {
 		return true
@@ -713,6 +1147,131 @@ func (p *Params) HasSecrets() bool {
 	return false
 }
 
+// UsesMeshMTLS returns true if the pods join the istio service mesh with mutual tls
+func (p *Params) UsesMeshMTLS() bool {
+	return p.HasIstioSidecar() && p.Mesh.MTLS != nil && *p.Mesh.MTLS
+}
+
+// UsesCDNTTLs returns true if the cloud cdn cache mode caches responses for the configured ttls instead of following the origin's cache headers
+func (p *Params) UsesCDNTTLs() bool {
+	return p.BackendConfig.CDN.CacheMode == "CACHE_ALL_STATIC" || p.BackendConfig.CDN.CacheMode == "FORCE_CACHE_ALL"
+}
+
+// HasIstioSidecar returns true if an istio sidecar is in the sidecars list, which has the pods join the istio service mesh
+func (p *Params) HasIstioSidecar() bool {
+	for _, sidecar := range p.Sidecars {
+		if sidecar.Type == SidecarTypeIstio {
+			return true
+		}
+	}
+	return false
+}
+
+// GetIngressController returns the ingress controller profile with the given name, looking in the profiles from the credential defaults before the built-in ones
+func (p *Params) GetIngressController(name string) (IngressControllerParams, bool) {
+	for _, ic := range p.getIngressControllers() {
+		if ic.Name == name {
+			return ic, true
+		}
+	}
+	return IngressControllerParams{}, false
+}
+
+func (p *Params) getIngressControllers() []IngressControllerParams {
+	ingressControllers := make([]IngressControllerParams, 0, len(p.IngressControllers)+len(DefaultIngressControllers))
+	ingressControllers = append(ingressControllers, p.IngressControllers...)
+	return append(ingressControllers, DefaultIngressControllers...)
+}
+
+// GetIngressControllerByClass returns the ingress controller profile for an ingress class, to find out which controller serves an existing ingress
+func (p *Params) GetIngressControllerByClass(class string) (IngressControllerParams, bool) {
+	for _, ic := range p.getIngressControllers() {
+		if ic.Class == class {
+			return ic, true
+		}
+	}
+	return IngressControllerParams{}, false
+}
+
+// GetIngressControllerName returns the name of the ingress controller profile for the hosts, defaulting to the one matching the visibility
+func (p *Params) GetIngressControllerName() string {
+	if p.IngressController != "" {
+		return p.IngressController
+	}
+	if p.Visibility == VisibilityIAP {
+		return "gce"
+	}
+	return "nginx-office"
+}
+
+// GetInternalIngressControllerName returns the name of the ingress controller profile for the internal hosts
+func (p *Params) GetInternalIngressControllerName() string {
+	if p.InternalIngressController != "" {
+		return p.InternalIngressController
+	}
+	return "nginx-internal"
+}
+
+// GetApigeeIngressControllerName returns the name of the ingress controller profile for the apigee hosts
+func (p *Params) GetApigeeIngressControllerName() string {
+	if p.ApigeeIngressController != "" {
+		return p.ApigeeIngressController
+	}
+	return "nginx-open"
+}
+
+// UsesGCEIngress returns true if the ingress for the hosts is served by the gce ingress controller, which configures the google cloud load balancer from a backendconfig
+func (p *Params) UsesGCEIngress() bool {
+	if p.Visibility == VisibilityIAP {
+		return true
+	}
+	if p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublicWhitelist {
+		return false
+	}
+	ingressController, _ := p.GetIngressController(p.GetIngressControllerName())
+	return ingressController.HasFeature(IngressControllerFeatureBackendConfig)
+}
+
+// HasConfigs returns true if any config files are set, either from file or inline
+func (p *Params) HasConfigs() bool {
+	return len(p.Configs.Files) > 0 || len(p.Configs.InlineFiles) > 0
+}
+
+// GetSharedConfigReferences returns the names of configmaps and secrets referenced from volume mounts or environment variables, which could be published by a release of kind config
+func (p *Params) GetSharedConfigReferences() []string {
+
+	references := []string{}
+	addReference := func(name string) {
+		if name == "" {
+			return
+		}
+		for _, r := range references {
+			if r == name {
+				return
+			}
+		}
+		references = append(references, name)
+	}
+
+	for _, vm := range p.VolumeMounts {
+		addReference(getNestedString(vm.Volume, "configMap", "name"))
+		addReference(getNestedString(vm.Volume, "secret", "secretName"))
+	}
+
+	envs := []map[string]interface{}{p.Container.EnvironmentVariables}
+	for _, sc := range p.Sidecars {
+		envs = append(envs, sc.EnvironmentVariables)
+	}
+	for _, env := range envs {
+		for _, value := range env {
+			addReference(getNestedString(value, "valueFrom", "configMapKeyRef", "name"))
+			addReference(getNestedString(value, "valueFrom", "secretKeyRef", "name"))
+		}
+	}
+
+	return references
+}
+
 func (p *Params) initializeSidecarDefaults(sidecar *SidecarParams) {
 	switch sidecar.Type {
 	case SidecarTypeOpenresty:
@@ -731,6 +1290,10 @@ func (p *Params) initializeSidecarDefaults(sidecar *SidecarParams) {
 			sidecar.Image = p.DefaultESPv2SidecarImage
 		}
 	case SidecarTypeCloudSQLProxy:
+		// v2 has different flags, so it's only used when asked for explicitly
+		if sidecar.Image == "" && sidecar.SQLProxyVersion == CloudSQLProxyVersionV2 {
+			sidecar.Image = p.DefaultCloudSQLProxyV2SidecarImage
+		}
 		if sidecar.Image == "" {
 			sidecar.Image = p.DefaultCloudSQLProxySidecarImage
 		}
@@ -740,6 +1303,38 @@ func (p *Params) initializeSidecarDefaults(sidecar *SidecarParams) {
 		if sidecar.SQLProxyTerminationTimeoutSeconds <= 0 {
 			sidecar.SQLProxyTerminationTimeoutSeconds = 60
 		}
+		if len(sidecar.SQLProxyInstances) == 0 && sidecar.DbInstanceConnectionName != "" {
+			sidecar.SQLProxyInstances = []*SQLProxyInstanceParams{
+				{
+					ConnectionName: sidecar.DbInstanceConnectionName,
+				},
+			}
+		}
+		// instances without a port listen on consecutive ports starting at sqlproxyport
+		for i, instance := range sidecar.SQLProxyInstances {
+			if instance != nil && instance.Port <= 0 {
+				instance.Port = sidecar.SQLProxyPort + i
+			}
+		}
+		if sidecar.SQLProxyVersion == CloudSQLProxyVersionUnknown {
+			sidecar.SQLProxyVersion = GetCloudSQLProxyVersion(sidecar.Image)
+		}
+		if sidecar.SQLProxyVersion == CloudSQLProxyVersionV2 && sidecar.SQLProxyHealthCheckPort <= 0 {
+			sidecar.SQLProxyHealthCheckPort = 9801
+		}
+	case SidecarTypeIstio:
+		// the envoy proxy needs more resources than the generic sidecar defaults
+		if sidecar.CPU.Request == "" && sidecar.CPU.Limit == "" {
+			sidecar.CPU.Request = "100m"
+		}
+		if sidecar.Memory.Request == "" && sidecar.Memory.Limit == "" {
+			sidecar.Memory.Request = "128Mi"
+			sidecar.Memory.Limit = "256Mi"
+		}
+	default:
+		if definition := p.GetSidecarTypeDefinition(sidecar.Type); definition != nil {
+			definition.initializeSidecarDefaults(sidecar)
+		}
 	}
 
 	// set sidecar cpu defaults
@@ -789,6 +1384,9 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 		return len(errors) == 0, errors, warnings
 	}
 
+	// validate secret manager references in secret values
+	errors = append(errors, p.validateSecretManagerReferences()...)
+
 	// validate container params
 	if p.Container.ImageRepository == "" {
 		errors = append(errors, fmt.Errorf("Image repository is required; set it via container.repository property on this stage"))
@@ -833,6 +1431,24 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 		errors = append(errors, fmt.Errorf("Rollingupdate max unavailable is required; set it via rollingupdate.maxunavailable property on this stage"))
 	}
 
+	// validate tracing params, which apply to workers as well
+	switch p.Tracing.Provider {
+	case TracingProviderUnknown, TracingProviderJaeger, TracingProviderOpenTelemetry, TracingProviderNone:
+	default:
+		errors = append(errors, fmt.Errorf("Tracing provider %v is unknown; set it via provider property for tracing on this stage; allowed values are jaeger, otel or none", p.Tracing.Provider))
+	}
+	for _, ratio := range []string{p.Tracing.SamplingRatio, p.Tracing.CanarySamplingRatio} {
+		if ratio == "" {
+			continue
+		}
+		if value, err := strconv.ParseFloat(ratio, 64); err != nil || value < 0 || value > 1 {
+			errors = append(errors, fmt.Errorf("Tracing sampling ratio %v is invalid; set samplingratio and canarysamplingratio properties for tracing to a number between 0 and 1", ratio))
+		}
+	}
+	if p.Tracing.Endpoint != "" && p.Tracing.Provider != TracingProviderOpenTelemetry {
+		warnings = append(warnings, "The tracing endpoint is only used by the otel tracing provider")
+	}
+
 	if p.Kind == KindJob || p.Kind == KindCronJob {
 		if p.Kind == KindCronJob {
 			if p.Schedule == "" {
@@ -844,6 +1460,23 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 			}
 		}
 
+		switch p.NativeSidecars {
+		case NativeSidecarsModeUnknown, NativeSidecarsModeAuto, NativeSidecarsModeEnabled, NativeSidecarsModeDisabled:
+		default:
+			errors = append(errors, fmt.Errorf("NativeSidecars is invalid; allowed values for nativeSidecars property are auto, enabled or disabled"))
+		}
+
+		// sidecars run next to the job container, so they need the same validation as for deployments
+		errors = p.validateSidecarTypes(errors)
+		for _, sidecar := range p.Sidecars {
+			errors = p.validateSidecar(sidecar, errors)
+		}
+		if p.NativeSidecars == NativeSidecarsModeDisabled {
+			for _, sidecar := range p.GetSidecarsKeepingJobRunning() {
+				errors = append(errors, fmt.Errorf("Sidecar %v keeps the job from completing without native sidecars; set nativeSidecars property to auto or enabled, or use sqlproxyversion v2 for a cloudsqlproxy sidecar", sidecar))
+			}
+		}
+
 		// the above properties are all you need for a worker
 		return len(errors) == 0, errors, warnings
 	}
@@ -861,11 +1494,143 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 		if p.StorageMountPath == "" {
 			errors = append(errors, fmt.Errorf("StorageMountPath is required for a statefulset; set it via storagemountpath property on this stage"))
 		}
+		if (p.Action == ActionDeployCanary || p.Action == ActionDiffCanary) && p.CanaryReplicas <= 0 {
+			errors = append(errors, fmt.Errorf("CanaryReplicas must be larger than zero for a statefulset canary; set it via canaryreplicas property on this stage"))
+		}
+	}
+	// validate network policy params
+	if p.NetworkPolicy.Enabled != nil && *p.NetworkPolicy.Enabled {
+		for _, cidr := range append(append([]string{}, p.NetworkPolicy.CIDRs...), p.NetworkPolicy.Egress.CIDRs...) {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				errors = append(errors, fmt.Errorf("Network policy cidr %v is invalid; set a valid cidr via cidrs property for networkPolicy on this stage", cidr))
+			}
+		}
+	}
+
+	// validate certificate params
+	if p.Certificate.Provider != CertificateProviderUnknown && p.Certificate.Provider != CertificateProviderLetsEncryptAnnotation && p.Certificate.Provider != CertificateProviderCertManager && p.Certificate.Provider != CertificateProviderGKEManaged {
+		errors = append(errors, fmt.Errorf("Certificate provider %v is unknown; set it via provider property for certificate on this stage; allowed values are letsencrypt-annotation, cert-manager or gke-managed", p.Certificate.Provider))
+	}
+	if p.Certificate.Provider == CertificateProviderGKEManaged && (p.Kind == KindDeployment || p.Kind == KindStatefulset) && !p.UsesGCEIngress() {
+		errors = append(errors, fmt.Errorf("Certificate provider gke-managed only works with the gce ingress controller; use it with visibility 'iap' or the gce ingress controller, or pick another provider"))
+	}
+
+	// validate workload identity params
+	if p.UsesWorkloadIdentity() && p.WorkloadIdentity.GCPServiceAccount == "" {
+		errors = append(errors, fmt.Errorf("Workload identity needs a google service account; set it via gcpServiceAccount property for workloadIdentity on this stage"))
+	}
+	if p.UsesWorkloadIdentity() && p.WorkloadIdentity.GCPServiceAccount != "" && !strings.HasSuffix(p.WorkloadIdentity.GCPServiceAccount, ".gserviceaccount.com") {
+		errors = append(errors, fmt.Errorf("Workload identity google service account %v is invalid; set gcpServiceAccount property for workloadIdentity to its email address, like <name>@<project>.iam.gserviceaccount.com", p.WorkloadIdentity.GCPServiceAccount))
+	}
+	if p.UsesWorkloadIdentity() && (p.UseGoogleCloudCredentials || p.LegacyGoogleCloudServiceAccountKeyFile != "") {
+		warnings = append(warnings, "Workload identity replaces the service account key secret; useGoogleCloudCredentials and legacyGoogleCloudServiceAccountKeyFile are ignored and the secret gets deleted")
+	}
+
+	// validate trusted ip range params; trustedips are only used as is without providers
+	if len(p.TrustedIPRangesProviders) == 0 {
+		for _, cidr := range p.TrustedIPRanges {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				errors = append(errors, fmt.Errorf("Trusted ip range %v is invalid; set a valid cidr via trustedips property on this stage", cidr))
+			}
+		}
+	}
+	for _, provider := range p.TrustedIPRangesProviders {
+		switch provider.Type {
+		case TrustedIPRangesProviderCloudflareV4, TrustedIPRangesProviderCloudflareV6:
+		case TrustedIPRangesProviderURL:
+			if provider.URL == "" {
+				errors = append(errors, fmt.Errorf("Trusted ip range provider of type url needs a url; set it via url property for trustedipproviders on this stage"))
+			}
+		case TrustedIPRangesProviderList:
+			if _, err := parseTrustedIPRanges(strings.Join(provider.Ranges, "\n")); err != nil {
+				errors = append(errors, fmt.Errorf("Trusted ip range provider of type list needs valid cidrs; %v", err))
+			}
+		default:
+			errors = append(errors, fmt.Errorf("Trusted ip range provider %v is unknown; set it via type property for trustedipproviders on this stage; allowed values are cloudflare-v4, cloudflare-v6, url or list", provider.Type))
+		}
+	}
+	if _, err := time.ParseDuration(p.TrustedIPRangesCache.TTL); p.TrustedIPRangesCache.TTL != "" && err != nil {
+		errors = append(errors, fmt.Errorf("Trusted ip range cache ttl %v is not a valid duration; set it via ttl property for trustedipcache on this stage, for example 24h", p.TrustedIPRangesCache.TTL))
+	}
+	if _, err := time.ParseDuration(p.DigestCache.TTL); p.DigestCache.TTL != "" && err != nil {
+		errors = append(errors, fmt.Errorf("Digest cache ttl %v is not a valid duration; set it via ttl property for digestcache on this stage, for example 24h", p.DigestCache.TTL))
+	}
+
+	// validate route params
+	if len(p.Routes) > 0 && (p.Kind == KindDeployment || p.Kind == KindStatefulset) {
+		if p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublicWhitelist && p.Visibility != VisibilityApigee {
+			errors = append(errors, fmt.Errorf("Routes need an nginx ingress; use them with visibility private, public-whitelist or apigee"))
+		}
+		if p.UsesGCEIngress() {
+			errors = append(errors, fmt.Errorf("Routes can't be used with the gce ingress controller, because each route gets an ingress and thus a load balancer of its own"))
+		}
+		routeNames := map[string]bool{}
+		for _, route := range p.Routes {
+			matchesInvalidChars, _ := regexp.MatchString("[^a-z0-9-]", route.Name)
+			if route.Name == "" || matchesInvalidChars {
+				errors = append(errors, fmt.Errorf("Route name %v is invalid; set it via name property for the route with only a-z, 0-9 and - characters", route.Name))
+			}
+			if routeNames[route.Name] {
+				errors = append(errors, fmt.Errorf("Route name %v is used more than once; route names need to be unique", route.Name))
+			}
+			routeNames[route.Name] = true
+			if !strings.HasPrefix(route.Path, "/") {
+				errors = append(errors, fmt.Errorf("Route %v has invalid path %v; set it via path property for the route, starting with /", route.Name, route.Path))
+			}
+			if route.Port != "" {
+				portExists := false
+				for _, ap := range p.Container.AdditionalPorts {
+					if ap.Name == route.Port {
+						portExists = true
+					}
+				}
+				if !portExists {
+					errors = append(errors, fmt.Errorf("Route %v has port %v, which isn't in additionalports; set it via port property for the route to the name of an additional port", route.Name, route.Port))
+				}
+			}
+			for _, host := range route.Hosts {
+				hostExists := false
+				for _, h := range append(append([]string{}, p.Hosts...), p.HostsRouteOnly...) {
+					if h == host {
+						hostExists = true
+					}
+				}
+				if !hostExists {
+					errors = append(errors, fmt.Errorf("Route %v has host %v, which isn't in hosts or hostsrouteonly", route.Name, host))
+				}
+			}
+		}
+	}
+
+	// validate backend config params
+	if p.BackendConfig.CDN.Enabled != nil && *p.BackendConfig.CDN.Enabled {
+		if p.Visibility == VisibilityIAP {
+			errors = append(errors, fmt.Errorf("Cloud cdn can't be combined with visibility 'iap'; disable it via enabled property for backendConfig.cdn on this stage"))
+		}
+		if p.BackendConfig.CDN.CacheMode != "CACHE_ALL_STATIC" && p.BackendConfig.CDN.CacheMode != "USE_ORIGIN_HEADERS" && p.BackendConfig.CDN.CacheMode != "FORCE_CACHE_ALL" {
+			errors = append(errors, fmt.Errorf("Cloud cdn cachemode %v is invalid; allowed values are CACHE_ALL_STATIC, USE_ORIGIN_HEADERS or FORCE_CACHE_ALL", p.BackendConfig.CDN.CacheMode))
+		}
+		if p.BackendConfig.CDN.CacheMode == "USE_ORIGIN_HEADERS" && (p.BackendConfig.CDN.DefaultTTL > 0 || p.BackendConfig.CDN.MaxTTL > 0 || p.BackendConfig.CDN.ClientTTL > 0) {
+			errors = append(errors, fmt.Errorf("Cloud cdn ttls can't be set with cachemode USE_ORIGIN_HEADERS; remove defaultttl, maxttl and clientttl for backendConfig.cdn or use cachemode CACHE_ALL_STATIC or FORCE_CACHE_ALL on this stage"))
+		}
+	}
+	if p.BackendConfig.SessionAffinity.Type != "" && p.BackendConfig.SessionAffinity.Type != "CLIENT_IP" && p.BackendConfig.SessionAffinity.Type != "GENERATED_COOKIE" {
+		errors = append(errors, fmt.Errorf("Session affinity type %v is invalid; allowed values are CLIENT_IP or GENERATED_COOKIE", p.BackendConfig.SessionAffinity.Type))
+	}
+	if p.BackendConfig.HealthCheck.Type != "" && p.BackendConfig.HealthCheck.Type != "HTTP" && p.BackendConfig.HealthCheck.Type != "HTTPS" && p.BackendConfig.HealthCheck.Type != "HTTP2" {
+		errors = append(errors, fmt.Errorf("Health check type %v is invalid; allowed values are HTTP, HTTPS or HTTP2", p.BackendConfig.HealthCheck.Type))
 	}
+	if p.BackendConfig.Logging.SampleRate != nil && (*p.BackendConfig.Logging.SampleRate < 0 || *p.BackendConfig.Logging.SampleRate > 1) {
+		errors = append(errors, fmt.Errorf("Logging samplerate needs to be between 0 and 1; set it via samplerate property for backendConfig.logging on this stage"))
+	}
+	if p.Visibility == VisibilityPublicWhitelist && len(p.WhitelistedIPS) > 0 && p.UsesGCEIngress() && p.BackendConfig.SecurityPolicy == "" {
+		errors = append(errors, fmt.Errorf("With visibility 'public-whitelist' the gce ingress controller can't apply the whitelist; set a cloud armor policy via securitypolicy property for backendConfig on this stage"))
+	}
+
 	// validate params with respect to incoming requests
 	if p.Kind == KindDeployment {
-		if p.Visibility == VisibilityUnknown || (p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublic && p.Visibility != VisibilityIAP && p.Visibility != VisibilityESP && p.Visibility != VisibilityESPv2 && p.Visibility != VisibilityPublicWhitelist && p.Visibility != VisibilityApigee) {
-			errors = append(errors, fmt.Errorf("Visibility property is required; set it via visibility property on this stage; allowed values are private, iap, esp, public-whitelist, public or apigee"))
+		if p.Visibility == VisibilityUnknown || (p.Visibility != VisibilityPrivate && p.Visibility != VisibilityPublic && p.Visibility != VisibilityIAP && p.Visibility != VisibilityESP && p.Visibility != VisibilityESPv2 && p.Visibility != VisibilityPublicWhitelist && p.Visibility != VisibilityApigee && p.Visibility != VisibilityGateway) {
+			errors = append(errors, fmt.Errorf("Visibility property is required; set it via visibility property on this stage; allowed values are private, iap, esp, public-whitelist, public, apigee or gateway"))
 		}
 		if p.Visibility == VisibilityPublic {
 			warnings = append(warnings, "Visibility public is deprecated, please use esp or apigee.")
@@ -877,22 +1642,47 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 			errors = append(errors, fmt.Errorf("With visibility 'iap' property iapOauthClientSecret is required; set it via iapOauthClientSecret property on this stage"))
 		}
 
-		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && !p.UseGoogleCloudCredentials {
-			errors = append(errors, fmt.Errorf("With visibility 'esp' property useGoogleCloudCredentials is required; set useGoogleCloudCredentials: true on this stage"))
+		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && !p.UseGoogleCloudCredentials && !p.UsesWorkloadIdentity() {
+			errors = append(errors, fmt.Errorf("With visibility 'esp' property useGoogleCloudCredentials or workloadIdentity is required; set useGoogleCloudCredentials: true or workloadIdentity.enabled: true on this stage"))
 		}
-		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && (p.DisableServiceAccountKeyRotation == nil || !*p.DisableServiceAccountKeyRotation) {
+		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && p.UsesServiceAccountKey() && (p.DisableServiceAccountKeyRotation == nil || !*p.DisableServiceAccountKeyRotation) {
 			errors = append(errors, fmt.Errorf("With visibility 'esp' property disableServiceAccountKeyRotation is required; set disableServiceAccountKeyRotation: true on this stage"))
 		}
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && (p.EspEndpointsProjectID == "") {
 			errors = append(errors, fmt.Errorf("With visibility 'esp' property espEndpointsProjectID is required; provide id of the 'endpoints' project"))
 		}
-		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && p.EspOpenAPIYamlPath == "" {
+		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && p.EspOpenAPIYamlPath == "" && !p.UsesEspGRPC() {
 			errors = append(errors, fmt.Errorf("With visibility 'esp' property espOpenapiYamlPath is required; set espOpenapiYamlPath to the path towards openapi.yaml"))
 		}
+		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && (p.EspServiceConfigYamlPath != "") != (p.EspDescriptorSetPath != "") {
+			errors = append(errors, fmt.Errorf("With visibility 'esp' properties espServiceConfigYamlPath and espDescriptorSetPath need to be set together to deploy a grpc service"))
+		}
+		if p.UsesEspGRPC() && p.Visibility != VisibilityESPv2 {
+			errors = append(errors, fmt.Errorf("A grpc service config via espServiceConfigYamlPath is only supported with visibility 'esp-v2'"))
+		}
+		if p.UsesEspGRPC() && p.EspOpenAPIYamlPath != "" {
+			errors = append(errors, fmt.Errorf("With visibility 'esp-v2' set either espOpenapiYamlPath or espServiceConfigYamlPath, not both"))
+		}
+		if p.UsesEspGRPC() && p.Container.Protocol != ContainerProtocolGRPC && p.Container.Protocol != ContainerProtocolGRPCS {
+			errors = append(errors, fmt.Errorf("A grpc service config via espServiceConfigYamlPath requires container.protocol 'grpc' or 'grpcs'"))
+		}
 		if (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && len(p.Hosts) < 1 {
 			errors = append(errors, fmt.Errorf("With visibility 'esp' property at least one host is required. Set it via hosts array property on this stage"))
 		}
 
+		if p.Visibility == VisibilityGateway && p.Gateway.Name == "" {
+			errors = append(errors, fmt.Errorf("With visibility 'gateway' property name is required; set it via name property for gateway on this stage"))
+		}
+		if p.Visibility == VisibilityGateway && p.Gateway.CanaryWeight != nil && (*p.Gateway.CanaryWeight < 0 || *p.Gateway.CanaryWeight > 100) {
+			errors = append(errors, fmt.Errorf("With visibility 'gateway' property canaryweight needs to be between 0 and 100; set it via canaryweight property for gateway on this stage"))
+		}
+
+		for _, name := range []string{p.GetIngressControllerName(), p.GetInternalIngressControllerName(), p.GetApigeeIngressControllerName()} {
+			if _, ok := p.GetIngressController(name); !ok {
+				errors = append(errors, fmt.Errorf("Ingress controller %v is unknown; set it via ingresscontrollers property in the credential defaults or pick one of the existing profiles", name))
+			}
+		}
+
 		if p.Visibility == VisibilityApigee && p.Request.AuthSecret == "" {
 			errors = append(errors, fmt.Errorf("With visibility 'apigee' property authsecret is required; set it via authsecret property for request on this stage"))
 		}
@@ -956,6 +1746,8 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 	if p.Autoscale.CPUPercentage <= 0 {
 		errors = append(errors, fmt.Errorf("Autoscaling cpu percentage must be larger than zero; set it via autoscale.cpu property on this stage"))
 	}
+	errors = append(errors, p.validateAutoscaleMetrics()...)
+	errors = append(errors, p.validateAutoscaleBehavior()...)
 
 	// validate liveness params
 	if p.Container.LivenessProbe.Path == "" {
@@ -1010,6 +1802,9 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 	// check if openresty was defined as deprecated sidecar type
 	hasOpenrestySidecar := p.Sidecar.Type == SidecarTypeOpenresty
 
+	// validate declared sidecar types before the sidecars using them
+	errors = p.validateSidecarTypes(errors)
+
 	// validate sidecars params
 	for _, sidecar := range p.Sidecars {
 		errors = p.validateSidecar(sidecar, errors)
@@ -1018,21 +1813,50 @@ func (p *Params) ValidateRequiredProperties() (bool, []error, []string) {
 		}
 	}
 
+	if hasOpenrestySidecar && p.UsesMeshMTLS() {
+		errors = append(errors, fmt.Errorf("The openresty sidecar isn't needed with mesh.mtls, since the mesh already encrypts traffic between pods; remove it from the sidecars"))
+	}
+
+	// a gke managed certificate only lives on the load balancer, so the openresty sidecar and internal ingress need a certificate secret of their own
+	if p.Certificate.Provider == CertificateProviderGKEManaged && p.CertificateSecret == "" && (p.Kind == KindDeployment || p.Kind == KindStatefulset) && (hasOpenrestySidecar || len(p.InternalHosts) > 0) {
+		errors = append(errors, fmt.Errorf("Certificate provider gke-managed only attaches a certificate to the load balancer, leaving none for the openresty sidecar or internal hosts; set certificatesecret on this stage, or disable injecthttpproxysidecar and remove internalhosts"))
+	}
+
 	// openresty sidecar cannot be added in combination with port 443
 	if hasOpenrestySidecar && p.Container.Port == 443 {
 		errors = append(errors, fmt.Errorf("Container port can't be 443 if an openresty sidecar is injected"))
 	}
 
+	// validate protocol
+	if p.Container.Protocol != ContainerProtocolUnknown && p.Container.Protocol != ContainerProtocolHTTP && p.Container.Protocol != ContainerProtocolHTTP2 && p.Container.Protocol != ContainerProtocolGRPC && p.Container.Protocol != ContainerProtocolGRPCS {
+		errors = append(errors, fmt.Errorf("Container protocol %v is invalid; set container.protocol property on this stage to http, http2, grpc or grpcs", p.Container.Protocol))
+	}
+	if hasOpenrestySidecar && p.Container.Protocol != ContainerProtocolUnknown && p.Container.Protocol != ContainerProtocolHTTP {
+		errors = append(errors, fmt.Errorf("The openresty sidecar only proxies http/1.1; remove it from the sidecars for container.protocol %v", p.Container.Protocol))
+	}
+	if p.Container.Protocol == ContainerProtocolGRPC && p.UsesGCEIngress() && (p.Kind == KindDeployment || p.Kind == KindStatefulset) {
+		errors = append(errors, fmt.Errorf("The gce ingress controller can't send grpc without tls to the container; use container.protocol grpcs or an nginx ingress controller"))
+	}
+
 	// validate load balance algorithm
 	if p.Request.LoadBalanceAlgorithm != "" && p.Request.LoadBalanceAlgorithm != "ewma" && p.Request.LoadBalanceAlgorithm != "round_robin" {
 		errors = append(errors, fmt.Errorf("Load balance algorithm is invalid; leave it empty or set request.loadbalance property on this stage to 'ewma' or 'round_robin'"))
 	}
 
 	// check for visibility esp if openapi.yaml exists
-	if _, err := os.Stat(p.EspOpenAPIYamlPath); (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && os.IsNotExist(err) {
+	if _, err := os.Stat(p.EspOpenAPIYamlPath); (p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2) && !p.UsesEspGRPC() && os.IsNotExist(err) {
 		errors = append(errors, fmt.Errorf("When using visibility: esp make sure to set clone: true and have openapi.yaml available in the working directory"))
 	}
 
+	// check for visibility esp-v2 with grpc if the service config and descriptor set exist
+	if p.UsesEspGRPC() && p.EspDescriptorSetPath != "" {
+		for _, path := range []string{p.EspServiceConfigYamlPath, p.EspDescriptorSetPath} {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				errors = append(errors, fmt.Errorf("When using a grpc service config make sure to set clone: true and have %v available in the working directory", path))
+			}
+		}
+	}
+
 	return len(errors) == 0, errors, warnings
 }
 
@@ -1041,14 +1865,38 @@ func (p *Params) validateSidecar(sidecar *SidecarParams, errors []error) []error
 	case SidecarTypeOpenresty:
 		break
 	case SidecarTypeCloudSQLProxy:
-		if sidecar.DbInstanceConnectionName == "" {
-			errors = append(errors, fmt.Errorf("The name of the DB instance used by this Cloud SQL Proxy is required; set it via sidecar.dbinstanceconnectionname property on this stage"))
+		if sidecar.DbInstanceConnectionName == "" && len(sidecar.SQLProxyInstances) == 0 {
+			errors = append(errors, fmt.Errorf("The name of the DB instance used by this Cloud SQL Proxy is required; set it via sidecar.dbinstanceconnectionname or sidecar.sqlproxyinstances property on this stage"))
 		}
 		if sidecar.SQLProxyPort == 0 {
 			errors = append(errors, fmt.Errorf("The port on which the Cloud SQL Proxy listens is required; set it via sidecar.sqlproxyport property on this stage"))
 		}
+		ports := map[int]bool{}
+		for _, instance := range sidecar.SQLProxyInstances {
+			if instance == nil || instance.ConnectionName == "" {
+				errors = append(errors, fmt.Errorf("The connection name of each Cloud SQL Proxy instance is required; set it via sidecar.sqlproxyinstances[].connectionname property on this stage"))
+				continue
+			}
+			if instance.Port > 0 && ports[instance.Port] {
+				errors = append(errors, fmt.Errorf("Cloud SQL Proxy instance %v uses port %v which is already used by another instance; set a unique sidecar.sqlproxyinstances[].port", instance.ConnectionName, instance.Port))
+			}
+			ports[instance.Port] = true
+		}
+		switch sidecar.SQLProxyVersion {
+		case CloudSQLProxyVersionUnknown, CloudSQLProxyVersionV2:
+		case CloudSQLProxyVersionV1:
+			if sidecar.SQLProxyPrivateIP || sidecar.SQLProxyAutoIAMAuthn || sidecar.SQLProxyStructuredLogs {
+				errors = append(errors, fmt.Errorf("The sidecar.sqlproxyprivateip, sidecar.sqlproxyautoiamauthn and sidecar.sqlproxystructuredlogs properties are only supported by version v2 of the Cloud SQL Proxy; use a v2 image or set sidecar.sqlproxyversion to v2"))
+			}
+		default:
+			errors = append(errors, fmt.Errorf("The Cloud SQL Proxy version %v is not supported; set sidecar.sqlproxyversion to v1 or v2", sidecar.SQLProxyVersion))
+		}
 	case SidecarTypeUnknown:
 		errors = append(errors, fmt.Errorf("The sidecar type is empty; set a type"))
+	default:
+		if definition := p.GetSidecarTypeDefinition(sidecar.Type); definition != nil {
+			errors = definition.validateSidecar(sidecar, errors)
+		}
 	}
 
 	if sidecar.Image == "" {
@@ -1071,60 +1919,107 @@ func (p *Params) validateSidecar(sidecar *SidecarParams, errors []error) []error
 	return errors
 }
 
-// ReplaceSidecarTagsWithDigest replaces image tags for sidecars with a digest
-func (p *Params) ReplaceSidecarTagsWithDigest() {
+func (p *Params) validateAutoscaleMetrics() (errors []error) {
+	for i, m := range p.Autoscale.Metrics {
+		switch m.Type {
+		case AutoscaleMetricTypeCPU, AutoscaleMetricTypeMemory:
+			if (m.AverageUtilization > 0) == (m.AverageValue != "") {
+				errors = append(errors, fmt.Errorf("Autoscaling %v metric needs either averageutilization or averagevalue; set one of them via autoscale.metrics[%v] property on this stage", m.Type, i))
+			}
+		case AutoscaleMetricTypePods:
+			if m.Name == "" || m.AverageValue == "" {
+				errors = append(errors, fmt.Errorf("Autoscaling pods metric needs a name and averagevalue; set them via autoscale.metrics[%v] property on this stage", i))
+			}
+			if m.AverageUtilization > 0 {
+				errors = append(errors, fmt.Errorf("Autoscaling pods metric doesn't support averageutilization; use averagevalue instead in autoscale.metrics[%v] property on this stage", i))
+			}
+		case AutoscaleMetricTypeExternal:
+			if m.Name == "" || (m.Value != "") == (m.AverageValue != "") {
+				errors = append(errors, fmt.Errorf("Autoscaling external metric needs a name and either value or averagevalue; set them via autoscale.metrics[%v] property on this stage", i))
+			}
+			if m.AverageUtilization > 0 {
+				errors = append(errors, fmt.Errorf("Autoscaling external metric doesn't support averageutilization; use value or averagevalue instead in autoscale.metrics[%v] property on this stage", i))
+			}
+		default:
+			errors = append(errors, fmt.Errorf("Autoscaling metric type %v is unknown; set it via autoscale.metrics[%v].type property on this stage; allowed values are cpu, memory, pods or external", m.Type, i))
+		}
+		if m.AverageUtilization < 0 {
+			errors = append(errors, fmt.Errorf("Autoscaling metric averageutilization must be larger than zero; set it via autoscale.metrics[%v].averageutilization property on this stage", i))
+		}
+		if m.Type.IsResource() && (m.Name != "" || len(m.Selector) > 0 || m.Value != "") {
+			errors = append(errors, fmt.Errorf("Autoscaling %v metric doesn't support name, selector or value; remove them from autoscale.metrics[%v] property on this stage", m.Type, i))
+		}
+	}
 
-	// see if there's a sidecar of type openresty
-	for _, s := range p.Sidecars {
-		log.Info().Msgf("Replacing sidecar %v image tag with digest...", s.Type)
+	return
+}
 
-		if s.Image == "" {
-			continue
+func (p *Params) validateAutoscaleBehavior() (errors []error) {
+	for _, direction := range []string{"scaleup", "scaledown"} {
+		rules := p.Autoscale.Behavior.ScaleUp
+		if direction == "scaledown" {
+			rules = p.Autoscale.Behavior.ScaleDown
 		}
-
-		imageDigestParts := strings.Split(s.Image, "@")
-		if len(imageDigestParts) > 1 {
-			// already uses a digest, skip replacement
+		if rules == nil {
 			continue
 		}
-
-		imageParts := strings.Split(s.Image, ":")
-		repository := imageParts[0]
-		tag := "latest"
-		if len(imageParts) > 1 {
-			tag = imageParts[1]
+		if rules.StabilizationWindowSeconds != nil && (*rules.StabilizationWindowSeconds < 0 || *rules.StabilizationWindowSeconds > 3600) {
+			errors = append(errors, fmt.Errorf("Autoscaling %v stabilization window must be between 0 and 3600 seconds; set it via autoscale.behavior.%v.stabilizationwindowseconds property on this stage", direction, direction))
 		}
-
-		// get docker hub api token
-		tokenJSON := httpRequestBody("GET", fmt.Sprintf("https://auth.docker.io/token?scope=repository:%v:pull&service=registry.docker.io", repository), map[string]string{})
-		if tokenJSON == "" {
-			continue
+		switch rules.SelectPolicy {
+		case "", "Max", "Min", "Disabled":
+		default:
+			errors = append(errors, fmt.Errorf("Autoscaling %v select policy %v is unknown; set it via autoscale.behavior.%v.selectpolicy property on this stage; allowed values are Max, Min or Disabled", direction, rules.SelectPolicy, direction))
 		}
-
-		type TokenObject struct {
-			Token string `json:"token"`
+		for _, policy := range rules.Policies {
+			if policy.Type != "Pods" && policy.Type != "Percent" {
+				errors = append(errors, fmt.Errorf("Autoscaling %v policy type %v is unknown; set it via autoscale.behavior.%v.policies property on this stage; allowed values are Pods or Percent", direction, policy.Type, direction))
+			}
+			if policy.Value <= 0 {
+				errors = append(errors, fmt.Errorf("Autoscaling %v policy value must be larger than zero; set it via autoscale.behavior.%v.policies property on this stage", direction, direction))
+			}
+			if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > 1800 {
+				errors = append(errors, fmt.Errorf("Autoscaling %v policy period must be between 1 and 1800 seconds; set it via autoscale.behavior.%v.policies property on this stage", direction, direction))
+			}
 		}
+	}
 
-		tokenObject := TokenObject{}
-		err := json.Unmarshal([]byte(tokenJSON), &tokenObject)
-		if err != nil {
+	return
+}
+
+// ReplaceSidecarTagsWithDigest replaces image tags for sidecars with the digest returned by getDigest, and returns the sidecars it failed for
+func (p *Params) ReplaceSidecarTagsWithDigest(getDigest func(image string) (digest string, err error)) (errors []error) {
+
+	for _, s := range p.Sidecars {
+		if s.Image == "" {
 			continue
 		}
-		if tokenObject.Token == "" {
+
+		if strings.Contains(s.Image, "@") {
+			// already uses a digest, skip replacement
 			continue
 		}
 
-		digest := httpRequestHeader("HEAD", fmt.Sprintf("https://index.docker.io/v2/%v/manifests/%v", repository, tag), map[string]string{
-			"Accept":        "application/vnd.docker.distribution.manifest.v2+json",
-			"Authorization": fmt.Sprintf("Bearer %v", tokenObject.Token),
-		}, "Docker-Content-Digest")
+		log.Info().Msgf("Replacing sidecar %v image tag with digest...", s.Type)
 
-		if len(digest) == 0 {
+		digest, err := getDigest(s.Image)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("Failed replacing tag of sidecar %v image %v with digest: %w", s.Type, s.Image, err))
 			continue
 		}
 
-		s.Image = fmt.Sprintf("%v@%v", repository, digest)
+		image := trimImageTag(s.Image)
+		log.Info().Msgf("Successfully replaced image %v with digest %v...", s.Image, digest)
+		s.Image = fmt.Sprintf("%v@%v", image, digest)
+	}
+
+	return errors
+}
 
-		log.Info().Msgf("Successfully replaced tag %v with digest %v...", tag, digest)
+// trimImageTag removes the tag from an image, without mistaking a registry port for it
+func trimImageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
 	}
+	return image
 }
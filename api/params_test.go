@@ -2,6 +2,11 @@ package api
 
 import (
 	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -2493,262 +2498,2158 @@ func TestSetDefaults(t *testing.T) {
 
 		assert.Equal(t, 3, params.Request.VerifyDepth)
 	})
-}
-
-func TestValidateRequiredProperties(t *testing.T) {
 
-	t.Run("ReturnsFalseIfAppIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsCanaryReplicasTo1ForStatefulsets", func(t *testing.T) {
 
-		params := validParams
-		params.App = ""
+		params := Params{
+			Kind:           KindStatefulset,
+			CanaryReplicas: 0,
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, 1, params.CanaryReplicas)
 	})
 
-	t.Run("ReturnsTrueIfAppIsSet", func(t *testing.T) {
+	t.Run("KeepsCanaryReplicasIfSetForStatefulsets", func(t *testing.T) {
 
-		params := validParams
-		params.App = "myapp"
+		params := Params{
+			Kind:           KindStatefulset,
+			CanaryReplicas: 2,
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 2, params.CanaryReplicas)
 	})
 
-	t.Run("ReturnsFalseIfNamespaceIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsSharedConfigNameToAppForKindConfig", func(t *testing.T) {
 
-		params := validParams
-		params.Namespace = ""
+		params := Params{
+			Kind: KindConfig,
+			App:  "myconfig",
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "myconfig", params.SharedConfigName)
 	})
 
-	t.Run("ReturnsTrueIfNamespaceIsSet", func(t *testing.T) {
+	t.Run("DefaultsGatewayCanaryWeightTo10IfNotSet", func(t *testing.T) {
 
-		params := validParams
-		params.Namespace = "mynamespace"
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 10, *params.Gateway.CanaryWeight)
 	})
 
-	t.Run("ReturnsFalseIfImageRepositoryIsNotSet", func(t *testing.T) {
+	t.Run("KeepsGatewayCanaryWeightIfSetToZero", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageRepository = ""
+		canaryWeight := 0
+		params := Params{
+			Gateway: GatewayParams{
+				CanaryWeight: &canaryWeight,
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, 0, *params.Gateway.CanaryWeight)
 	})
 
-	t.Run("ReturnsTrueIfImageRepositoryIsSet", func(t *testing.T) {
+	t.Run("DefaultsInternalGatewayToGatewayIfInternalNameIsNotSet", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageRepository = "myrepository"
+		params := Params{
+			Gateway: GatewayParams{
+				Name:        "shared-gateway",
+				Namespace:   "gateways",
+				SectionName: "https",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "shared-gateway", params.Gateway.InternalName)
+		assert.Equal(t, "gateways", params.Gateway.InternalNamespace)
+		assert.Equal(t, "https", params.Gateway.InternalSectionName)
 	})
 
-	t.Run("ReturnsFalseIfImageNameIsNotSet", func(t *testing.T) {
+	t.Run("KeepsInternalGatewayIfInternalNameIsSet", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageName = ""
+		params := Params{
+			Gateway: GatewayParams{
+				Name:         "shared-gateway",
+				Namespace:    "gateways",
+				InternalName: "internal-gateway",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "internal-gateway", params.Gateway.InternalName)
+		assert.Equal(t, "", params.Gateway.InternalNamespace)
 	})
 
-	t.Run("ReturnsTrueIfImageNameIsSet", func(t *testing.T) {
+	t.Run("DefaultsMeshParamsIfIstioSidecarIsSpecified", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageName = "myimage"
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeIstio,
+				},
+			},
+			Request: RequestParams{
+				Timeout: "30s",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, *params.Mesh.MTLS)
+		assert.Equal(t, "30s", params.Mesh.Timeout)
+		assert.Equal(t, 2, params.Mesh.Retries.Attempts)
+		assert.Equal(t, "connect-failure,refused-stream,unavailable,cancelled,retriable-status-codes", params.Mesh.Retries.RetryOn)
+		assert.Equal(t, 10, params.Mesh.CanaryWeight)
+		assert.Equal(t, "", params.Mesh.OutlierDetection.Interval)
 	})
 
-	t.Run("ReturnsFalseIfImageTagIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsMeshOutlierDetectionIfConsecutive5xxErrorsIsSet", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageTag = ""
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeIstio,
+				},
+			},
+			Mesh: MeshParams{
+				OutlierDetection: MeshOutlierDetectionParams{
+					Consecutive5xxErrors: 5,
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "10s", params.Mesh.OutlierDetection.Interval)
+		assert.Equal(t, "30s", params.Mesh.OutlierDetection.BaseEjectionTime)
+		assert.Equal(t, 10, params.Mesh.OutlierDetection.MaxEjectionPercent)
 	})
 
-	t.Run("ReturnsTrueIfImageTagIsSet", func(t *testing.T) {
+	t.Run("DefaultsIstioSidecarResources", func(t *testing.T) {
 
-		params := validParams
-		params.Container.ImageTag = "1.0.0"
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeIstio,
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "100m", params.Sidecars[0].CPU.Request)
+		assert.Equal(t, "128Mi", params.Sidecars[0].Memory.Request)
+		assert.Equal(t, "256Mi", params.Sidecars[0].Memory.Limit)
 	})
 
-	t.Run("ReturnsFalseIfVisibilityIsNotSet", func(t *testing.T) {
+	t.Run("DoesNotInjectOpenrestySidecarIfMeshMTLSIsEnabled", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = ""
+		trueValue := true
+		params := Params{
+			Kind: KindDeployment,
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeIstio,
+				},
+			},
+			Mesh: MeshParams{
+				MTLS: &trueValue,
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, 1, len(params.Sidecars))
+		assert.Equal(t, SidecarTypeIstio, params.Sidecars[0].Type)
 	})
 
-	t.Run("ReturnsFalseIfVisibilityIsSetToUnsupportedValue", func(t *testing.T) {
+	t.Run("KeepsDeclaredOpenrestySidecarIfMeshMTLSIsEnabled", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = "everywhere"
+		trueValue := true
+		params := Params{
+			Kind: KindDeployment,
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeOpenresty,
+				},
+				{
+					Type: SidecarTypeIstio,
+				},
+			},
+			Mesh: MeshParams{
+				MTLS: &trueValue,
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, 2, len(params.Sidecars))
+		assert.Equal(t, SidecarTypeOpenresty, params.Sidecars[0].Type)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToPublic", func(t *testing.T) {
+	t.Run("InjectsESPSidecarIfMeshMTLSIsEnabled", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = VisibilityPublic
+		trueValue := true
+		params := Params{
+			Kind:       KindDeployment,
+			Visibility: VisibilityESP,
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeIstio,
+				},
+			},
+			Mesh: MeshParams{
+				MTLS: &trueValue,
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 2, len(params.Sidecars))
+		assert.Equal(t, SidecarTypeESP, params.Sidecars[1].Type)
+		assert.True(t, *params.InjectHTTPProxySidecar)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToPrivate", func(t *testing.T) {
+	t.Run("DefaultsNetworkPolicyParams", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = VisibilityPrivate
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, *params.NetworkPolicy.Enabled)
+		assert.Equal(t, []string{"ingress-nginx"}, params.NetworkPolicy.IngressControllerNamespaces)
+		assert.Equal(t, []string{"monitoring"}, params.NetworkPolicy.MetricsNamespaces)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToIAP", func(t *testing.T) {
+	t.Run("DefaultsCertificateProviderToLetsEncryptAnnotation", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = "iap"
-		params.IapOauthCredentialsClientID = "123123"
-		params.IapOauthCredentialsClientSecret = "somesecret"
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, CertificateProviderLetsEncryptAnnotation, params.Certificate.Provider)
+		assert.Equal(t, "", params.Certificate.Issuer)
 	})
 
-	t.Run("ReturnsTrueIfVisibilityIsSetToPublicWhitelist", func(t *testing.T) {
+	t.Run("DefaultsCertificateIssuerIfProviderIsCertManager", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = VisibilityPublicWhitelist
+		params := Params{
+			Certificate: CertificateParams{
+				Provider: CertificateProviderCertManager,
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, "letsencrypt", params.Certificate.Issuer)
+		assert.Equal(t, "ClusterIssuer", params.Certificate.IssuerKind)
 	})
 
-	t.Run("ReturnsFalseIfCpuRequestIsNotSet", func(t *testing.T) {
+	t.Run("DefaultsBackendConfigCDNCachePolicyIfCDNIsEnabled", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Request = ""
+		trueValue := true
+		params := Params{
+			BackendConfig: BackendConfigParams{
+				CDN: BackendConfigCDNParams{
+					Enabled: &trueValue,
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "CACHE_ALL_STATIC", params.BackendConfig.CDN.CacheMode)
+		assert.Equal(t, 3600, params.BackendConfig.CDN.DefaultTTL)
+		assert.Equal(t, 86400, params.BackendConfig.CDN.MaxTTL)
+		assert.True(t, *params.BackendConfig.CDN.IncludeQueryString)
 	})
 
-	t.Run("ReturnsTrueIfCpuRequestIsSet", func(t *testing.T) {
+	t.Run("DefaultsBackendConfigCDNTTLsIfCacheModeIsForceCacheAll", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Request = "100m"
+		trueValue := true
+		params := Params{
+			BackendConfig: BackendConfigParams{
+				CDN: BackendConfigCDNParams{
+					Enabled:   &trueValue,
+					CacheMode: "FORCE_CACHE_ALL",
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 3600, params.BackendConfig.CDN.DefaultTTL)
+		assert.Equal(t, 86400, params.BackendConfig.CDN.MaxTTL)
+		assert.Equal(t, 3600, params.BackendConfig.CDN.ClientTTL)
 	})
 
-	t.Run("ReturnsTrueIfCpuLimitIsNotSet", func(t *testing.T) {
+	t.Run("DoesNotDefaultBackendConfigCDNTTLsIfCacheModeIsUseOriginHeaders", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Limit = ""
+		trueValue := true
+		params := Params{
+			BackendConfig: BackendConfigParams{
+				CDN: BackendConfigCDNParams{
+					Enabled:   &trueValue,
+					CacheMode: "USE_ORIGIN_HEADERS",
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 0, params.BackendConfig.CDN.DefaultTTL)
+		assert.Equal(t, 0, params.BackendConfig.CDN.MaxTTL)
+		assert.Equal(t, 0, params.BackendConfig.CDN.ClientTTL)
+		assert.True(t, *params.BackendConfig.CDN.IncludeQueryString)
 	})
 
-	t.Run("ReturnsTrueIfCpuLimitIsSet", func(t *testing.T) {
+	t.Run("DefaultsRouteHostsAndRequestParamsToStageValues", func(t *testing.T) {
 
-		params := validParams
-		params.Container.CPU.Limit = "100m"
+		params := Params{
+			Hosts: []string{"myapp.example.com"},
+			Request: RequestParams{
+				Timeout: "120s",
+			},
+			Routes: []RouteParams{
+				{
+					Name: "admin",
+					Path: "/admin",
+					Request: RequestParams{
+						MaxBodySize: "1m",
+					},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, []string{"myapp.example.com"}, params.Routes[0].Hosts)
+		assert.Equal(t, "120s", params.Routes[0].Request.Timeout)
+		assert.Equal(t, "1m", params.Routes[0].Request.MaxBodySize)
+		assert.Equal(t, "4k", params.Routes[0].Request.ProxyBufferSize)
+	})
+	t.Run("DefaultsTrustedIPRangesProvidersToCloudflareV4IfTrustedIPRangesAreEmpty", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 1, len(params.TrustedIPRangesProviders))
+		assert.Equal(t, TrustedIPRangesProviderCloudflareV4, params.TrustedIPRangesProviders[0].Type)
+	})
+
+	t.Run("DoesNotDefaultTrustedIPRangesProvidersIfTrustedIPRangesAreSet", func(t *testing.T) {
+
+		params := Params{
+			TrustedIPRanges: []string{
+				"0.0.0.0/0",
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 0, len(params.TrustedIPRangesProviders))
+	})
+
+	t.Run("SetsTrustedIPRangesFromBundledRangesOfAllProviders", func(t *testing.T) {
+
+		params := Params{
+			TrustedIPRanges: []string{
+				"0.0.0.0/0",
+			},
+			TrustedIPRangesProviders: []TrustedIPRangesProviderParams{
+				{Type: TrustedIPRangesProviderCloudflareV6},
+				{Type: TrustedIPRangesProviderList, Ranges: []string{"10.0.0.0/8"}},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 8, len(params.TrustedIPRanges))
+		assert.Equal(t, "2400:cb00::/32", params.TrustedIPRanges[0])
+		assert.Equal(t, "10.0.0.0/8", params.TrustedIPRanges[7])
+	})
+
+	t.Run("DefaultsTrustedIPRangesCacheTTLTo24HoursIfEmpty", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "24h", params.TrustedIPRangesCache.TTL)
+		assert.Equal(t, ".estafette-extension-gke/trustedips", params.TrustedIPRangesCache.Directory)
+	})
+	t.Run("DoesNotDefaultDigestCacheIfDirectoryIsEmpty", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "", params.DigestCache.Directory)
+		assert.Equal(t, "", params.DigestCache.TTL)
+	})
+	t.Run("DefaultsDigestCacheTTLTo24HoursIfDirectoryIsSet", func(t *testing.T) {
+
+		params := Params{
+			DigestCache: DigestCacheParams{
+				Directory: "/cache/digests",
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "24h", params.DigestCache.TTL)
+	})
+	t.Run("DefaultsContainerProtocolToHTTPIfEmpty", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, ContainerProtocolHTTP, params.Container.Protocol)
+	})
+
+	t.Run("DoesNotInjectOpenrestySidecarIfContainerProtocolIsGRPC", func(t *testing.T) {
+
+		params := Params{
+			Kind: KindDeployment,
+			Container: ContainerParams{
+				Protocol: ContainerProtocolGRPC,
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		for _, sidecar := range params.Sidecars {
+			assert.NotEqual(t, SidecarTypeOpenresty, sidecar.Type)
+		}
+	})
+	t.Run("DefaultsWorkloadIdentityEnabledToFalse", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.False(t, *params.WorkloadIdentity.Enabled)
+	})
+	t.Run("DoesNotDefaultEspOpenAPIYamlPathWhenUsingGRPCServiceConfig", func(t *testing.T) {
+
+		params := Params{
+			Visibility:               VisibilityESPv2,
+			EspServiceConfigYamlPath: "api_config.yaml",
+			EspDescriptorSetPath:     "api_descriptor.pb",
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "", params.EspOpenAPIYamlPath)
+	})
+	t.Run("DefaultsImageCheckToVerifyWithoutPinning", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.True(t, *params.Container.ImageCheck.Verify)
+		assert.False(t, *params.Container.ImageCheck.Pin)
+		assert.False(t, *params.Container.ImageCheck.InitContainers)
+	})
+
+	t.Run("DefaultsSQLProxyInstancesToDbInstanceConnectionNameOnSQLProxyPort", func(t *testing.T) {
+
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:                     SidecarTypeCloudSQLProxy,
+					DbInstanceConnectionName: "project:europe-west1:instance",
+					SQLProxyPort:             5043,
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 1, len(params.Sidecars[0].SQLProxyInstances))
+		assert.Equal(t, "project:europe-west1:instance", params.Sidecars[0].SQLProxyInstances[0].ConnectionName)
+		assert.Equal(t, 5043, params.Sidecars[0].SQLProxyInstances[0].Port)
+	})
+
+	t.Run("DefaultsSQLProxyInstancePortsToConsecutivePortsFromSQLProxyPort", func(t *testing.T) {
+
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeCloudSQLProxy,
+					SQLProxyInstances: []*SQLProxyInstanceParams{
+						{
+							ConnectionName: "project:europe-west1:instance-a",
+						},
+						{
+							ConnectionName: "project:europe-west1:instance-b",
+							Port:           3306,
+						},
+						{
+							ConnectionName: "project:europe-west1:instance-c",
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 5432, params.Sidecars[0].SQLProxyInstances[0].Port)
+		assert.Equal(t, 3306, params.Sidecars[0].SQLProxyInstances[1].Port)
+		assert.Equal(t, 5434, params.Sidecars[0].SQLProxyInstances[2].Port)
+	})
+
+	t.Run("DefaultsSQLProxyVersionToV1ForDefaultImage", func(t *testing.T) {
+
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type: SidecarTypeCloudSQLProxy,
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "eu.gcr.io/cloudsql-docker/gce-proxy:1.21.0", params.Sidecars[0].Image)
+		assert.Equal(t, CloudSQLProxyVersionV1, params.Sidecars[0].SQLProxyVersion)
+		assert.Equal(t, 0, params.Sidecars[0].SQLProxyHealthCheckPort)
+	})
+
+	t.Run("DefaultsImageToV2ImageAndHealthCheckPortTo9801IfSQLProxyVersionIsV2", func(t *testing.T) {
+
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:            SidecarTypeCloudSQLProxy,
+					SQLProxyVersion: CloudSQLProxyVersionV2,
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.14.1", params.Sidecars[0].Image)
+		assert.Equal(t, CloudSQLProxyVersionV2, params.Sidecars[0].SQLProxyVersion)
+		assert.Equal(t, 9801, params.Sidecars[0].SQLProxyHealthCheckPort)
+	})
+
+	t.Run("DefaultsSQLProxyVersionToV1ForV1ImageTag", func(t *testing.T) {
+
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:  SidecarTypeCloudSQLProxy,
+					Image: "eu.gcr.io/cloudsql-docker/gce-proxy:1.21.0",
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, CloudSQLProxyVersionV1, params.Sidecars[0].SQLProxyVersion)
+		assert.Equal(t, 0, params.Sidecars[0].SQLProxyHealthCheckPort)
+	})
+
+	t.Run("KeepsSQLProxyVersionIfSet", func(t *testing.T) {
+
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:            SidecarTypeCloudSQLProxy,
+					Image:           "my-registry.example.com/cloudsql/proxy:stable",
+					SQLProxyVersion: CloudSQLProxyVersionV1,
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, CloudSQLProxyVersionV1, params.Sidecars[0].SQLProxyVersion)
+	})
+
+	t.Run("DefaultsDeclaredSidecarTypeImageResourcesEnvAndProperties", func(t *testing.T) {
+
+		params := Params{
+			SidecarTypes: []*SidecarTypeDefinition{
+				{
+					Type:  "logshipper",
+					Image: "fluent/fluent-bit:3.0.7",
+					CPU: CPUParams{
+						Request: "20m",
+					},
+					EnvironmentVariables: map[string]interface{}{
+						"FLB_LOG_LEVEL": "info",
+					},
+					Properties: map[string]interface{}{
+						"logpath": "/var/log/app",
+					},
+				},
+			},
+			Sidecars: []*SidecarParams{
+				{
+					Type: "logshipper",
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "fluent/fluent-bit:3.0.7", params.Sidecars[0].Image)
+		assert.Equal(t, "20m", params.Sidecars[0].CPU.Request)
+		assert.Equal(t, "30Mi", params.Sidecars[0].Memory.Request)
+		assert.Equal(t, "info", params.Sidecars[0].EnvironmentVariables["FLB_LOG_LEVEL"])
+		assert.Equal(t, "/var/log/app", params.Sidecars[0].CustomProperties["logpath"])
+	})
+
+	t.Run("KeepsDeclaredSidecarTypeValuesSetOnSidecar", func(t *testing.T) {
+
+		params := Params{
+			SidecarTypes: []*SidecarTypeDefinition{
+				{
+					Type:  "logshipper",
+					Image: "fluent/fluent-bit:3.0.7",
+					EnvironmentVariables: map[string]interface{}{
+						"FLB_LOG_LEVEL": "info",
+					},
+					Properties: map[string]interface{}{
+						"logpath": "/var/log/app",
+					},
+				},
+			},
+			Sidecars: []*SidecarParams{
+				{
+					Type:  "logshipper",
+					Image: "fluent/fluent-bit:3.1.0",
+					EnvironmentVariables: map[string]interface{}{
+						"FLB_LOG_LEVEL": "debug",
+					},
+					CustomProperties: map[string]interface{}{
+						"logpath": "/logs",
+					},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, "fluent/fluent-bit:3.1.0", params.Sidecars[0].Image)
+		assert.Equal(t, "debug", params.Sidecars[0].EnvironmentVariables["FLB_LOG_LEVEL"])
+		assert.Equal(t, "/logs", params.Sidecars[0].CustomProperties["logpath"])
+	})
+
+	t.Run("DefaultsNativeSidecarsToAutoIfNotSet", func(t *testing.T) {
+
+		params := Params{
+			Kind: KindJob,
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, NativeSidecarsModeAuto, params.NativeSidecars)
+	})
+
+	t.Run("DefaultsTracingToJaegerWithSamplingRatios", func(t *testing.T) {
+
+		params := Params{}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, TracingProviderJaeger, params.Tracing.Provider)
+		assert.Equal(t, "0.001", params.Tracing.SamplingRatio)
+		assert.Equal(t, "0.1", params.Tracing.CanarySamplingRatio)
+	})
+
+	t.Run("KeepsTracingProviderIfSet", func(t *testing.T) {
+
+		params := Params{
+			Tracing: TracingParams{
+				Provider:      TracingProviderOpenTelemetry,
+				SamplingRatio: "0.05",
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, TracingProviderOpenTelemetry, params.Tracing.Provider)
+		assert.Equal(t, "0.05", params.Tracing.SamplingRatio)
+	})
+
+	t.Run("DefaultsAutoscaleCPUMetricAverageUtilizationToCPUPercentage", func(t *testing.T) {
+
+		params := Params{
+			Autoscale: AutoscaleParams{
+				CPUPercentage: 65,
+				Metrics: []*AutoscaleMetricParams{
+					{
+						Type: AutoscaleMetricTypeCPU,
+					},
+					{
+						Type:         AutoscaleMetricTypeMemory,
+						AverageValue: "512Mi",
+					},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 65, params.Autoscale.Metrics[0].AverageUtilization)
+		assert.Equal(t, 0, params.Autoscale.Metrics[1].AverageUtilization)
+	})
+
+	t.Run("DefaultsAutoscaleBehaviorPolicyPeriodSecondsTo60", func(t *testing.T) {
+
+		params := Params{
+			Autoscale: AutoscaleParams{
+				Behavior: AutoscaleBehaviorParams{
+					ScaleUp: &AutoscaleScalingRulesParams{
+						Policies: []*AutoscalePolicyParams{
+							{
+								Type:  "Percent",
+								Value: 100,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+
+		assert.Equal(t, 60, params.Autoscale.Behavior.ScaleUp.Policies[0].PeriodSeconds)
+	})
+}
+
+func TestValidateRequiredProperties(t *testing.T) {
+
+	t.Run("ReturnsFalseIfAppIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.App = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfAppIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.App = "myapp"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfNamespaceIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Namespace = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfNamespaceIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Namespace = "mynamespace"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfImageRepositoryIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageRepository = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfImageRepositoryIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageRepository = "myrepository"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfImageNameIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageName = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfImageNameIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageName = "myimage"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfImageTagIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageTag = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfImageTagIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ImageTag = "1.0.0"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfVisibilityIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfVisibilityIsSetToUnsupportedValue", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = "everywhere"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfVisibilityIsSetToPublic", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityPublic
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfVisibilityIsSetToPrivate", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityPrivate
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfVisibilityIsSetToIAP", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = "iap"
+		params.IapOauthCredentialsClientID = "123123"
+		params.IapOauthCredentialsClientSecret = "somesecret"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfVisibilityIsSetToPublicWhitelist", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityPublicWhitelist
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfCpuRequestIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.CPU.Request = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfCpuRequestIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.CPU.Request = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfCpuLimitIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.CPU.Limit = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfCpuLimitIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.CPU.Limit = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
 	t.Run("ReturnsFalseIfMemoryRequestIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Request = ""
+		params.Container.Memory.Request = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMemoryRequestIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Memory.Request = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMemoryLimitIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Memory.Limit = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMemoryLimitIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Memory.Limit = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfContainerPortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfContainerPortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfHostsAreNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hosts = []string{}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfOneOrMoreHostsAreSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hosts = []string{"gke.estafette.io"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfOneOrMoreUppercaseHostsAreSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hosts = []string{"GKE.ESTAFETTE.IO"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfHostHasLabelsLongerThan63Characters", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.io"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfHostIsLongerThan253Characters", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hosts = []string{"ab.abcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.io"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Hosts = []string{"gke_site.estafette.io"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfInternalHostsAreNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.InternalHosts = []string{}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfOneOrMoreInternalHostsAreSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.InternalHosts = []string{"ci.estafette.internal"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfOneOrMoreUppercaseInternalHostsAreSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.InternalHosts = []string{"CI.ESTAFETTE.INTERNAL"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfInternalHostHasLabelsLongerThan63Characters", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.InternalHosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.internal"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfInternalHostIsLongerThan253Characters", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.InternalHosts = []string{"abcdefghijklmnopqrstuvw.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.internal"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfInternalHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.InternalHosts = []string{"gke_site.estafette.internal"}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleMinReplicasIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.MinReplicas = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfAutoscaleMinReplicasIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.MinReplicas = 5
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleMaxReplicasIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.MaxReplicas = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfAutoscaleMaxReplicasIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.MaxReplicas = 15
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfAutoscaleCPUPercentageIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.CPUPercentage = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfAutoscaleCPUPercentageIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Autoscale.CPUPercentage = 35
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfLivenessPathIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLivenessPathIsNotEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.Path = "/liveness"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfLivenessProbePortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLivenessProbePortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfLivenessInitialDelaySecondsIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.InitialDelaySeconds = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLivenessInitialDelaySecondsIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.InitialDelaySeconds = 30
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfLivenessTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.TimeoutSeconds = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLivenessTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.LivenessProbe.TimeoutSeconds = 2
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfReadinessProbePathIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessProbePathIsNotEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Path = "/readiness"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfReadinessProbePortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessProbePortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfReadinessProbeTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.TimeoutSeconds = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfReadinessProbeTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.ReadinessProbe.TimeoutSeconds = 2
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsPathIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPathIsNotEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Path = "/metrics"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPathIsEmptyButScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+		params.Container.Metrics.Path = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsPortIsZeroOrLess", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPortIsLargerThanZero", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Port = 5000
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsPortIsZeroOrLessButScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+		params.Container.Metrics.Port = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfMetricsScrapeIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = nil
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsScrapeIsTrue", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &trueValue
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfMetricsScrapeIsFalse", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Metrics.Scrape = &falseValue
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseSidecarTypeIsEmpty", func(t *testing.T) {
+
+		params := validParams
+
+		params.Sidecars = []*SidecarParams{
+			&SidecarParams{
+				Type:  "",
+				Image: "docker",
+				CPU: CPUParams{
+					Request: "10m",
+					Limit:   "50m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "50Mi",
+				},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarTypeIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = SidecarTypeOpenresty
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarImageIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Image = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarImageIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Image = "estafette/openresty-sidecar:1.13.6.2-alpine"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarCpuRequestIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Request = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarCpuRequestIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Request = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarCpuLimitIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Limit = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarCpuLimitIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.CPU.Limit = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarMemoryRequestIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Request = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarMemoryRequestIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Request = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSidecarMemoryLimitIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Limit = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfSidecarMemoryLimitIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Memory.Limit = "100m"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfSqlProxyInstanceNameNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.DbInstanceConnectionName = ""
+		params.Sidecar.SQLProxyPort = 8080
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
+	})
+
+	t.Run("ReturnsFalseIfSqlProxyPortNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.DbInstanceConnectionName = "instance"
+		params.Sidecar.SQLProxyPort = 0
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
+	})
+
+	t.Run("ReturnsTrueIfSqlProxyProperlyConfigured", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.DbInstanceConnectionName = "instance"
+		params.Sidecar.SQLProxyPort = 8080
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsWarningIfSidecarFieldUsed", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{
+			Type:  SidecarTypeOpenresty,
+			Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
+			CPU: CPUParams{
+				Request: "10m",
+				Limit:   "50m",
+			},
+			Memory: MemoryParams{
+				Request: "10Mi",
+				Limit:   "50Mi",
+			},
+		}
+
+		// act
+		valid, errors, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 1, len(warnings))
+	})
+
+	t.Run("NoWarningRetuendIfSidecarsCollectionUsed", func(t *testing.T) {
+
+		params := validParams
+		params.Sidecar = SidecarParams{
+			Type: "",
+		}
+
+		params.Sidecars = []*SidecarParams{
+			&SidecarParams{
+				Type:  SidecarTypeOpenresty,
+				Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
+				CPU: CPUParams{
+					Request: "10m",
+					Limit:   "50m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "50Mi",
+				},
+			},
+		}
+
+		// act
+		valid, errors, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+		assert.Equal(t, 0, len(warnings))
+	})
+
+	t.Run("ReturnsFalseIfBasepathIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Basepath = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfBasepathIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.Basepath = "/"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxSurge = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfRollingUpdateMaxSurgeIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxSurge = "25%"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfRollingUpdateMaxUnavailableIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxUnavailable = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfRollingUpdateMaxUnavailableIsSet", func(t *testing.T) {
+
+		params := validParams
+		params.RollingUpdate.MaxUnavailable = "25%"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfScheduleIsNotSetAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindCronJob
+		params.Schedule = ""
+		params.ConcurrencyPolicy = "Allow"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfConcurrencyPolicyIsInvalidAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindCronJob
+		params.Schedule = "*/5 * * * *"
+		params.ConcurrencyPolicy = "InvalidPolicy"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfScheduleIsSetAndConcurrencyPolicyIsValidAndKindIsCronjob", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindCronJob
+		params.Schedule = "*/5 * * * *"
+		params.ConcurrencyPolicy = "Allow"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfPodManagementPolicyIsInvalidAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.PodManagementPolicy = "InvalidPolicy"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfPodManagementPolicyIsValidAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.PodManagementPolicy = "Parallel"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageClassIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageClass = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageClassIsSetAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageClass = "standard"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageSizeIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageSize = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfStorageSizeIsSetAndKindIsStatefulset", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindStatefulset
+		params.StorageSize = "1Gi"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseIfLoadBalanceAlgorithmIsNonValidValue", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = "leastconn"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEmpty", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = ""
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEwma", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = "ewma"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsRoundRobin", func(t *testing.T) {
+
+		params := validParams
+		params.Request.LoadBalanceAlgorithm = "round_robin"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
+	})
+
+	t.Run("ReturnsFalseAuthSecretIsNotSetForVisibilityApigee", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityApigee
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.Equal(t, 1, len(errors))
+	})
+
+	t.Run("WarnOnUsingVisibilityPublic", func(t *testing.T) {
+
+		params := validParams
+		params.Visibility = VisibilityPublic
+
+		// act
+		valid, _, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid)
+		assert.Equal(t, 1, len(warnings))
+	})
+
+	t.Run("ReturnsFalseIfEspEndpointsProjectIDNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityESP
+		params.EspEndpointsProjectID = ""
+		error_string := "With visibility 'esp' property espEndpointsProjectID is required; provide id of the 'endpoints' project"
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+		assert.False(t, valid)
+		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
+	})
+
+	t.Run("ReturnsFalseIfVisibilityIsGatewayAndGatewayNameIsNotSet", func(t *testing.T) {
+
+		params := validParams
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityGateway
+		canaryWeight := 10
+		params.Gateway = GatewayParams{
+			CanaryWeight: &canaryWeight,
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2757,10 +4658,16 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMemoryRequestIsSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfVisibilityIsGatewayAndGatewayNameIsSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Request = "100m"
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityGateway
+		canaryWeight := 10
+		params.Gateway = GatewayParams{
+			Name:         "shared-gateway",
+			CanaryWeight: &canaryWeight,
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2769,10 +4676,16 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfMemoryLimitIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfVisibilityIsGatewayAndCanaryWeightIsLargerThan100", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Memory.Limit = ""
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityGateway
+		canaryWeight := 101
+		params.Gateway = GatewayParams{
+			Name:         "shared-gateway",
+			CanaryWeight: &canaryWeight,
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2781,10 +4694,35 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMemoryLimitIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfNetworkPolicyIsEnabledAndCIDRIsInvalid", func(t *testing.T) {
+
+		trueValue := true
+		params := validParams
+		params.NetworkPolicy = NetworkPolicyParams{
+			Enabled: &trueValue,
+			Egress: NetworkPolicyEgressParams{
+				CIDRs: []string{"10.0.0.0/33"},
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsTrueIfNetworkPolicyIsEnabledAndCIDRsAreValid", func(t *testing.T) {
 
+		trueValue := true
 		params := validParams
-		params.Container.Memory.Limit = "100m"
+		params.NetworkPolicy = NetworkPolicyParams{
+			Enabled: &trueValue,
+			CIDRs:   []string{"10.0.0.0/8"},
+			Egress: NetworkPolicyEgressParams{
+				CIDRs: []string{"192.168.0.0/16"},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2793,10 +4731,11 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfContainerPortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfIngressControllerIsUnknown", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Port = 0
+		params.Kind = KindDeployment
+		params.IngressController = "traefik"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2805,10 +4744,18 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfContainerPortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfIngressControllerIsDefinedInIngressControllers", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Port = 5000
+		params.Kind = KindDeployment
+		params.IngressControllers = []IngressControllerParams{
+			{
+				Name:             "traefik",
+				Class:            "traefik",
+				AnnotationPrefix: "traefik.ingress.kubernetes.io/",
+			},
+		}
+		params.IngressController = "traefik"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2817,11 +4764,28 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfHostsAreNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfCertificateProviderIsUnknown", func(t *testing.T) {
+
+		params := validParams
+		params.Certificate = CertificateParams{
+			Provider: "vault",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfCertificateProviderIsGKEManagedAndVisibilityIsNotIap", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.Hosts = []string{}
+		params.Visibility = VisibilityPrivate
+		params.Certificate = CertificateParams{
+			Provider: CertificateProviderGKEManaged,
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2830,37 +4794,71 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfCertificateProviderIsGKEManagedWithOpenrestySidecarAndNoCertificateSecret", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.Hosts = []string{"gke.estafette.io"}
+		params.Visibility = VisibilityIAP
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "def"
+		params.Certificate = CertificateParams{
+			Provider: CertificateProviderGKEManaged,
+		}
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:  SidecarTypeOpenresty,
+				Image: "estafette/openresty-sidecar:1.13.6.1-alpine",
+				CPU: CPUParams{
+					Request: "10m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "50Mi",
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreUppercaseHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfCertificateProviderIsGKEManagedWithInternalHostsAndNoCertificateSecret", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.Hosts = []string{"GKE.ESTAFETTE.IO"}
+		params.Visibility = VisibilityIAP
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "def"
+		params.InternalHosts = []string{"myapp.internal.example.com"}
+		params.Certificate = CertificateParams{
+			Provider: CertificateProviderGKEManaged,
+		}
+		params.Sidecars = []*SidecarParams{}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfHostHasLabelsLongerThan63Characters", func(t *testing.T) {
+	t.Run("ReturnsFalseIfBackendConfigCDNIsEnabledAndVisibilityIsIap", func(t *testing.T) {
 
+		trueValue := true
 		params := validParams
 		params.Kind = KindDeployment
-		params.Hosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.io"}
+		params.Visibility = VisibilityIAP
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "def"
+		params.BackendConfig = BackendConfigParams{
+			CDN: BackendConfigCDNParams{
+				Enabled:   &trueValue,
+				CacheMode: "CACHE_ALL_STATIC",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2869,11 +4867,18 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfHostIsLongerThan253Characters", func(t *testing.T) {
+	t.Run("ReturnsFalseIfBackendConfigCDNTTLsAreSetWithCacheModeUseOriginHeaders", func(t *testing.T) {
 
+		trueValue := true
 		params := validParams
 		params.Kind = KindDeployment
-		params.Hosts = []string{"ab.abcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.io"}
+		params.BackendConfig = BackendConfigParams{
+			CDN: BackendConfigCDNParams{
+				Enabled:    &trueValue,
+				CacheMode:  "USE_ORIGIN_HEADERS",
+				DefaultTTL: 600,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2882,11 +4887,37 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
+	t.Run("ReturnsFalseIfBackendConfigLoggingSampleRateIsLargerThanOne", func(t *testing.T) {
+
+		sampleRate := 1.5
+		params := validParams
+		params.BackendConfig = BackendConfigParams{
+			Logging: BackendConfigLoggingParams{
+				SampleRate: &sampleRate,
+			},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfRoutesAreUsedWithVisibilityIAP", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.Hosts = []string{"gke_site.estafette.io"}
+		params.Visibility = VisibilityIAP
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "def"
+		params.Routes = []RouteParams{
+			{
+				Name:  "admin",
+				Hosts: params.Hosts,
+				Path:  "/admin",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2895,37 +4926,56 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfInternalHostsAreNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfRoutePortIsNotAnAdditionalPort", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.InternalHosts = []string{}
+		params.Routes = []RouteParams{
+			{
+				Name:  "admin",
+				Hosts: params.Hosts,
+				Path:  "/admin",
+				Port:  "admin",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreInternalHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfRouteHostIsNotInHosts", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.InternalHosts = []string{"ci.estafette.internal"}
+		params.Routes = []RouteParams{
+			{
+				Name:  "admin",
+				Hosts: []string{"other.example.com"},
+				Path:  "/admin",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfOneOrMoreUppercaseInternalHostsAreSet", func(t *testing.T) {
+	t.Run("ReturnsTrueIfRoutesAreValid", func(t *testing.T) {
 
 		params := validParams
 		params.Kind = KindDeployment
-		params.InternalHosts = []string{"CI.ESTAFETTE.INTERNAL"}
+		params.Routes = []RouteParams{
+			{
+				Name:  "admin",
+				Hosts: params.Hosts,
+				Path:  "/admin",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2933,12 +4983,26 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, valid)
 		assert.True(t, len(errors) == 0)
 	})
+	t.Run("ReturnsFalseIfTrustedIPRangesProviderIsUnknown", func(t *testing.T) {
 
-	t.Run("ReturnsFalseIfInternalHostHasLabelsLongerThan63Characters", func(t *testing.T) {
+		params := validParams
+		params.TrustedIPRangesProviders = []TrustedIPRangesProviderParams{
+			{Type: "akamai"},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfTrustedIPRangesProviderOfTypeURLHasNoURL", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindDeployment
-		params.InternalHosts = []string{"abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyzabcdefghijkl.estafette.internal"}
+		params.TrustedIPRangesProviders = []TrustedIPRangesProviderParams{
+			{Type: TrustedIPRangesProviderURL},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2947,11 +5011,12 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfInternalHostIsLongerThan253Characters", func(t *testing.T) {
+	t.Run("ReturnsFalseIfTrustedIPRangesProviderOfTypeListHasInvalidCIDR", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindDeployment
-		params.InternalHosts = []string{"abcdefghijklmnopqrstuvw.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.abcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstuvwxyz.estafette.internal"}
+		params.TrustedIPRangesProviders = []TrustedIPRangesProviderParams{
+			{Type: TrustedIPRangesProviderList, Ranges: []string{"10.0.0.0/8", "10.0.0.1"}},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2960,11 +5025,11 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfInternalHostHasOtherCharacterThanAlphaNumericOrHyphen", func(t *testing.T) {
+	t.Run("ReturnsFalseIfTrustedIPRangeIsNotACIDR", func(t *testing.T) {
 
 		params := validParams
-		params.Kind = KindDeployment
-		params.InternalHosts = []string{"gke_site.estafette.internal"}
+		params.TrustedIPRanges = []string{"103.21.244.0/22", "103.22.200.0"}
+		params.TrustedIPRangesProviders = []TrustedIPRangesProviderParams{}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2973,10 +5038,13 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfAutoscaleMinReplicasIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfDigestCacheTTLIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MinReplicas = 0
+		params.DigestCache = DigestCacheParams{
+			Directory: "/cache/digests",
+			TTL:       "1 day",
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -2985,22 +5053,38 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfAutoscaleMinReplicasIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfTrustedIPRangesCacheTTLIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MinReplicas = 5
+		params.TrustedIPRangesCache = TrustedIPRangesCacheParams{
+			TTL: "1 day",
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfAutoscaleMaxReplicasIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsTrueIfTrustedIPRangesProvidersAreValid", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MaxReplicas = 0
+		params.TrustedIPRangesProviders = []TrustedIPRangesProviderParams{
+			{Type: TrustedIPRangesProviderCloudflareV4},
+			{Type: TrustedIPRangesProviderURL, URL: "https://example.com/ips"},
+			{Type: TrustedIPRangesProviderList, Ranges: []string{"10.0.0.0/8"}},
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.True(t, valid, errors)
+	})
+	t.Run("ReturnsFalseIfContainerProtocolIsInvalid", func(t *testing.T) {
+
+		params := validParams
+		params.Container.Protocol = "websocket"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3009,22 +5093,41 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfAutoscaleMaxReplicasIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfOpenrestySidecarIsUsedWithContainerProtocolGRPC", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.MaxReplicas = 15
+		params.Container.Protocol = ContainerProtocolGRPC
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:  SidecarTypeOpenresty,
+				Image: "estafette/openresty-sidecar:1.13.6.1-alpine",
+				CPU: CPUParams{
+					Request: "10m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "50Mi",
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfAutoscaleCPUPercentageIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfContainerProtocolIsGRPCWithVisibilityIap", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.CPUPercentage = 0
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityIAP
+		params.IapOauthCredentialsClientID = "abc"
+		params.IapOauthCredentialsClientSecret = "def"
+		params.Container.Protocol = ContainerProtocolGRPC
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3033,154 +5136,289 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfAutoscaleCPUPercentageIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfContainerProtocolIsGRPCS", func(t *testing.T) {
 
 		params := validParams
-		params.Autoscale.CPUPercentage = 35
+		params.Container.Protocol = ContainerProtocolGRPCS
+		params.Sidecar = SidecarParams{}
+		params.Sidecars = []*SidecarParams{}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.True(t, valid, errors)
+	})
+	t.Run("ReturnsFalseIfWorkloadIdentityIsEnabledWithoutGCPServiceAccount", func(t *testing.T) {
+
+		trueValue := true
+		params := validParams
+		params.WorkloadIdentity = WorkloadIdentityParams{
+			Enabled: &trueValue,
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsFalseIfWorkloadIdentityGCPServiceAccountIsNotAnEmailAddress", func(t *testing.T) {
+
+		trueValue := true
+		params := validParams
+		params.WorkloadIdentity = WorkloadIdentityParams{
+			Enabled:           &trueValue,
+			GCPServiceAccount: "myapp",
+		}
+
+		// act
+		valid, errors, _ := params.ValidateRequiredProperties()
+
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
+	})
+
+	t.Run("ReturnsWarningIfWorkloadIdentityIsEnabledWithUseGoogleCloudCredentials", func(t *testing.T) {
+
+		trueValue := true
+		params := validParams
+		params.UseGoogleCloudCredentials = true
+		params.WorkloadIdentity = WorkloadIdentityParams{
+			Enabled:           &trueValue,
+			GCPServiceAccount: "myapp@myproject.iam.gserviceaccount.com",
+		}
+
+		// act
+		valid, errors, warnings := params.ValidateRequiredProperties()
+
+		assert.True(t, valid, errors)
+		assert.Contains(t, warnings, "Workload identity replaces the service account key secret; useGoogleCloudCredentials and legacyGoogleCloudServiceAccountKeyFile are ignored and the secret gets deleted")
 	})
 
-	t.Run("ReturnsFalseIfLivenessPathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfEspServiceConfigYamlPathIsSetWithoutEspDescriptorSetPath", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Path = ""
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityESPv2
+		params.Container.Protocol = ContainerProtocolGRPC
+		params.EspOpenAPIYamlPath = ""
+		params.EspServiceConfigYamlPath = "api_config.yaml"
+		error_string := "With visibility 'esp' properties espServiceConfigYamlPath and espDescriptorSetPath need to be set together to deploy a grpc service"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
 	})
 
-	t.Run("ReturnsTrueIfLivenessPathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfEspServiceConfigYamlPathIsSetWithVisibilityESP", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Path = "/liveness"
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityESP
+		params.Container.Protocol = ContainerProtocolGRPC
+		params.EspOpenAPIYamlPath = ""
+		params.EspServiceConfigYamlPath = "api_config.yaml"
+		params.EspDescriptorSetPath = "api_descriptor.pb"
+		error_string := "A grpc service config via espServiceConfigYamlPath is only supported with visibility 'esp-v2'"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
 	})
 
-	t.Run("ReturnsFalseIfLivenessProbePortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfEspServiceConfigYamlPathIsSetWithHTTPContainerProtocol", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Port = 0
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityESPv2
+		params.Container.Protocol = ContainerProtocolHTTP
+		params.EspOpenAPIYamlPath = ""
+		params.EspServiceConfigYamlPath = "api_config.yaml"
+		params.EspDescriptorSetPath = "api_descriptor.pb"
+		error_string := "A grpc service config via espServiceConfigYamlPath requires container.protocol 'grpc' or 'grpcs'"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
 	})
 
-	t.Run("ReturnsTrueIfLivenessProbePortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfEspServiceConfigYamlPathAndEspOpenAPIYamlPathAreBothSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.Port = 5000
+		params.Kind = KindDeployment
+		params.Visibility = VisibilityESPv2
+		params.Container.Protocol = ContainerProtocolGRPC
+		params.EspOpenAPIYamlPath = "openapi.yaml"
+		params.EspServiceConfigYamlPath = "api_config.yaml"
+		params.EspDescriptorSetPath = "api_descriptor.pb"
+		error_string := "With visibility 'esp-v2' set either espOpenapiYamlPath or espServiceConfigYamlPath, not both"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
 	})
 
-	t.Run("ReturnsFalseIfLivenessInitialDelaySecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSecretManagerReferenceIsMalformed", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.InitialDelaySeconds = 0
+		params.Container.SecretEnvironmentVariables = map[string]interface{}{
+			"DB_PASSWORD": "gcpsm://projects/p/secrets/db-password",
+		}
+		error_string := "Secret DB_PASSWORD has an invalid secret manager reference; use the format gcpsm://projects/<project>/secrets/<secret>/versions/<version>"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
 	})
 
-	t.Run("ReturnsTrueIfLivenessInitialDelaySecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfSecretManagerReferenceIsWellFormed", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.InitialDelaySeconds = 30
+		params.Container.SecretEnvironmentVariables = map[string]interface{}{
+			"DB_PASSWORD": "gcpsm://projects/p/secrets/db-password/versions/latest",
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.True(t, valid, errors)
+		assert.True(t, params.HasSecretManagerReferences())
 	})
 
-	t.Run("ReturnsFalseIfLivenessTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsTrueIfSqlProxyInstancesAreSetWithoutDbInstanceConnectionName", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.TimeoutSeconds = 0
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.SQLProxyPort = 5432
+		params.Sidecar.SQLProxyVersion = CloudSQLProxyVersionV2
+		params.Sidecar.SQLProxyInstances = []*SQLProxyInstanceParams{
+			{
+				ConnectionName: "project:europe-west1:instance-a",
+				Port:           5432,
+			},
+			{
+				ConnectionName: "project:europe-west1:instance-b",
+				Port:           5433,
+			},
+		}
+		params.Sidecar.SQLProxyPrivateIP = true
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, valid)
+		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsTrueIfLivenessTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSqlProxyInstanceConnectionNameIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.LivenessProbe.TimeoutSeconds = 2
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.SQLProxyPort = 5432
+		params.Sidecar.SQLProxyInstances = []*SQLProxyInstanceParams{
+			{
+				Port: 5432,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbePathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSqlProxyInstancesShareAPort", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Path = ""
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.SQLProxyPort = 5432
+		params.Sidecar.SQLProxyInstances = []*SQLProxyInstanceParams{
+			{
+				ConnectionName: "project:europe-west1:instance-a",
+				Port:           5432,
+			},
+			{
+				ConnectionName: "project:europe-west1:instance-b",
+				Port:           5432,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbePathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSqlProxyVersionIsUnsupported", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Path = "/readiness"
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.DbInstanceConnectionName = "instance"
+		params.Sidecar.SQLProxyPort = 5432
+		params.Sidecar.SQLProxyVersion = "v3"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbePortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSqlProxyV2OnlyFlagsAreSetForV1", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Port = 0
+		params.Sidecar.Type = SidecarTypeCloudSQLProxy
+		params.Sidecar.DbInstanceConnectionName = "instance"
+		params.Sidecar.SQLProxyPort = 5432
+		params.Sidecar.SQLProxyVersion = CloudSQLProxyVersionV1
+		params.Sidecar.SQLProxyAutoIAMAuthn = true
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbePortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsTrueIfDeclaredSidecarTypeRequiredPropertiesAreSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.Port = 5000
+		params.SidecarTypes = []*SidecarTypeDefinition{
+			{
+				Type:     "logshipper",
+				Required: []string{"output"},
+				Template: `args: ["-o", "{{ index .Sidecar.SidecarSpecificProperties "output" }}"]`,
+			},
+		}
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:  "logshipper",
+				Image: "fluent/fluent-bit:3.0.7",
+				CPU: CPUParams{
+					Request: "10m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "10Mi",
+				},
+				CustomProperties: map[string]interface{}{
+					"output": "stdout",
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3189,108 +5427,176 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) == 0)
 	})
 
-	t.Run("ReturnsFalseIfReadinessProbeTimeoutSecondsIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfDeclaredSidecarTypeRequiredPropertyIsNotSet", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.TimeoutSeconds = 0
+		params.SidecarTypes = []*SidecarTypeDefinition{
+			{
+				Type:     "logshipper",
+				Required: []string{"output"},
+			},
+		}
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:  "logshipper",
+				Image: "fluent/fluent-bit:3.0.7",
+				CPU: CPUParams{
+					Request: "10m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "10Mi",
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfReadinessProbeTimeoutSecondsIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfBuiltInSidecarTypeIsDeclared", func(t *testing.T) {
 
 		params := validParams
-		params.Container.ReadinessProbe.TimeoutSeconds = 2
+		params.SidecarTypes = []*SidecarTypeDefinition{
+			{
+				Type: SidecarTypeCloudSQLProxy,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsFalseIfMetricsPathIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSidecarTypeIsDeclaredTwice", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Path = ""
+		params.SidecarTypes = []*SidecarTypeDefinition{
+			{
+				Type: "logshipper",
+			},
+			{
+				Type: "logshipper",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPathIsNotEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfDeclaredSidecarTypeTemplateIsInvalid", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Path = "/metrics"
+		params.SidecarTypes = []*SidecarTypeDefinition{
+			{
+				Type:     "logshipper",
+				Template: `args: ["{{ .Sidecar.UnknownField }}"]`,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPathIsEmptyButScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsFalseIfNativeSidecarsIsInvalidAndKindIsJob", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
-		params.Container.Metrics.Path = ""
+		params.Kind = KindJob
+		params.NativeSidecars = "always"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsFalseIfMetricsPortIsZeroOrLess", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSidecarKeepsJobRunningAndNativeSidecarsIsDisabled", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Port = 0
+		params.Kind = KindJob
+		params.NativeSidecars = NativeSidecarsModeDisabled
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:                     SidecarTypeCloudSQLProxy,
+				Image:                    "eu.gcr.io/cloudsql-docker/gce-proxy:1.21.0",
+				SQLProxyVersion:          CloudSQLProxyVersionV1,
+				DbInstanceConnectionName: "project:region:instance",
+				SQLProxyPort:             5432,
+				CPU: CPUParams{
+					Request: "10m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "10Mi",
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
 		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPortIsLargerThanZero", func(t *testing.T) {
+	t.Run("ReturnsFalseIfSidecarIsInvalidAndKindIsJob", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Port = 5000
+		params.Kind = KindJob
+		params.Sidecars = []*SidecarParams{
+			{
+				Type:         SidecarTypeCloudSQLProxy,
+				Image:        "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.14.1",
+				SQLProxyPort: 5432,
+				CPU: CPUParams{
+					Request: "10m",
+				},
+				Memory: MemoryParams{
+					Request: "10Mi",
+					Limit:   "10Mi",
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) == 1)
 	})
 
-	t.Run("ReturnsTrueIfMetricsPortIsZeroOrLessButScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsFalseIfTracingProviderIsUnknown", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
-		params.Container.Metrics.Port = 0
+		params.Tracing.Provider = "zipkin"
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfMetricsScrapeIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfTracingSamplingRatioIsNotBetweenZeroAndOne", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = nil
+		params.Tracing = TracingParams{
+			Provider:            TracingProviderOpenTelemetry,
+			SamplingRatio:       "0.01",
+			CanarySamplingRatio: "1.5",
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3299,46 +5605,54 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfMetricsScrapeIsTrue", func(t *testing.T) {
+	t.Run("ReturnsWarningIfTracingEndpointIsSetForJaeger", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &trueValue
+		params.Tracing = TracingParams{
+			Provider: TracingProviderJaeger,
+			Endpoint: "http://otel-collector.tracing:4317",
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		valid, errors, warnings := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.True(t, valid, errors)
+		assert.Contains(t, warnings, "The tracing endpoint is only used by the otel tracing provider")
 	})
 
-	t.Run("ReturnsTrueIfMetricsScrapeIsFalse", func(t *testing.T) {
+	t.Run("ReturnsTrueIfAutoscaleMetricsAreValid", func(t *testing.T) {
 
 		params := validParams
-		params.Container.Metrics.Scrape = &falseValue
+		params.Autoscale.Metrics = []*AutoscaleMetricParams{
+			{
+				Type:               AutoscaleMetricTypeMemory,
+				AverageUtilization: 70,
+			},
+			{
+				Type:         AutoscaleMetricTypePods,
+				Name:         "http_requests_per_second",
+				AverageValue: "100",
+			},
+			{
+				Type:  AutoscaleMetricTypeExternal,
+				Name:  "pubsub.googleapis.com|subscription|num_undelivered_messages",
+				Value: "1000",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.True(t, valid, errors)
 	})
 
-	t.Run("ReturnsFalseSidecarTypeIsEmpty", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleMetricTypeIsUnknown", func(t *testing.T) {
 
 		params := validParams
-
-		params.Sidecars = []*SidecarParams{
-			&SidecarParams{
-				Type:  "",
-				Image: "docker",
-				CPU: CPUParams{
-					Request: "10m",
-					Limit:   "50m",
-				},
-				Memory: MemoryParams{
-					Request: "10Mi",
-					Limit:   "50Mi",
-				},
+		params.Autoscale.Metrics = []*AutoscaleMetricParams{
+			{
+				Type:         "object",
+				AverageValue: "100",
 			},
 		}
 
@@ -3349,22 +5663,34 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarTypeIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscalePodsMetricHasNoName", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Type = SidecarTypeOpenresty
+		params.Autoscale.Metrics = []*AutoscaleMetricParams{
+			{
+				Type:         AutoscaleMetricTypePods,
+				AverageValue: "100",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarImageIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleExternalMetricHasBothValueAndAverageValue", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Image = ""
+		params.Autoscale.Metrics = []*AutoscaleMetricParams{
+			{
+				Type:         AutoscaleMetricTypeExternal,
+				Name:         "pubsub.googleapis.com|subscription|num_undelivered_messages",
+				Value:        "1000",
+				AverageValue: "30",
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3373,22 +5699,36 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarImageIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscalePodsMetricHasAverageUtilization", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Image = "estafette/openresty-sidecar:1.13.6.2-alpine"
+		params.Autoscale.Metrics = []*AutoscaleMetricParams{
+			{
+				Type:               AutoscaleMetricTypePods,
+				Name:               "requests_per_second",
+				AverageValue:       "100",
+				AverageUtilization: 80,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsFalseIfSidecarCpuRequestIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleExternalMetricHasAverageUtilization", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.CPU.Request = ""
+		params.Autoscale.Metrics = []*AutoscaleMetricParams{
+			{
+				Type:               AutoscaleMetricTypeExternal,
+				Name:               "pubsub.googleapis.com|subscription|num_undelivered_messages",
+				AverageValue:       "30",
+				AverageUtilization: 80,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
@@ -3397,583 +5737,832 @@ func TestValidateRequiredProperties(t *testing.T) {
 		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarCpuRequestIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleBehaviorPolicyTypeIsUnknown", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.CPU.Request = "100m"
+		params.Autoscale.Behavior = AutoscaleBehaviorParams{
+			ScaleUp: &AutoscaleScalingRulesParams{
+				Policies: []*AutoscalePolicyParams{
+					{
+						Type:          "Replicas",
+						Value:         4,
+						PeriodSeconds: 60,
+					},
+				},
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarCpuLimitIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfAutoscaleBehaviorStabilizationWindowIsLongerThanAnHour", func(t *testing.T) {
 
+		stabilizationWindowSeconds := 7200
 		params := validParams
-		params.Sidecar.CPU.Limit = ""
+		params.Autoscale.Behavior = AutoscaleBehaviorParams{
+			ScaleDown: &AutoscaleScalingRulesParams{
+				StabilizationWindowSeconds: &stabilizationWindowSeconds,
+			},
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
 
-	t.Run("ReturnsTrueIfSidecarCpuLimitIsSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfOpenrestySidecarIsDeclaredWithMeshMTLS", func(t *testing.T) {
 
+		trueValue := true
 		params := validParams
-		params.Sidecar.CPU.Limit = "100m"
+		params.Sidecars = []*SidecarParams{
+			validParams.Sidecars[0],
+			{
+				Type:  SidecarTypeIstio,
+				Image: "docker.io/istio/proxyv2:1.20.0",
+				CPU: CPUParams{
+					Request: "100m",
+				},
+				Memory: MemoryParams{
+					Request: "128Mi",
+					Limit:   "256Mi",
+				},
+			},
+		}
+		params.Mesh = MeshParams{
+			MTLS: &trueValue,
+		}
 
 		// act
 		valid, errors, _ := params.ValidateRequiredProperties()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, valid)
+		assert.True(t, len(errors) > 0)
 	})
+}
 
-	t.Run("ReturnsFalseIfSidecarMemoryRequestIsNotSet", func(t *testing.T) {
+// getTestDigest resolves any image to the same digest, instead of looking it up in a registry
+func getTestDigest(image string) (string, error) {
+	return "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", nil
+}
+
+func TestReplaceSidecarTagsWithDigest(t *testing.T) {
+
+	t.Run("ReplacesFirstSidecarImageTagWithDigest", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Request = ""
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ReplaceSidecarTagsWithDigest(getTestDigest)
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, SidecarTypeOpenresty, params.Sidecars[0].Type)
+		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
 	})
 
-	t.Run("ReturnsTrueIfSidecarMemoryRequestIsSet", func(t *testing.T) {
+	t.Run("KeepsFirstSidecarImageTagWithDigest", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Request = "100m"
+		params.Sidecars[0].Image = "estafette/openresty-sidecar@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf"
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ReplaceSidecarTagsWithDigest(getTestDigest)
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, SidecarTypeOpenresty, params.Sidecars[0].Type)
+		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
 	})
 
-	t.Run("ReturnsFalseIfSidecarMemoryLimitIsNotSet", func(t *testing.T) {
+	t.Run("ReplacesLastSidecarImageTagWithDigest", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Limit = ""
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ReplaceSidecarTagsWithDigest(getTestDigest)
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, SidecarTypeESP, params.Sidecars[1].Type)
+		assert.True(t, strings.HasPrefix(params.Sidecars[1].Image, "estafette/estafette-docker-cache-heater@sha256:"))
 	})
 
-	t.Run("ReturnsTrueIfSidecarMemoryLimitIsSet", func(t *testing.T) {
+	t.Run("KeepsLastSidecarImageTagWithDigest", func(t *testing.T) {
 
 		params := validParams
-		params.Sidecar.Memory.Limit = "100m"
+		params.Sidecars[1].Image = "estafette/estafette-docker-cache-heater@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf"
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ReplaceSidecarTagsWithDigest(getTestDigest)
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, SidecarTypeESP, params.Sidecars[1].Type)
+		assert.True(t, strings.HasPrefix(params.Sidecars[1].Image, "estafette/estafette-docker-cache-heater@sha256:"))
 	})
 
-	t.Run("ReturnsFalseIfSqlProxyInstanceNameNotSet", func(t *testing.T) {
+	t.Run("DefaultsSQLProxyPortTo5432IfNotSet", func(t *testing.T) {
 
-		params := validParams
-		params.Sidecar.Type = SidecarTypeCloudSQLProxy
-		params.Sidecar.DbInstanceConnectionName = ""
-		params.Sidecar.SQLProxyPort = 8080
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:         SidecarTypeCloudSQLProxy,
+					SQLProxyPort: 0,
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) == 1)
+		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
+		assert.Equal(t, 5432, params.Sidecars[0].SQLProxyPort)
 	})
 
-	t.Run("ReturnsFalseIfSqlProxyPortNotSet", func(t *testing.T) {
+	t.Run("KeepsSQLProxyPortIfSet", func(t *testing.T) {
 
-		params := validParams
-		params.Sidecar.Type = SidecarTypeCloudSQLProxy
-		params.Sidecar.DbInstanceConnectionName = "instance"
-		params.Sidecar.SQLProxyPort = 0
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:         SidecarTypeCloudSQLProxy,
+					SQLProxyPort: 1601,
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) == 1)
+		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
+		assert.Equal(t, 1601, params.Sidecars[0].SQLProxyPort)
 	})
 
-	t.Run("ReturnsTrueIfSqlProxyProperlyConfigured", func(t *testing.T) {
+	t.Run("DefaultsSQLProxyTerminationTimeoutSecondsTo60IfNotSet", func(t *testing.T) {
 
-		params := validParams
-		params.Sidecar.Type = SidecarTypeCloudSQLProxy
-		params.Sidecar.DbInstanceConnectionName = "instance"
-		params.Sidecar.SQLProxyPort = 8080
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:                              SidecarTypeCloudSQLProxy,
+					SQLProxyTerminationTimeoutSeconds: 0,
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
+		assert.Equal(t, 60, params.Sidecars[0].SQLProxyTerminationTimeoutSeconds)
 	})
 
-	t.Run("ReturnsWarningIfSidecarFieldUsed", func(t *testing.T) {
+	t.Run("KeepsSQLProxyTerminationTimeoutSecondsIfSet", func(t *testing.T) {
 
-		params := validParams
-		params.Sidecar = SidecarParams{
-			Type:  SidecarTypeOpenresty,
-			Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
-			CPU: CPUParams{
-				Request: "10m",
-				Limit:   "50m",
-			},
-			Memory: MemoryParams{
-				Request: "10Mi",
-				Limit:   "50Mi",
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:                              SidecarTypeCloudSQLProxy,
+					SQLProxyTerminationTimeoutSeconds: 45,
+				},
 			},
 		}
 
 		// act
-		valid, errors, warnings := params.ValidateRequiredProperties()
+		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
-		assert.Equal(t, 1, len(warnings))
+		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
+		assert.Equal(t, 45, params.Sidecars[0].SQLProxyTerminationTimeoutSeconds)
 	})
 
-	t.Run("NoWarningRetuendIfSidecarsCollectionUsed", func(t *testing.T) {
-
-		params := validParams
-		params.Sidecar = SidecarParams{
-			Type: "",
-		}
+	t.Run("KeepsRegistryPortWhenReplacingTagWithDigest", func(t *testing.T) {
 
-		params.Sidecars = []*SidecarParams{
-			&SidecarParams{
-				Type:  SidecarTypeOpenresty,
-				Image: "estafette/openresty-sidecar:1.13.6.2-alpine",
-				CPU: CPUParams{
-					Request: "10m",
-					Limit:   "50m",
-				},
-				Memory: MemoryParams{
-					Request: "10Mi",
-					Limit:   "50Mi",
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:  SidecarTypeCloudSQLProxy,
+					Image: "registry.example.com:5000/cloudsql-docker/gce-proxy:1.21.0",
 				},
 			},
 		}
 
 		// act
-		valid, errors, warnings := params.ValidateRequiredProperties()
+		errors := params.ReplaceSidecarTagsWithDigest(getTestDigest)
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
-		assert.Equal(t, 0, len(warnings))
+		assert.Equal(t, 0, len(errors))
+		assert.Equal(t, "registry.example.com:5000/cloudsql-docker/gce-proxy@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", params.Sidecars[0].Image)
 	})
 
-	t.Run("ReturnsFalseIfBasepathIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsErrorAndKeepsTagIfDigestCannotBeResolved", func(t *testing.T) {
 
-		params := validParams
-		params.Basepath = ""
+		params := Params{
+			Sidecars: []*SidecarParams{
+				{
+					Type:  SidecarTypeESPv2,
+					Image: "gcr.io/endpoints-release/endpoints-runtime:2.25.0",
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		errors := params.ReplaceSidecarTagsWithDigest(func(image string) (string, error) {
+			return "", fmt.Errorf("Registry gcr.io responded with status 503")
+		})
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, 1, len(errors))
+		assert.Equal(t, "gcr.io/endpoints-release/endpoints-runtime:2.25.0", params.Sidecars[0].Image)
 	})
+}
 
-	t.Run("ReturnsTrueIfBasepathIsSet", func(t *testing.T) {
+func TestGetSharedConfigReferences(t *testing.T) {
 
-		params := validParams
-		params.Basepath = "/"
+	t.Run("ReturnsConfigMapAndSecretNamesFromVolumeMounts", func(t *testing.T) {
+
+		params := Params{
+			VolumeMounts: []VolumeMountParams{
+				{
+					Name:      "shared-configs",
+					MountPath: "/shared-configs",
+					Volume: map[string]interface{}{
+						"configMap": map[interface{}]interface{}{
+							"name": "myconfig",
+						},
+					},
+				},
+				{
+					Name:      "shared-secrets",
+					MountPath: "/shared-secrets",
+					Volume: map[string]interface{}{
+						"secret": map[interface{}]interface{}{
+							"secretName": "mysecret",
+						},
+					},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		references := params.GetSharedConfigReferences()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, []string{"myconfig", "mysecret"}, references)
 	})
 
-	t.Run("ReturnsFalseIfRollingUpdateMaxSurgeIsNotSet", func(t *testing.T) {
+	t.Run("ReturnsConfigMapAndSecretNamesFromEnvironmentVariables", func(t *testing.T) {
 
-		params := validParams
-		params.RollingUpdate.MaxSurge = ""
+		params := Params{
+			Container: ContainerParams{
+				EnvironmentVariables: map[string]interface{}{
+					"MY_VALUE": map[interface{}]interface{}{
+						"valueFrom": map[interface{}]interface{}{
+							"secretKeyRef": map[interface{}]interface{}{
+								"name": "mysecret",
+								"key":  "value",
+							},
+						},
+					},
+					"MY_SIMPLE_VALUE": "value",
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		references := params.GetSharedConfigReferences()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, []string{"mysecret"}, references)
 	})
 
-	t.Run("ReturnsTrueIfRollingUpdateMaxSurgeIsSet", func(t *testing.T) {
+	t.Run("ReturnsEachNameOnce", func(t *testing.T) {
 
-		params := validParams
-		params.RollingUpdate.MaxSurge = "25%"
+		params := Params{
+			VolumeMounts: []VolumeMountParams{
+				{
+					Volume: map[string]interface{}{
+						"configMap": map[string]interface{}{
+							"name": "myconfig",
+						},
+					},
+				},
+				{
+					Volume: map[string]interface{}{
+						"configMap": map[string]interface{}{
+							"name": "myconfig",
+						},
+					},
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		references := params.GetSharedConfigReferences()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, []string{"myconfig"}, references)
 	})
+}
 
-	t.Run("ReturnsFalseIfRollingUpdateMaxUnavailableIsNotSet", func(t *testing.T) {
+func TestGetIngressController(t *testing.T) {
 
-		params := validParams
-		params.RollingUpdate.MaxUnavailable = ""
+	t.Run("ReturnsDefaultProfileIfNoIngressControllersAreSet", func(t *testing.T) {
+
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		ingressController, ok := params.GetIngressController("nginx-office")
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, ok)
+		assert.Equal(t, "nginx-office", ingressController.Class)
+		assert.True(t, ingressController.HasFeature(IngressControllerFeatureWhitelist))
 	})
 
-	t.Run("ReturnsTrueIfRollingUpdateMaxUnavailableIsSet", func(t *testing.T) {
+	t.Run("ReturnsProfileFromIngressControllersBeforeDefaultProfile", func(t *testing.T) {
 
-		params := validParams
-		params.RollingUpdate.MaxUnavailable = "25%"
+		params := Params{
+			IngressControllers: []IngressControllerParams{
+				{
+					Name:             "nginx-office",
+					Class:            "nginx-office-v2",
+					AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+				},
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		ingressController, ok := params.GetIngressController("nginx-office")
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.True(t, ok)
+		assert.Equal(t, "nginx-office-v2", ingressController.Class)
 	})
 
-	t.Run("ReturnsFalseIfScheduleIsNotSetAndKindIsCronjob", func(t *testing.T) {
+	t.Run("ReturnsFalseIfProfileDoesNotExist", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindCronJob
-		params.Schedule = ""
-		params.ConcurrencyPolicy = "Allow"
+		params := Params{}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		_, ok := params.GetIngressController("traefik")
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.False(t, ok)
 	})
 
-	t.Run("ReturnsFalseIfConcurrencyPolicyIsInvalidAndKindIsCronjob", func(t *testing.T) {
+	t.Run("ReturnsGCEProfileNameForVisibilityIap", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindCronJob
-		params.Schedule = "*/5 * * * *"
-		params.ConcurrencyPolicy = "InvalidPolicy"
+		params := Params{
+			Visibility: VisibilityIAP,
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		name := params.GetIngressControllerName()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, "gce", name)
 	})
+}
 
-	t.Run("ReturnsTrueIfScheduleIsSetAndConcurrencyPolicyIsValidAndKindIsCronjob", func(t *testing.T) {
+func TestResolveTrustedIPRanges(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindCronJob
-		params.Schedule = "*/5 * * * *"
-		params.ConcurrencyPolicy = "Allow"
+	t.Run("FetchesRangesFromURLAndCachesThem", func(t *testing.T) {
+
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			fmt.Fprint(w, "# trusted\n10.0.0.0/8\n192.168.0.0/16\n")
+		}))
+		defer server.Close()
+
+		params := Params{
+			TrustedIPRangesProviders: []TrustedIPRangesProviderParams{
+				{Type: TrustedIPRangesProviderURL, URL: server.URL},
+			},
+			TrustedIPRangesCache: TrustedIPRangesCacheParams{
+				Directory: t.TempDir(),
+				TTL:       "1h",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ResolveTrustedIPRanges()
+		params.ResolveTrustedIPRanges()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.0.0/16"}, params.TrustedIPRanges)
+		assert.Equal(t, 1, requests)
 	})
 
-	t.Run("ReturnsFalseIfPodManagementPolicyIsInvalidAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("FallsBackToExpiredCacheIfFetchedRangesAreInvalid", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindStatefulset
-		params.PodManagementPolicy = "InvalidPolicy"
+		valid := true
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if valid {
+				fmt.Fprint(w, "10.0.0.0/8\n")
+				return
+			}
+			fmt.Fprint(w, "<html>not found</html>\n")
+		}))
+		defer server.Close()
+
+		params := Params{
+			TrustedIPRangesProviders: []TrustedIPRangesProviderParams{
+				{Type: TrustedIPRangesProviderURL, URL: server.URL},
+			},
+			TrustedIPRangesCache: TrustedIPRangesCacheParams{
+				Directory: t.TempDir(),
+				TTL:       "1ns",
+			},
+		}
+		params.ResolveTrustedIPRanges()
+		valid = false
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ResolveTrustedIPRanges()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, []string{"10.0.0.0/8"}, params.TrustedIPRanges)
 	})
 
-	t.Run("ReturnsTrueIfPodManagementPolicyIsValidAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("FallsBackToBundledRangesIfFetchedRangesAreInvalidAndNothingIsCached", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindStatefulset
-		params.PodManagementPolicy = "Parallel"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "103.21.244.0/22\nnot-a-cidr\n")
+		}))
+		defer server.Close()
+
+		params := Params{
+			TrustedIPRangesProviders: []TrustedIPRangesProviderParams{
+				{Type: TrustedIPRangesProviderURL, URL: server.URL},
+				{Type: TrustedIPRangesProviderList, Ranges: []string{"10.0.0.0/8"}},
+			},
+			TrustedIPRangesCache: TrustedIPRangesCacheParams{
+				Directory: t.TempDir(),
+				TTL:       "1h",
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		params.ResolveTrustedIPRanges()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, []string{"10.0.0.0/8"}, params.TrustedIPRanges)
 	})
+}
 
-	t.Run("ReturnsFalseIfStorageClassIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+func TestUsesServiceAccountKey(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageClass = ""
+	t.Run("ReturnsTrueIfUseGoogleCloudCredentialsIsTrue", func(t *testing.T) {
+
+		params := Params{
+			UseGoogleCloudCredentials: true,
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		usesKey := params.UsesServiceAccountKey()
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.True(t, usesKey)
 	})
 
-	t.Run("ReturnsFalseIfStorageClassIsSetAndKindIsStatefulset", func(t *testing.T) {
+	t.Run("ReturnsFalseIfWorkloadIdentityIsEnabled", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageClass = "standard"
+		trueValue := true
+		params := Params{
+			UseGoogleCloudCredentials: true,
+			WorkloadIdentity: WorkloadIdentityParams{
+				Enabled: &trueValue,
+			},
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		usesKey := params.UsesServiceAccountKey()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.False(t, usesKey)
 	})
+}
 
-	t.Run("ReturnsFalseIfStorageSizeIsEmptyAndKindIsStatefulset", func(t *testing.T) {
+func TestGetCloudSQLProxyVersion(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageSize = ""
+	t.Run("ReturnsV1ForV1Tag", func(t *testing.T) {
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		version := GetCloudSQLProxyVersion("eu.gcr.io/cloudsql-docker/gce-proxy:1.33.2")
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, CloudSQLProxyVersionV1, version)
 	})
 
-	t.Run("ReturnsFalseIfStorageSizeIsSetAndKindIsStatefulset", func(t *testing.T) {
-
-		params := validParams
-		params.Kind = KindStatefulset
-		params.StorageSize = "1Gi"
+	t.Run("ReturnsV2ForV2Tag", func(t *testing.T) {
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		version := GetCloudSQLProxyVersion("gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.14.1-alpine")
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, CloudSQLProxyVersionV2, version)
 	})
 
-	t.Run("ReturnsFalseIfLoadBalanceAlgorithmIsNonValidValue", func(t *testing.T) {
-
-		params := validParams
-		params.Request.LoadBalanceAlgorithm = "leastconn"
+	t.Run("ReturnsVersionForTagWithDigestAndRegistryPort", func(t *testing.T) {
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		version := GetCloudSQLProxyVersion("registry.example.com:5000/cloudsql-docker/gce-proxy:v1.21.0@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf")
 
-		assert.False(t, valid)
-		assert.True(t, len(errors) > 0)
+		assert.Equal(t, CloudSQLProxyVersionV1, version)
 	})
 
-	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEmpty", func(t *testing.T) {
-
-		params := validParams
-		params.Request.LoadBalanceAlgorithm = ""
+	t.Run("ReturnsV1ForGceProxyRepositoryWithoutVersionTag", func(t *testing.T) {
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		version := GetCloudSQLProxyVersion("eu.gcr.io/cloudsql-docker/gce-proxy:latest")
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, CloudSQLProxyVersionV1, version)
 	})
 
-	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsEwma", func(t *testing.T) {
-
-		params := validParams
-		params.Request.LoadBalanceAlgorithm = "ewma"
+	t.Run("ReturnsV2ForOtherRepositoryWithoutVersionTag", func(t *testing.T) {
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		version := GetCloudSQLProxyVersion("gcr.io/cloud-sql-connectors/cloud-sql-proxy")
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Equal(t, CloudSQLProxyVersionV2, version)
 	})
+}
 
-	t.Run("ReturnsTrueIfLoadBalanceAlgorithmIsRoundRobin", func(t *testing.T) {
+func TestLoadSidecarTypes(t *testing.T) {
 
-		params := validParams
-		params.Request.LoadBalanceAlgorithm = "round_robin"
+	t.Run("AddsSidecarTypesFromYamlFilesInDirectory", func(t *testing.T) {
+
+		directory := t.TempDir()
+		err := ioutil.WriteFile(filepath.Join(directory, "logshipper.yaml"), []byte("type: logshipper\nimage: fluent/fluent-bit:3.0.7\nrequired:\n- output\n"), 0644)
+		assert.Nil(t, err)
+		err = ioutil.WriteFile(filepath.Join(directory, "README.md"), []byte("# sidecar types\n"), 0644)
+		assert.Nil(t, err)
+
+		params := Params{
+			SidecarTypes: []*SidecarTypeDefinition{
+				{
+					Type: "vault-agent",
+				},
+			},
+			SidecarTypesDirectory: directory,
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		err = params.LoadSidecarTypes()
 
-		assert.True(t, valid)
-		assert.True(t, len(errors) == 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(params.SidecarTypes))
+		assert.Equal(t, SidecarType("vault-agent"), params.SidecarTypes[0].Type)
+		assert.Equal(t, SidecarType("logshipper"), params.SidecarTypes[1].Type)
+		assert.Equal(t, "fluent/fluent-bit:3.0.7", params.SidecarTypes[1].Image)
+		assert.Equal(t, []string{"output"}, params.SidecarTypes[1].Required)
 	})
 
-	t.Run("ReturnsFalseAuthSecretIsNotSetForVisibilityApigee", func(t *testing.T) {
+	t.Run("ReturnsErrorIfDirectoryDoesNotExist", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = VisibilityApigee
+		params := Params{
+			SidecarTypesDirectory: filepath.Join(t.TempDir(), "missing"),
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
+		err := params.LoadSidecarTypes()
 
-		assert.False(t, valid)
-		assert.Equal(t, 1, len(errors))
+		assert.NotNil(t, err)
 	})
+}
 
-	t.Run("WarnOnUsingVisibilityPublic", func(t *testing.T) {
+func TestUsesNativeSidecars(t *testing.T) {
 
-		params := validParams
-		params.Visibility = VisibilityPublic
+	t.Run("ReturnsTrueIfModeIsAutoAndKubernetesVersionIs129OrNewer", func(t *testing.T) {
+
+		params := Params{
+			Kind:              KindJob,
+			NativeSidecars:    NativeSidecarsModeAuto,
+			KubernetesVersion: "v1.30.2-gke.1587003",
+		}
 
 		// act
-		valid, _, warnings := params.ValidateRequiredProperties()
+		usesNativeSidecars := params.UsesNativeSidecars()
 
-		assert.True(t, valid)
-		assert.Equal(t, 1, len(warnings))
+		assert.True(t, usesNativeSidecars)
 	})
 
-	t.Run("ReturnsFalseIfEspEndpointsProjectIDNotSet", func(t *testing.T) {
+	t.Run("ReturnsFalseIfModeIsAutoAndKubernetesVersionIsOlderThan129", func(t *testing.T) {
 
-		params := validParams
-		params.Kind = KindDeployment
-		params.Visibility = VisibilityESP
-		params.EspEndpointsProjectID = ""
-		error_string := "With visibility 'esp' property espEndpointsProjectID is required; provide id of the 'endpoints' project"
+		params := Params{
+			Kind:              KindCronJob,
+			NativeSidecars:    NativeSidecarsModeAuto,
+			KubernetesVersion: "v1.28.9",
+		}
 
 		// act
-		valid, errors, _ := params.ValidateRequiredProperties()
-		assert.False(t, valid)
-		assert.Equal(t, error_string, stringInErrorSlice(error_string, errors))
-	})
-}
+		usesNativeSidecars := params.UsesNativeSidecars()
 
-func TestReplaceSidecarTagsWithDigest(t *testing.T) {
+		assert.False(t, usesNativeSidecars)
+	})
 
-	t.Run("ReplacesFirstSidecarImageTagWithDigest", func(t *testing.T) {
+	t.Run("ReturnsFalseIfModeIsAutoAndKubernetesVersionIsUnknown", func(t *testing.T) {
 
-		params := validParams
+		params := Params{
+			Kind:           KindJob,
+			NativeSidecars: NativeSidecarsModeAuto,
+		}
 
 		// act
-		params.ReplaceSidecarTagsWithDigest()
+		usesNativeSidecars := params.UsesNativeSidecars()
 
-		assert.Equal(t, SidecarTypeOpenresty, params.Sidecars[0].Type)
-		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
+		assert.False(t, usesNativeSidecars)
 	})
 
-	t.Run("KeepsFirstSidecarImageTagWithDigest", func(t *testing.T) {
+	t.Run("ReturnsTrueIfModeIsEnabledRegardlessOfKubernetesVersion", func(t *testing.T) {
 
-		params := validParams
-		params.Sidecars[0].Image = "estafette/openresty-sidecar@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf"
+		params := Params{
+			Kind:              KindJob,
+			NativeSidecars:    NativeSidecarsModeEnabled,
+			KubernetesVersion: "v1.28.9",
+		}
 
 		// act
-		params.ReplaceSidecarTagsWithDigest()
+		usesNativeSidecars := params.UsesNativeSidecars()
 
-		assert.Equal(t, SidecarTypeOpenresty, params.Sidecars[0].Type)
-		assert.True(t, strings.HasPrefix(params.Sidecars[0].Image, "estafette/openresty-sidecar@sha256:"))
+		assert.True(t, usesNativeSidecars)
 	})
 
-	t.Run("ReplacesLastSidecarImageTagWithDigest", func(t *testing.T) {
+	t.Run("ReturnsFalseIfModeIsDisabled", func(t *testing.T) {
 
-		params := validParams
+		params := Params{
+			Kind:              KindJob,
+			NativeSidecars:    NativeSidecarsModeDisabled,
+			KubernetesVersion: "v1.30.2",
+		}
 
 		// act
-		params.ReplaceSidecarTagsWithDigest()
+		usesNativeSidecars := params.UsesNativeSidecars()
 
-		assert.Equal(t, SidecarTypeESP, params.Sidecars[1].Type)
-		assert.True(t, strings.HasPrefix(params.Sidecars[1].Image, "estafette/estafette-docker-cache-heater@sha256:"))
+		assert.False(t, usesNativeSidecars)
 	})
 
-	t.Run("KeepsLastSidecarImageTagWithDigest", func(t *testing.T) {
+	t.Run("ReturnsFalseIfKindIsDeployment", func(t *testing.T) {
 
-		params := validParams
-		params.Sidecars[1].Image = "estafette/estafette-docker-cache-heater@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf"
+		params := Params{
+			Kind:              KindDeployment,
+			NativeSidecars:    NativeSidecarsModeEnabled,
+			KubernetesVersion: "v1.30.2",
+		}
 
 		// act
-		params.ReplaceSidecarTagsWithDigest()
+		usesNativeSidecars := params.UsesNativeSidecars()
 
-		assert.Equal(t, SidecarTypeESP, params.Sidecars[1].Type)
-		assert.True(t, strings.HasPrefix(params.Sidecars[1].Image, "estafette/estafette-docker-cache-heater@sha256:"))
+		assert.False(t, usesNativeSidecars)
 	})
+}
 
-	t.Run("DefaultsSQLProxyPortTo5432IfNotSet", func(t *testing.T) {
+func TestMergeSidecarTypes(t *testing.T) {
+
+	t.Run("KeepsDefaultSidecarTypesAndAddsTheOnesFromTheManifest", func(t *testing.T) {
 
 		params := Params{
-			Sidecars: []*SidecarParams{
+			SidecarTypes: []*SidecarTypeDefinition{
 				{
-					Type:         SidecarTypeCloudSQLProxy,
-					SQLProxyPort: 0,
+					Type: "logshipper",
 				},
 			},
 		}
 
 		// act
-		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+		params.MergeSidecarTypes([]*SidecarTypeDefinition{
+			{
+				Type: "vault-agent",
+			},
+		})
 
-		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
-		assert.Equal(t, 5432, params.Sidecars[0].SQLProxyPort)
+		assert.Equal(t, 2, len(params.SidecarTypes))
+		assert.Equal(t, SidecarType("vault-agent"), params.SidecarTypes[0].Type)
+		assert.Equal(t, SidecarType("logshipper"), params.SidecarTypes[1].Type)
 	})
 
-	t.Run("KeepsSQLProxyPortIfSet", func(t *testing.T) {
+	t.Run("ReplacesDefaultSidecarTypeWithTheSameTypeFromTheManifest", func(t *testing.T) {
 
 		params := Params{
-			Sidecars: []*SidecarParams{
+			SidecarTypes: []*SidecarTypeDefinition{
 				{
-					Type:         SidecarTypeCloudSQLProxy,
-					SQLProxyPort: 1601,
+					Type:  "vault-agent",
+					Image: "hashicorp/vault:1.17.2",
 				},
 			},
 		}
 
 		// act
-		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+		params.MergeSidecarTypes([]*SidecarTypeDefinition{
+			{
+				Type:  "vault-agent",
+				Image: "hashicorp/vault:1.15.0",
+			},
+			{
+				Type: "logshipper",
+			},
+		})
 
-		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
-		assert.Equal(t, 1601, params.Sidecars[0].SQLProxyPort)
+		assert.Equal(t, 2, len(params.SidecarTypes))
+		assert.Equal(t, "hashicorp/vault:1.17.2", params.SidecarTypes[0].Image)
+		assert.Equal(t, SidecarType("logshipper"), params.SidecarTypes[1].Type)
 	})
+}
 
-	t.Run("DefaultsSQLProxyTerminationTimeoutSecondsTo60IfNotSet", func(t *testing.T) {
+func TestGetSidecarsKeepingJobRunning(t *testing.T) {
+
+	t.Run("ReturnsEmptyListForCloudSQLProxyV2Sidecar", func(t *testing.T) {
 
 		params := Params{
 			Sidecars: []*SidecarParams{
 				{
-					Type:                              SidecarTypeCloudSQLProxy,
-					SQLProxyTerminationTimeoutSeconds: 0,
+					Type:            SidecarTypeCloudSQLProxy,
+					SQLProxyVersion: CloudSQLProxyVersionV2,
 				},
 			},
 		}
 
 		// act
-		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+		sidecars := params.GetSidecarsKeepingJobRunning()
 
-		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
-		assert.Equal(t, 60, params.Sidecars[0].SQLProxyTerminationTimeoutSeconds)
+		assert.Equal(t, 0, len(sidecars))
 	})
 
-	t.Run("KeepsSQLProxyTerminationTimeoutSecondsIfSet", func(t *testing.T) {
+	t.Run("ReturnsCloudSQLProxyV1DeclaredAndCustomSidecars", func(t *testing.T) {
 
 		params := Params{
 			Sidecars: []*SidecarParams{
 				{
-					Type:                              SidecarTypeCloudSQLProxy,
-					SQLProxyTerminationTimeoutSeconds: 45,
+					Type:            SidecarTypeCloudSQLProxy,
+					SQLProxyVersion: CloudSQLProxyVersionV1,
+				},
+				{
+					Type: "vault",
+				},
+			},
+			CustomSidecars: []*map[string]interface{}{
+				{
+					"name": "exporter",
 				},
 			},
 		}
 
 		// act
-		params.SetDefaults("", "", "", "", "", "", "", "", map[string]string{})
+		sidecars := params.GetSidecarsKeepingJobRunning()
 
-		assert.Equal(t, SidecarTypeCloudSQLProxy, params.Sidecars[0].Type)
-		assert.Equal(t, 45, params.Sidecars[0].SQLProxyTerminationTimeoutSeconds)
+		assert.Equal(t, []string{"cloudsqlproxy", "vault", "customsidecars[0]"}, sidecars)
+	})
+}
+
+func TestLimitsTrustedIPRanges(t *testing.T) {
+	t.Run("ReturnsTrueForDeploymentWithVisibilityPublic", func(t *testing.T) {
+
+		params := Params{
+			Kind:       KindDeployment,
+			Action:     ActionDeploySimple,
+			Visibility: VisibilityPublic,
+		}
+
+		// act
+		limits := params.LimitsTrustedIPRanges()
+
+		assert.True(t, limits)
+	})
+
+	t.Run("ReturnsTrueForStatefulsetDiffWithVisibilityESPv2", func(t *testing.T) {
+
+		params := Params{
+			Kind:       KindStatefulset,
+			Action:     ActionDiffStable,
+			Visibility: VisibilityESPv2,
+		}
+
+		// act
+		limits := params.LimitsTrustedIPRanges()
+
+		assert.True(t, limits)
+	})
+
+	t.Run("ReturnsFalseForVisibilityPrivate", func(t *testing.T) {
+
+		params := Params{
+			Kind:       KindDeployment,
+			Action:     ActionDeploySimple,
+			Visibility: VisibilityPrivate,
+		}
+
+		// act
+		limits := params.LimitsTrustedIPRanges()
+
+		assert.False(t, limits)
+	})
+
+	t.Run("ReturnsFalseForJob", func(t *testing.T) {
+
+		params := Params{
+			Kind:       KindJob,
+			Action:     ActionDeploySimple,
+			Visibility: VisibilityPublic,
+		}
+
+		// act
+		limits := params.LimitsTrustedIPRanges()
+
+		assert.False(t, limits)
+	})
+
+	t.Run("ReturnsFalseForRestartAction", func(t *testing.T) {
+
+		params := Params{
+			Kind:       KindDeployment,
+			Action:     ActionRestartSimple,
+			Visibility: VisibilityPublic,
+		}
+
+		// act
+		limits := params.LimitsTrustedIPRanges()
+
+		assert.False(t, limits)
 	})
 }
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SecretManagerReferencePrefix marks a secret value to be resolved from google secret manager at release time
+const SecretManagerReferencePrefix = "gcpsm://"
+
+var secretManagerVersionNameRegex = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+/versions/[^/]+$`)
+
+// GetSecretManagerVersionName returns the secret version name, like projects/p/secrets/s/versions/v, if the value is a gcpsm:// reference
+func GetSecretManagerVersionName(value interface{}) (name string, isReference bool) {
+	stringValue, ok := value.(string)
+	if !ok || !strings.HasPrefix(stringValue, SecretManagerReferencePrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(stringValue, SecretManagerReferencePrefix), true
+}
+
+// HasSecretManagerReferences returns true if any secret value needs to be resolved from google secret manager
+func (p *Params) HasSecretManagerReferences() bool {
+	for _, secretMap := range p.getSecretMaps() {
+		for _, value := range secretMap {
+			if _, isReference := GetSecretManagerVersionName(value); isReference {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// getSecretMaps returns the maps that can hold gcpsm:// references; container and sidecar secretEnv and secrets.keys
+func (p *Params) getSecretMaps() []map[string]interface{} {
+	secretMaps := []map[string]interface{}{p.Container.SecretEnvironmentVariables, p.Secrets.Keys}
+	for _, sc := range p.Sidecars {
+		secretMaps = append(secretMaps, sc.SecretEnvironmentVariables)
+	}
+
+	return secretMaps
+}
+
+func (p *Params) validateSecretManagerReferences() (errors []error) {
+	for _, secretMap := range p.getSecretMaps() {
+		for key, value := range secretMap {
+			if name, isReference := GetSecretManagerVersionName(value); isReference && !secretManagerVersionNameRegex.MatchString(name) {
+				errors = append(errors, fmt.Errorf("Secret %v has an invalid secret manager reference; use the format gcpsm://projects/<project>/secrets/<secret>/versions/<version>", key))
+			}
+		}
+	}
+
+	return
+}
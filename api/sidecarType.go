@@ -11,3 +11,12 @@ const (
 
 	SidecarTypeUnknown SidecarType = ""
 )
+
+// IsBuiltIn returns true for the sidecar types rendered by the extension itself, which can't be redeclared via sidecarTypes
+func (t SidecarType) IsBuiltIn() bool {
+	switch t {
+	case SidecarTypeOpenresty, SidecarTypeESP, SidecarTypeESPv2, SidecarTypeCloudSQLProxy, SidecarTypeIstio:
+		return true
+	}
+	return false
+}
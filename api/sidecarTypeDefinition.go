@@ -0,0 +1,172 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SidecarTypeDefinition declares a sidecar type outside of the extension, with its defaults, required properties, injected env and a container snippet
+type SidecarTypeDefinition struct {
+	Type                 SidecarType            `json:"type,omitempty" yaml:"type,omitempty"`
+	Image                string                 `json:"image,omitempty" yaml:"image,omitempty"`
+	CPU                  CPUParams              `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Memory               MemoryParams           `json:"memory,omitempty" yaml:"memory,omitempty"`
+	EnvironmentVariables map[string]interface{} `json:"env,omitempty" yaml:"env,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty" yaml:"required,omitempty"`
+	Template             string                 `json:"template,omitempty" yaml:"template,omitempty"`
+}
+
+// LoadSidecarTypes adds the sidecar type definitions from the yaml files in sidecarTypesDirectory to the ones set in the manifest or credential defaults
+func (p *Params) LoadSidecarTypes() error {
+	if p.SidecarTypesDirectory == "" {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(p.SidecarTypesDirectory)
+	if err != nil {
+		return fmt.Errorf("Failed reading sidecar types directory %v: %w", p.SidecarTypesDirectory, err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || (filepath.Ext(file.Name()) != ".yaml" && filepath.Ext(file.Name()) != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(p.SidecarTypesDirectory, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Failed reading sidecar type file %v: %w", path, err)
+		}
+
+		var definition SidecarTypeDefinition
+		err = yaml.Unmarshal(data, &definition)
+		if err != nil {
+			return fmt.Errorf("Failed unmarshalling sidecar type file %v: %w", path, err)
+		}
+
+		p.SidecarTypes = append(p.SidecarTypes, &definition)
+	}
+
+	return nil
+}
+
+// MergeSidecarTypes combines the sidecar types from the credential defaults with the ones set in the manifest, which replace defaults of the same type
+func (p *Params) MergeSidecarTypes(defaults []*SidecarTypeDefinition) {
+	merged := []*SidecarTypeDefinition{}
+	merged = append(merged, defaults...)
+
+	for _, definition := range p.SidecarTypes {
+		replaced := false
+		for i, d := range merged {
+			if d != nil && definition != nil && d.Type == definition.Type {
+				merged[i] = definition
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, definition)
+		}
+	}
+
+	p.SidecarTypes = merged
+}
+
+// GetSidecarTypeDefinition returns the declared definition for a sidecar type, or nil for built-in and undeclared types
+func (p *Params) GetSidecarTypeDefinition(sidecarType SidecarType) *SidecarTypeDefinition {
+	for _, definition := range p.SidecarTypes {
+		if definition != nil && definition.Type == sidecarType {
+			return definition
+		}
+	}
+
+	return nil
+}
+
+// HasProperty returns true if a custom property of a sidecar is one of the properties of this type, instead of a container field
+func (d *SidecarTypeDefinition) HasProperty(key string) bool {
+	if _, ok := d.Properties[key]; ok {
+		return true
+	}
+	for _, required := range d.Required {
+		if required == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *SidecarTypeDefinition) initializeSidecarDefaults(sidecar *SidecarParams) {
+	if sidecar.Image == "" {
+		sidecar.Image = d.Image
+	}
+	if sidecar.CPU.Request == "" && sidecar.CPU.Limit == "" {
+		sidecar.CPU = d.CPU
+	}
+	if sidecar.Memory.Request == "" && sidecar.Memory.Limit == "" {
+		sidecar.Memory = d.Memory
+	}
+
+	// injected env and property defaults don't override what's set on the sidecar itself
+	for key, value := range d.EnvironmentVariables {
+		if _, ok := sidecar.EnvironmentVariables[key]; !ok {
+			if sidecar.EnvironmentVariables == nil {
+				sidecar.EnvironmentVariables = map[string]interface{}{}
+			}
+			sidecar.EnvironmentVariables[key] = value
+		}
+	}
+	for key, value := range d.Properties {
+		if _, ok := sidecar.CustomProperties[key]; !ok && value != nil {
+			if sidecar.CustomProperties == nil {
+				sidecar.CustomProperties = map[string]interface{}{}
+			}
+			sidecar.CustomProperties[key] = value
+		}
+	}
+}
+
+func (d *SidecarTypeDefinition) validateSidecar(sidecar *SidecarParams, errors []error) []error {
+	for _, key := range d.Required {
+		if value, ok := sidecar.CustomProperties[key]; !ok || value == nil || value == "" {
+			errors = append(errors, fmt.Errorf("Sidecar type %v requires property %v; set it via sidecars[].%v property on this stage", d.Type, key, key))
+		}
+	}
+
+	return errors
+}
+
+func (p *Params) validateSidecarTypes(errors []error) []error {
+	types := map[SidecarType]bool{}
+	for _, definition := range p.SidecarTypes {
+		if definition == nil || definition.Type == SidecarTypeUnknown {
+			errors = append(errors, fmt.Errorf("The type of a sidecar type definition is required; set it via sidecarTypes[].type property"))
+			continue
+		}
+		if definition.Type.IsBuiltIn() || definition.Type == "none" {
+			errors = append(errors, fmt.Errorf("Sidecar type %v is built into the extension and can't be declared in sidecarTypes", definition.Type))
+		}
+		if types[definition.Type] {
+			errors = append(errors, fmt.Errorf("Sidecar type %v is declared more than once", definition.Type))
+		}
+		types[definition.Type] = true
+
+		if definition.Template != "" {
+			// execute the template against empty data to catch references to unknown fields before deploying
+			tmpl, err := template.New(string(definition.Type)).Parse(definition.Template)
+			if err == nil {
+				err = tmpl.Execute(ioutil.Discard, SidecarTemplateData{})
+			}
+			if err != nil {
+				errors = append(errors, fmt.Errorf("The template of sidecar type %v is invalid: %v", definition.Type, err))
+			}
+		}
+	}
+
+	return errors
+}
@@ -24,10 +24,42 @@ type TemplateData struct {
 	ApigeeHosts                          []string
 	ApigeeHostsJoined                    string
 	IngressPath                          string
+	IngressPathType                      string
 	InternalIngressPath                  string
+	InternalIngressPathType              string
 	UseIngress                           bool
 	UseNginxIngress                      bool
 	UseGCEIngress                        bool
+	Routes                               []RouteData
+	IngressController                    IngressControllerData
+	InternalIngressController            IngressControllerData
+	ApigeeIngressController              IngressControllerData
+	RoutesIngressController              IngressControllerData
+	UseGatewayRoute                      bool
+	GatewayName                          string
+	GatewayNamespace                     string
+	GatewaySectionName                   string
+	InternalGatewayName                  string
+	InternalGatewayNamespace             string
+	InternalGatewaySectionName           string
+	GatewayPath                          string
+	GatewayRequestTimeout                string
+	GatewayBackendPort                   int
+	GatewayBackends                      []GatewayBackendData
+	UseIstio                             bool
+	IstioMTLS                            bool
+	IstioProxy                           SidecarData
+	MeshTimeout                          string
+	MeshRetryAttempts                    int
+	MeshRetryPerTryTimeout               string
+	MeshRetryOn                          string
+	UseMeshOutlierDetection              bool
+	MeshOutlierDetection                 MeshOutlierDetectionData
+	MeshDestinations                     []MeshDestinationData
+	UseNetworkPolicy                     bool
+	NetworkPolicyIngress                 []interface{}
+	NetworkPolicyRestrictEgress          bool
+	NetworkPolicyEgress                  []interface{}
 	UseDNSAnnotationsOnIngress           bool
 	UseCloudflareProxy                   bool
 	UseDNSAnnotationsOnService           bool
@@ -38,6 +70,8 @@ type TemplateData struct {
 	MinReplicas                          int
 	MaxReplicas                          int
 	TargetCPUPercentage                  int
+	HpaMetrics                           []interface{}
+	HpaBehavior                          map[string]interface{}
 	UseHpaScaler                         bool
 	HpaScalerPromQuery                   string
 	HpaScalerRequestsPerReplica          string
@@ -47,7 +81,10 @@ type TemplateData struct {
 	PreferPreemptibles                   bool
 	UseWindowsNodes                      bool
 	Container                            ContainerData
+	UseJaegerTracing                     bool
+	UseOpenTelemetryNodeIP               bool
 	Sidecars                             []SidecarData
+	UseNativeSidecars                    bool
 	HasCustomSidecars                    bool
 	CustomSidecars                       []*map[string]interface{}
 	HasInitContainers                    bool
@@ -57,20 +94,26 @@ type TemplateData struct {
 	HasEspConfigID                       bool
 	EspConfigID                          string
 	EspService                           string
+	EspBackend                           string
 	EspRequestTimeout                    int
 	MountVolumes                         bool
 	MountSslCertificate                  bool
 	MountApplicationSecrets              bool
 	Secrets                              map[string]interface{}
+	HasSecretManagerVersions             bool
+	SecretManagerVersions                string
 	SecretMountPath                      string
 	MountConfigmap                       bool
 	ConfigmapFiles                       map[string]string
 	ConfigMountPath                      string
+	SharedConfigName                     string
 	MountPayloadLogging                  bool
 	MountServiceAccountSecret            bool
 	DisableServiceAccountKeyRotation     bool
 	UseLegacyServiceAccountKey           bool
 	LegacyServiceAccountKey              string
+	UseWorkloadIdentity                  bool
+	WorkloadIdentityGCPAccount           string
 	GoogleCloudCredentialsAppName        string
 	GoogleCloudCredentialsLabels         map[string]string
 	StrategyType                         string
@@ -98,8 +141,29 @@ type TemplateData struct {
 	SetsNginxIngressLoadBalanceAlgorithm bool
 	NginxIngressLoadBalanceAlgorithm     string
 	UseHTTPS                             bool
+	NginxIngressBackendProtocol          string
+	NginxIngressApigeeBackendProtocol    string
+	ServiceAppProtocols                  string
+	ServiceAppProtocol                   string
 	AllowHTTP                            bool
 	BackendConfigTimeout                 int
+	UseIAP                               bool
+	BackendConfigSecurityPolicy          string
+	UseBackendConfigCDN                  bool
+	BackendConfigCDNCacheMode            string
+	UseBackendConfigCDNTTLs              bool
+	BackendConfigCDNDefaultTTL           int
+	BackendConfigCDNMaxTTL               int
+	BackendConfigCDNClientTTL            int
+	BackendConfigCDNIncludeQueryString   bool
+	BackendConfigSessionAffinityType     string
+	BackendConfigSessionAffinityTTL      int
+	BackendConfigDrainingTimeout         int
+	BackendConfigHealthCheckPath         string
+	BackendConfigHealthCheckPort         int
+	BackendConfigHealthCheckType         string
+	UseBackendConfigLogging              bool
+	BackendConfigLoggingSampleRate       float64
 	NginxAuthTLSSecret                   string
 	NginxAuthTLSVerifyDepth              int
 	Tolerations                          []*map[string]interface{}
@@ -111,6 +175,8 @@ type TemplateData struct {
 	StorageClass                    string
 	StorageSize                     string
 	StorageMountPath                string
+	UsePartition                    bool
+	Partition                       int
 	IapOauthCredentialsClientID     string
 	IapOauthCredentialsClientSecret string
 	IsSimpleEnvvarValue             func(interface{}) bool
@@ -118,12 +184,75 @@ type TemplateData struct {
 	RenderToYAML                    func(v interface{}, data interface{}) string
 	UseCertificateSecret            bool
 	CertificateSecretName           string
+	UseCertManagerCertificate       bool
+	CertManagerIssuer               string
+	CertManagerIssuerKind           string
+	UseManagedCertificate           bool
 
 	HasImagePullSecret bool
 	DockerConfig       map[string]map[string]map[string]string
 
 	IncludeAtomicIDSelector bool
 	AtomicID                string
+
+	APIVersions APIVersionsData
+}
+
+// APIVersionsData has the api versions to render resources with, picked from the ones served by the cluster
+type APIVersionsData struct {
+	Ingress                 string
+	PodDisruptionBudget     string
+	CronJob                 string
+	HorizontalPodAutoscaler string
+	BackendConfig           string
+	Gateway                 string
+	IstioNetworking         string
+}
+
+// MeshOutlierDetectionData has the settings for ejecting misbehaving pods by the istio service mesh
+type MeshOutlierDetectionData struct {
+	Consecutive5xxErrors int
+	Interval             string
+	BaseEjectionTime     string
+	MaxEjectionPercent   int
+}
+
+// MeshDestinationData has the subset and weight of a destination for a virtualservice
+type MeshDestinationData struct {
+	Subset string
+	Weight int
+}
+
+// RouteData has the hosts, path, port and request settings for the ingress of a route
+type RouteData struct {
+	Name                 string
+	Hosts                []string
+	Path                 string
+	PathType             string
+	PortName             string
+	BackendProtocol      string
+	ProxyConnectTimeout  int
+	ProxySendTimeout     int
+	ProxyReadTimeout     int
+	ProxyBodySize        string
+	ClientBodyBufferSize string
+	ProxyBufferSize      string
+	ProxyBuffersNumber   string
+	LoadBalanceAlgorithm string
+}
+
+// IngressControllerData contains the class, annotation prefix and supported features of the ingress controller profile used for an ingress
+type IngressControllerData struct {
+	Name             string
+	Class            string
+	AnnotationPrefix string
+	Features         map[string]bool
+}
+
+// GatewayBackendData has the service and weight of a backend for an httproute
+type GatewayBackendData struct {
+	Name   string
+	Weight int
 }
 
 // ContainerData has data specific to the application container
@@ -131,12 +260,14 @@ type ContainerData struct {
 	Repository                      string
 	Name                            string
 	Tag                             string
+	Digest                          string
 	ImagePullPolicy                 string
 	CPURequest                      string
 	MemoryRequest                   string
 	CPULimit                        string
 	MemoryLimit                     string
 	Port                            int
+	Protocol                        string
 	EnvironmentVariables            map[string]interface{}
 	SecretEnvironmentVariables      map[string]interface{}
 	Liveness                        ProbeData
@@ -148,8 +279,10 @@ type ContainerData struct {
 
 // ProbeData has data specific to liveness and readiness probes
 type ProbeData struct {
+	Type                string
 	Path                string
 	Port                int
+	Scheme              string
 	InitialDelaySeconds int
 	TimeoutSeconds      int
 	PeriodSeconds       int
@@ -179,6 +312,14 @@ type SidecarData struct {
 	SidecarSpecificProperties  map[string]interface{}
 	HasCustomProperties        bool
 	CustomPropertiesYAML       string
+	ContainerTemplate          string
+	ContainerYAML              string
+}
+
+// SidecarTemplateData is passed to the container template of a declared sidecar type
+type SidecarTemplateData struct {
+	Sidecar    SidecarData
+	Deployment TemplateData
 }
 
 // VolumeMountData configures additional volume mounts for shared secrets, existing volumes, etc
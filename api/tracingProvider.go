@@ -0,0 +1,11 @@
+package api
+
+type TracingProvider string
+
+const (
+	TracingProviderJaeger        TracingProvider = "jaeger"
+	TracingProviderOpenTelemetry TracingProvider = "otel"
+	TracingProviderNone          TracingProvider = "none"
+
+	TracingProviderUnknown TracingProvider = ""
+)
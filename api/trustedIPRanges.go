@@ -0,0 +1,203 @@
+package api
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type TrustedIPRangesProviderType string
+
+const (
+	TrustedIPRangesProviderCloudflareV4 TrustedIPRangesProviderType = "cloudflare-v4"
+	TrustedIPRangesProviderCloudflareV6 TrustedIPRangesProviderType = "cloudflare-v6"
+	TrustedIPRangesProviderURL          TrustedIPRangesProviderType = "url"
+	TrustedIPRangesProviderList         TrustedIPRangesProviderType = "list"
+
+	TrustedIPRangesProviderUnknown TrustedIPRangesProviderType = ""
+)
+
+// TrustedIPRangesProviderParams is a source of trusted ip ranges; a cloudflare list, a url returning one cidr per line or a list set in the credential defaults
+type TrustedIPRangesProviderParams struct {
+	Type   TrustedIPRangesProviderType `json:"type,omitempty" yaml:"type,omitempty"`
+	URL    string                      `json:"url,omitempty" yaml:"url,omitempty"`
+	Ranges []string                    `json:"ranges,omitempty" yaml:"ranges,omitempty"`
+}
+
+// TrustedIPRangesCacheParams sets where and for how long fetched trusted ip ranges are cached
+type TrustedIPRangesCacheParams struct {
+	Directory string `json:"directory,omitempty" yaml:"directory,omitempty"`
+	TTL       string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// bundledTrustedIPRanges are snapshots of the provider lists, used when they can't be fetched or read from cache
+var bundledTrustedIPRanges = map[TrustedIPRangesProviderType][]string{
+	// from https://www.cloudflare.com/ips-v4
+	TrustedIPRangesProviderCloudflareV4: {
+		"103.21.244.0/22",
+		"103.22.200.0/22",
+		"103.31.4.0/22",
+		"104.16.0.0/12",
+		"108.162.192.0/18",
+		"131.0.72.0/22",
+		"141.101.64.0/18",
+		"162.158.0.0/15",
+		"172.64.0.0/13",
+		"173.245.48.0/20",
+		"188.114.96.0/20",
+		"190.93.240.0/20",
+		"197.234.240.0/22",
+		"198.41.128.0/17",
+	},
+	// from https://www.cloudflare.com/ips-v6
+	TrustedIPRangesProviderCloudflareV6: {
+		"2400:cb00::/32",
+		"2606:4700::/32",
+		"2803:f800::/32",
+		"2405:b500::/32",
+		"2405:8100::/32",
+		"2a06:98c0::/29",
+		"2c0f:f248::/32",
+	},
+}
+
+func (tp TrustedIPRangesProviderParams) getURL() string {
+	switch tp.Type {
+	case TrustedIPRangesProviderCloudflareV4:
+		return "https://www.cloudflare.com/ips-v4"
+	case TrustedIPRangesProviderCloudflareV6:
+		return "https://www.cloudflare.com/ips-v6"
+	case TrustedIPRangesProviderURL:
+		return tp.URL
+	}
+	return ""
+}
+
+func (tp TrustedIPRangesProviderParams) getBundledRanges() []string {
+	if tp.Type == TrustedIPRangesProviderList {
+		return tp.Ranges
+	}
+	return bundledTrustedIPRanges[tp.Type]
+}
+
+// getBundledTrustedIPRanges returns the trusted ip ranges of all providers without fetching them
+func (p *Params) getBundledTrustedIPRanges() []string {
+	ranges := []string{}
+	for _, provider := range p.TrustedIPRangesProviders {
+		ranges = append(ranges, provider.getBundledRanges()...)
+	}
+	return ranges
+}
+
+// LimitsTrustedIPRanges returns true if the release renders a load balancer service limited to the trusted ip ranges, the only case they need to be refreshed for
+func (p *Params) LimitsTrustedIPRanges() bool {
+	if p.Kind != KindDeployment && p.Kind != KindStatefulset {
+		return false
+	}
+
+	switch p.Action {
+	case ActionDeploySimple, ActionDeployCanary, ActionDeployStable, ActionDiffSimple, ActionDiffCanary, ActionDiffStable:
+	default:
+		return false
+	}
+
+	return p.Visibility == VisibilityPublic || p.Visibility == VisibilityESP || p.Visibility == VisibilityESPv2
+}
+
+// ResolveTrustedIPRanges refreshes the trusted ip ranges from their providers, using a cache on disk and falling back to the bundled ranges when offline
+func (p *Params) ResolveTrustedIPRanges() {
+	if len(p.TrustedIPRangesProviders) == 0 {
+		return
+	}
+
+	ttl, err := time.ParseDuration(p.TrustedIPRangesCache.TTL)
+	if err != nil {
+		ttl = 24 * time.Hour
+	}
+
+	ranges := []string{}
+	for _, provider := range p.TrustedIPRangesProviders {
+		ranges = append(ranges, p.getTrustedIPRangesFromProvider(provider, ttl)...)
+	}
+
+	if len(ranges) > 0 {
+		p.TrustedIPRanges = ranges
+	}
+}
+
+func (p *Params) getTrustedIPRangesFromProvider(provider TrustedIPRangesProviderParams, ttl time.Duration) []string {
+	url := provider.getURL()
+	if url == "" {
+		return provider.getBundledRanges()
+	}
+
+	cacheFile := filepath.Join(p.TrustedIPRangesCache.Directory, fmt.Sprintf("%x.txt", sha256.Sum256([]byte(url))))
+
+	// use the cached ranges while they're fresh
+	if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < ttl {
+		ranges, err := readTrustedIPRanges(cacheFile)
+		if err == nil {
+			log.Info().Msgf("Using %v cached trusted ip ranges from %v", len(ranges), url)
+			return ranges
+		}
+	}
+
+	log.Info().Msgf("Fetching trusted ip ranges from %v...", url)
+	ranges, err := parseTrustedIPRanges(httpRequestBody("GET", url, map[string]string{}))
+	if err == nil {
+		err = os.MkdirAll(p.TrustedIPRangesCache.Directory, 0755)
+		if err == nil {
+			err = ioutil.WriteFile(cacheFile, []byte(strings.Join(ranges, "\n")), 0644)
+		}
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed caching trusted ip ranges from %v", url)
+		}
+		return ranges
+	}
+	log.Warn().Err(err).Msgf("Failed fetching trusted ip ranges from %v", url)
+
+	// an expired cache is still more recent than the bundled ranges
+	ranges, err = readTrustedIPRanges(cacheFile)
+	if err == nil {
+		log.Info().Msgf("Using %v expired cached trusted ip ranges from %v", len(ranges), url)
+		return ranges
+	}
+
+	bundledRanges := provider.getBundledRanges()
+	log.Info().Msgf("Using %v bundled trusted ip ranges for %v", len(bundledRanges), url)
+	return bundledRanges
+}
+
+func readTrustedIPRanges(file string) ([]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseTrustedIPRanges(string(content))
+}
+
+// parseTrustedIPRanges returns the cidrs from a list with one per line, and rejects the list entirely if any of them is invalid
+func parseTrustedIPRanges(content string) ([]string, error) {
+	ranges := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(line); err != nil {
+			return nil, fmt.Errorf("Trusted ip range %v is not a valid cidr", line)
+		}
+		ranges = append(ranges, line)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("No trusted ip ranges found")
+	}
+	return ranges, nil
+}
@@ -10,6 +10,7 @@ const (
 	VisibilityESPv2           Visibility = "espv2"
 	VisibilityIAP             Visibility = "iap"
 	VisibilityApigee          Visibility = "apigee"
+	VisibilityGateway         Visibility = "gateway"
 
 	VisibilityUnknown Visibility = ""
 )
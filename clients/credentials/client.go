@@ -2,6 +2,7 @@ package credentials
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -11,12 +12,15 @@ import (
 	"github.com/estafette/estafette-extension-gke/api"
 	foundation "github.com/estafette/estafette-foundation"
 	"github.com/rs/zerolog/log"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 //go:generate mockgen -package=credentials -destination ./mock.go -source=client.go
 type Client interface {
-	Init(ctx context.Context, paramsJSON, releaseName, credentialsPath string) (credential *api.GKECredentials, err error)
+	Init(ctx context.Context, paramsJSON, releaseName string, credentialsPaths []string) (credential *api.GKECredentials, err error)
 	GetCredentialsByName(c []api.GKECredentials, credentialName string) *api.GKECredentials
+	LoadKubernetesClusterKubeConfig(ctx context.Context, credential *api.GKECredentials) (kubeContextName string, err error)
 }
 
 // NewClient returns a new gcp.Client
@@ -27,7 +31,7 @@ func NewClient(ctx context.Context) (Client, error) {
 type client struct {
 }
 
-func (c *client) Init(ctx context.Context, paramsJSON, releaseName, credentialsPath string) (credential *api.GKECredentials, err error) {
+func (c *client) Init(ctx context.Context, paramsJSON, releaseName string, credentialsPaths []string) (credential *api.GKECredentials, err error) {
 	log.Info().Msg("Unmarshalling credentials parameter...")
 	var credentialsParam api.CredentialsParam
 	err = json.Unmarshal([]byte(paramsJSON), &credentialsParam)
@@ -48,24 +52,28 @@ func (c *client) Init(ctx context.Context, paramsJSON, releaseName, credentialsP
 	log.Info().Msg("Unmarshalling injected credentials...")
 	var credentials []api.GKECredentials
 
-	// use mounted credential file if present instead of relying on an envvar
-	if runtime.GOOS == "windows" {
-		credentialsPath = "C:" + credentialsPath
-	}
-	if foundation.FileExists(credentialsPath) {
-		log.Info().Msgf("Reading credentials from file at path %v...", credentialsPath)
-		credentialsFileContent, err := ioutil.ReadFile(credentialsPath)
-		if err != nil {
-			return nil, fmt.Errorf("Failed reading credential file at path %v.", credentialsPath)
-		}
-		err = json.Unmarshal(credentialsFileContent, &credentials)
-		if err != nil {
-			return nil, fmt.Errorf("Failed unmarshalling injected credentials: %w", err)
+	// use mounted credential files if present instead of relying on an envvar; there's one per credential type
+	for _, credentialsPath := range credentialsPaths {
+		if runtime.GOOS == "windows" {
+			credentialsPath = "C:" + credentialsPath
 		}
-		if len(credentials) == 0 {
-			log.Warn().Str("data", string(credentialsFileContent)).Msgf("Found 0 credentials in file %v", credentialsPath)
+		if foundation.FileExists(credentialsPath) {
+			log.Info().Msgf("Reading credentials from file at path %v...", credentialsPath)
+			credentialsFileContent, err := ioutil.ReadFile(credentialsPath)
+			if err != nil {
+				return nil, fmt.Errorf("Failed reading credential file at path %v.", credentialsPath)
+			}
+			var credentialsFromFile []api.GKECredentials
+			err = json.Unmarshal(credentialsFileContent, &credentialsFromFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed unmarshalling injected credentials: %w", err)
+			}
+			if len(credentialsFromFile) == 0 {
+				log.Warn().Str("data", string(credentialsFileContent)).Msgf("Found 0 credentials in file %v", credentialsPath)
+			}
+			log.Debug().Msgf("Read %v credentials", len(credentialsFromFile))
+			credentials = append(credentials, credentialsFromFile...)
 		}
-		log.Debug().Msgf("Read %v credentials", len(credentials))
 	}
 
 	log.Info().Msgf("Checking if credential %v exists...", credentialsParam.Credentials)
@@ -74,6 +82,11 @@ func (c *client) Init(ctx context.Context, paramsJSON, releaseName, credentialsP
 		return nil, fmt.Errorf("Credential with name %v does not exist", credentialsParam.Credentials)
 	}
 
+	// a kubernetes-cluster credential has no service account keyfile, its kube config is generated from the credential itself
+	if credential.IsKubernetesCluster() {
+		return
+	}
+
 	log.Info().Msgf("Storing gke credential %v on disk at path %v...", credentialsParam.Credentials, os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
 	err = ioutil.WriteFile(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), []byte(credential.AdditionalProperties.ServiceAccountKeyfile), 0666)
 	if err != nil {
@@ -92,3 +105,88 @@ func (c *client) GetCredentialsByName(creds []api.GKECredentials, credentialName
 
 	return nil
 }
+
+// LoadKubernetesClusterKubeConfig adds a context for a kubernetes-cluster credential to the kube config, without any calls to gcp apis
+func (c *client) LoadKubernetesClusterKubeConfig(ctx context.Context, credential *api.GKECredentials) (kubeContextName string, err error) {
+	if credential == nil {
+		return kubeContextName, fmt.Errorf("LoadKubernetesClusterKubeConfig argument credential is nil")
+	}
+	if credential.AdditionalProperties.Server == "" {
+		return kubeContextName, fmt.Errorf("LoadKubernetesClusterKubeConfig credential argument has empty Server")
+	}
+	hasClientCertificate := credential.AdditionalProperties.ClientCertificateData != "" && credential.AdditionalProperties.ClientKeyData != ""
+	if credential.AdditionalProperties.Token == "" && !hasClientCertificate {
+		return kubeContextName, fmt.Errorf("LoadKubernetesClusterKubeConfig credential argument has empty Token and no ClientCertificateData and ClientKeyData")
+	}
+
+	kubeContextName = credential.Name
+
+	log.Info().Msgf("Generating .kube/config sections for context %v", kubeContextName)
+
+	kubeConfigPath := os.Getenv("KUBECONFIG")
+	if kubeConfigPath == "" {
+		return kubeContextName, fmt.Errorf("Value of envvar KUBECONFIG is empty, cannot create kube config")
+	}
+
+	// check if kubeconfig exists and read if it does
+	currentConfig := clientcmdapi.NewConfig()
+	if foundation.FileExists(kubeConfigPath) {
+		currentConfig, err = clientcmd.LoadFromFile(kubeConfigPath)
+		if err != nil {
+			return
+		}
+	}
+
+	cluster := &clientcmdapi.Cluster{
+		Server:                credential.AdditionalProperties.Server,
+		InsecureSkipTLSVerify: credential.AdditionalProperties.InsecureSkipTLSVerify,
+	}
+	if credential.AdditionalProperties.CertificateAuthorityData != "" {
+		cluster.CertificateAuthorityData, err = base64.StdEncoding.DecodeString(credential.AdditionalProperties.CertificateAuthorityData)
+		if err != nil {
+			return kubeContextName, fmt.Errorf("Failed decoding certificateAuthorityData of credential %v: %w", credential.Name, err)
+		}
+	}
+
+	authInfo := &clientcmdapi.AuthInfo{
+		Token: credential.AdditionalProperties.Token,
+	}
+	if hasClientCertificate {
+		authInfo.ClientCertificateData, err = base64.StdEncoding.DecodeString(credential.AdditionalProperties.ClientCertificateData)
+		if err != nil {
+			return kubeContextName, fmt.Errorf("Failed decoding clientCertificateData of credential %v: %w", credential.Name, err)
+		}
+		authInfo.ClientKeyData, err = base64.StdEncoding.DecodeString(credential.AdditionalProperties.ClientKeyData)
+		if err != nil {
+			return kubeContextName, fmt.Errorf("Failed decoding clientKeyData of credential %v: %w", credential.Name, err)
+		}
+	}
+
+	// unlike for gke the server and token come from the credential itself, so always overwrite them in case it has been updated
+	currentConfig.Clusters[kubeContextName] = cluster
+	currentConfig.AuthInfos[kubeContextName] = authInfo
+	currentConfig.Contexts[kubeContextName] = &clientcmdapi.Context{
+		Cluster:  kubeContextName,
+		AuthInfo: kubeContextName,
+	}
+
+	// set apiversion if empty
+	if currentConfig.APIVersion == "" {
+		currentConfig.APIVersion = "v1"
+	}
+
+	// set kind if empty
+	if currentConfig.Kind == "" {
+		currentConfig.Kind = "Config"
+	}
+
+	currentConfig.CurrentContext = kubeContextName
+
+	// write kube config file
+	err = clientcmd.WriteToFile(*currentConfig, kubeConfigPath)
+	if err != nil {
+		return
+	}
+
+	return
+}
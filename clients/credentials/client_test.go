@@ -2,6 +2,8 @@ package credentials
 
 import (
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/estafette/estafette-extension-gke/api"
@@ -45,3 +47,51 @@ func TestGetCredentialsByName(t *testing.T) {
 		assert.Nil(t, credential)
 	})
 }
+
+func TestInit(t *testing.T) {
+
+	t.Run("ReturnsKubernetesClusterCredentialFromSecondCredentialsFile", func(t *testing.T) {
+
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		dir := t.TempDir()
+		gkeCredentialsPath := filepath.Join(dir, "kubernetes_engine.json")
+		clusterCredentialsPath := filepath.Join(dir, "kubernetes_cluster.json")
+		ioutil.WriteFile(gkeCredentialsPath, []byte(`[{"name":"gke-production","type":"kubernetes-engine"}]`), 0644)
+		ioutil.WriteFile(clusterCredentialsPath, []byte(`[{"name":"kind-local","type":"kubernetes-cluster","additionalProperties":{"server":"https://127.0.0.1:6443","token":"abc"}}]`), 0644)
+
+		// act
+		credential, err := client.Init(context.Background(), `{"credentials":"kind-local"}`, "local", []string{gkeCredentialsPath, clusterCredentialsPath})
+
+		assert.Nil(t, err)
+		if assert.NotNil(t, credential) {
+			assert.True(t, credential.IsKubernetesCluster())
+			assert.Equal(t, "https://127.0.0.1:6443", credential.AdditionalProperties.Server)
+		}
+	})
+}
+
+func TestLoadKubernetesClusterKubeConfig(t *testing.T) {
+
+	t.Run("ReturnsErrorIfCredentialHasNeitherTokenNorClientCertificate", func(t *testing.T) {
+
+		client, err := NewClient(context.Background())
+		assert.Nil(t, err)
+
+		t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "config"))
+
+		credential := &api.GKECredentials{
+			Name: "kind-local",
+			Type: api.CredentialTypeKubernetesCluster,
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Server: "https://127.0.0.1:6443",
+			},
+		}
+
+		// act
+		_, err = client.LoadKubernetesClusterKubeConfig(context.Background(), credential)
+
+		assert.NotNil(t, err)
+	})
+}
@@ -35,18 +35,18 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // Init mocks base method
-func (m *MockClient) Init(ctx context.Context, paramsJSON, releaseName, credentialsPath string) (*api.GKECredentials, error) {
+func (m *MockClient) Init(ctx context.Context, paramsJSON, releaseName string, credentialsPaths []string) (*api.GKECredentials, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Init", ctx, paramsJSON, releaseName, credentialsPath)
+	ret := m.ctrl.Call(m, "Init", ctx, paramsJSON, releaseName, credentialsPaths)
 	ret0, _ := ret[0].(*api.GKECredentials)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Init indicates an expected call of Init
-func (mr *MockClientMockRecorder) Init(ctx, paramsJSON, releaseName, credentialsPath interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) Init(ctx, paramsJSON, releaseName, credentialsPaths interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClient)(nil).Init), ctx, paramsJSON, releaseName, credentialsPath)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockClient)(nil).Init), ctx, paramsJSON, releaseName, credentialsPaths)
 }
 
 // GetCredentialsByName mocks base method
@@ -62,3 +62,18 @@ func (mr *MockClientMockRecorder) GetCredentialsByName(c, credentialName interfa
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredentialsByName", reflect.TypeOf((*MockClient)(nil).GetCredentialsByName), c, credentialName)
 }
+
+// LoadKubernetesClusterKubeConfig mocks base method
+func (m *MockClient) LoadKubernetesClusterKubeConfig(ctx context.Context, credential *api.GKECredentials) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadKubernetesClusterKubeConfig", ctx, credential)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadKubernetesClusterKubeConfig indicates an expected call of LoadKubernetesClusterKubeConfig
+func (mr *MockClientMockRecorder) LoadKubernetesClusterKubeConfig(ctx, credential interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadKubernetesClusterKubeConfig", reflect.TypeOf((*MockClient)(nil).LoadKubernetesClusterKubeConfig), ctx, credential)
+}
@@ -2,6 +2,7 @@ package gcp
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,6 +24,7 @@ import (
 	containerv1 "google.golang.org/api/container/v1beta1"
 	"google.golang.org/api/googleapi"
 	iamv1 "google.golang.org/api/iam/v1"
+	secretmanagerv1 "google.golang.org/api/secretmanager/v1"
 	servicemanagementv1 "google.golang.org/api/servicemanagement/v1"
 	"gopkg.in/yaml.v2"
 	"k8s.io/client-go/tools/clientcmd"
@@ -55,7 +58,10 @@ var (
 type Client interface {
 	LoadGKEClusterKubeConfig(ctx context.Context, credential *api.GKECredentials) (kubeContextName string, err error)
 	GetGKECluster(ctx context.Context, projectID, location, clusterID string) (cluster *containerv1.Cluster, err error)
-	DeployGoogleCloudEndpoints(ctx context.Context, params api.Params) (err error)
+	DeployGoogleCloudEndpoints(ctx context.Context, params api.Params) (configID string, err error)
+	GetActiveGoogleCloudEndpointsConfigID(ctx context.Context, params api.Params) (configID string, err error)
+	EnsureWorkloadIdentityBinding(ctx context.Context, gcpServiceAccount, workloadPool, namespace, kubernetesServiceAccount string) (err error)
+	ResolveSecretManagerReferences(ctx context.Context, params *api.Params) (err error)
 }
 
 // NewClient returns a new gcp.Client
@@ -77,15 +83,29 @@ func NewClient(ctx context.Context) (Client, error) {
 		return nil, err
 	}
 
+	iamv1Service, err := iamv1.New(googleClient)
+	if err != nil {
+		return nil, err
+	}
+
+	secretmanagerv1Service, err := secretmanagerv1.New(googleClient)
+	if err != nil {
+		return nil, err
+	}
+
 	return &client{
 		containerv1Service:         containerv1Service,
 		servicemanagementv1Service: servicemanagementv1Service,
+		iamv1Service:               iamv1Service,
+		secretManagerClient:        NewSecretManagerClient(secretmanagerv1Service),
 	}, nil
 }
 
 type client struct {
 	containerv1Service         *containerv1.Service
 	servicemanagementv1Service *servicemanagementv1.APIService
+	iamv1Service               *iamv1.Service
+	secretManagerClient        SecretManagerClient
 }
 
 func (c *client) LoadGKEClusterKubeConfig(ctx context.Context, credential *api.GKECredentials) (kubeContextName string, err error) {
@@ -125,27 +145,19 @@ func (c *client) LoadGKEClusterKubeConfig(ctx context.Context, credential *api.G
 		}
 	}
 
-	decodedClusterCaCertificate, err := base64.StdEncoding.DecodeString(cluster.MasterAuth.ClusterCaCertificate)
+	clusterConfig, err := getKubeConfigCluster(credential, cluster)
 	if err != nil {
 		return
 	}
 
-	// add cluster if it doesn't exist
-	if _, exists := currentConfig.Clusters[kubeContextName]; !exists {
-		currentConfig.Clusters[kubeContextName] = &clientcmdapi.Cluster{
-			Server:                   fmt.Sprintf("https://%v", cluster.Endpoint),
-			CertificateAuthorityData: decodedClusterCaCertificate,
-		}
+	authInfo, err := getKubeConfigAuthInfo(ctx, credential)
+	if err != nil {
+		return
 	}
 
-	// add authinfo if it doesn't exist
-	if _, exists := currentConfig.AuthInfos[kubeContextName]; !exists {
-		currentConfig.AuthInfos[kubeContextName] = &clientcmdapi.AuthInfo{
-			AuthProvider: &clientcmdapi.AuthProviderConfig{
-				Name: "gcp",
-			},
-		}
-	}
+	// set cluster and authinfo even if they exist, since the endpoint can change and tokens expire
+	currentConfig.Clusters[kubeContextName] = clusterConfig
+	currentConfig.AuthInfos[kubeContextName] = authInfo
 
 	// add context if it doesn't exist
 	if _, exists := currentConfig.Contexts[kubeContextName]; !exists {
@@ -176,6 +188,90 @@ func (c *client) LoadGKEClusterKubeConfig(ctx context.Context, credential *api.G
 	return
 }
 
+// getKubeConfigCluster returns the public, private or dns endpoint of the cluster as set in the credential
+func getKubeConfigCluster(credential *api.GKECredentials, cluster *containerv1.Cluster) (*clientcmdapi.Cluster, error) {
+	switch credential.AdditionalProperties.Endpoint {
+	case api.GKEClusterEndpointDNS:
+		if credential.AdditionalProperties.DNSEndpoint == "" {
+			return nil, fmt.Errorf("Credential %v uses endpoint dns, but has empty dnsEndpoint", credential.Name)
+		}
+		// the dns endpoint serves a publicly trusted certificate instead of one signed by the cluster ca
+		return &clientcmdapi.Cluster{
+			Server: fmt.Sprintf("https://%v", credential.AdditionalProperties.DNSEndpoint),
+		}, nil
+
+	case api.GKEClusterEndpointPublic, api.GKEClusterEndpointPrivate, api.GKEClusterEndpointUnknown:
+		if cluster == nil || cluster.MasterAuth == nil {
+			return nil, fmt.Errorf("Cluster of credential %v has no master auth to get the ca certificate from", credential.Name)
+		}
+
+		decodedClusterCaCertificate, err := base64.StdEncoding.DecodeString(cluster.MasterAuth.ClusterCaCertificate)
+		if err != nil {
+			return nil, err
+		}
+
+		endpoint := cluster.Endpoint
+		if credential.AdditionalProperties.Endpoint == api.GKEClusterEndpointPrivate {
+			if cluster.PrivateClusterConfig == nil || cluster.PrivateClusterConfig.PrivateEndpoint == "" {
+				return nil, fmt.Errorf("Credential %v uses endpoint private, but cluster %v has no private endpoint", credential.Name, cluster.Name)
+			}
+			endpoint = cluster.PrivateClusterConfig.PrivateEndpoint
+		}
+
+		return &clientcmdapi.Cluster{
+			Server:                   fmt.Sprintf("https://%v", endpoint),
+			CertificateAuthorityData: decodedClusterCaCertificate,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Credential %v has unsupported endpoint %v; use public, private or dns", credential.Name, credential.AdditionalProperties.Endpoint)
+}
+
+// getKubeConfigAuthInfo returns the gke-gcloud-auth-plugin by default, which refreshes its token during long rollouts, or a short-lived access token minted from the service account keyfile or the deprecated gcp auth provider as set in the credential
+func getKubeConfigAuthInfo(ctx context.Context, credential *api.GKECredentials) (*clientcmdapi.AuthInfo, error) {
+	switch credential.AdditionalProperties.Auth {
+	case api.GKEClusterAuthToken:
+		keyfilePath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+		keyfileBytes, err := ioutil.ReadFile(keyfilePath)
+		if err != nil {
+			return nil, fmt.Errorf("Failed reading service account keyfile at path %v to mint an access token: %w", keyfilePath, err)
+		}
+
+		googleCredentials, err := google.CredentialsFromJSON(ctx, keyfileBytes, containerv1.CloudPlatformScope)
+		if err != nil {
+			return nil, err
+		}
+
+		token, err := googleCredentials.TokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("Failed minting access token for credential %v: %w", credential.Name, err)
+		}
+
+		return &clientcmdapi.AuthInfo{
+			Token: token.AccessToken,
+		}, nil
+
+	case api.GKEClusterAuthExec, api.GKEClusterAuthUnknown:
+		return &clientcmdapi.AuthInfo{
+			Exec: &clientcmdapi.ExecConfig{
+				APIVersion:  "client.authentication.k8s.io/v1beta1",
+				Command:     "gke-gcloud-auth-plugin",
+				Args:        []string{"--use_application_default_credentials"},
+				InstallHint: "Install gke-gcloud-auth-plugin for use with kubectl by following https://cloud.google.com/kubernetes-engine/docs/how-to/cluster-access-for-kubectl#install_plugin",
+			},
+		}, nil
+
+	case api.GKEClusterAuthGCP:
+		return &clientcmdapi.AuthInfo{
+			AuthProvider: &clientcmdapi.AuthProviderConfig{
+				Name: "gcp",
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("Credential %v has unsupported auth %v; use token, exec or gcp", credential.Name, credential.AdditionalProperties.Auth)
+}
+
 func (c *client) GetGKECluster(ctx context.Context, projectID, location, clusterID string) (cluster *containerv1.Cluster, err error) {
 	if projectID == "" {
 		return nil, fmt.Errorf("GetGKECluster argument projectID is empty")
@@ -206,36 +302,30 @@ func (c *client) GetGKECluster(ctx context.Context, projectID, location, cluster
 	return
 }
 
-func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Params) (err error) {
+// GetActiveGoogleCloudEndpointsConfigID returns the id of the config of the active rollout without submitting anything; it's empty if the service has no rollout yet
+func (c *client) GetActiveGoogleCloudEndpointsConfigID(ctx context.Context, params api.Params) (configID string, err error) {
 
-	// get host from openapi file
-	if !foundation.FileExists(params.EspOpenAPIYamlPath) {
-		return fmt.Errorf("File at path %v does not exist. Did you forget to use `clone: true` for your release?", params.EspOpenAPIYamlPath)
-	}
-
-	log.Info().Msgf("Checking if openapi spec at path %v exists...", params.EspOpenAPIYamlPath)
-	openapiSpecBytes, err := ioutil.ReadFile(params.EspOpenAPIYamlPath)
+	serviceName, _, err := getEndpointsConfigFiles(params)
 	if err != nil {
 		return
 	}
 
-	var openapiSpec struct {
-		Host string `yaml:"host"`
+	configID, err = c.getActiveServiceConfigID(ctx, serviceName)
+	if err != nil {
+		return configID, fmt.Errorf("Can't get active config for service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
 	}
 
-	log.Info().Msg("Unmarshalling openapi spec to get service name...")
-	err = yaml.Unmarshal(openapiSpecBytes, &openapiSpec)
+	return
+}
+
+// DeployGoogleCloudEndpoints submits the openapi spec or grpc service config and rolls it out, unless the active config has the same spec; it returns the id of the active config
+func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Params) (configID string, err error) {
+
+	serviceName, configFiles, err := getEndpointsConfigFiles(params)
 	if err != nil {
 		return
 	}
 
-	if openapiSpec.Host == "" {
-		return fmt.Errorf("The host field in the openapi spec at %v is empty, please set it", params.EspOpenAPIYamlPath)
-	}
-
-	serviceName := openapiSpec.Host
-	log.Info().Msgf("Found service name %v in openapi", serviceName)
-
 	log.Info().Msgf("Checking if service %v exists...", serviceName)
 	// GET https://servicemanagement.googleapis.com/v1/services/<servicename>
 	var service *servicemanagementv1.ManagedService
@@ -248,7 +338,7 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		return nil
 	}, c.getRetryOptions()...))
 	if err != nil && !errors.Is(err, ErrServiceNotFound) {
-		return fmt.Errorf("Can't get service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
+		return configID, fmt.Errorf("Can't get service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
 	}
 
 	if service == nil {
@@ -270,7 +360,7 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 			return nil
 		}, c.getRetryOptions()...))
 		if err != nil {
-			return fmt.Errorf("Can't get service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
+			return configID, fmt.Errorf("Can't get service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
 		}
 
 		err = c.waitForServiceManagementV1Operation(ctx, params.EspEndpointsProjectID, operation)
@@ -279,6 +369,26 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		}
 	}
 
+	// skip submitting and rolling out a config identical to the active one, it only slows down the release and clutters the config history
+	activeConfigID, err := c.getActiveServiceConfigID(ctx, serviceName)
+	if err != nil {
+		return configID, fmt.Errorf("Can't get active config for service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
+	}
+	if activeConfigID != "" {
+		activeConfigHash, err := c.getServiceConfigSourceHash(ctx, serviceName, activeConfigID)
+		if err != nil {
+			return configID, fmt.Errorf("Can't get config %v for service %v for project %v: %w", activeConfigID, serviceName, params.EspEndpointsProjectID, err)
+		}
+		configHash, err := getConfigFilesHash(configFiles)
+		if err != nil {
+			return configID, err
+		}
+		if activeConfigHash == configHash {
+			log.Info().Msgf("Active config with id %v for service %v in project %v has the same openapi spec, no need to submit it", activeConfigID, serviceName, params.EspEndpointsProjectID)
+			return activeConfigID, nil
+		}
+	}
+
 	log.Info().Msgf("Submitting config for service %v in project %v...", serviceName, params.EspEndpointsProjectID)
 	// POST https://servicemanagement.googleapis.com/v1/services/<servicename>/configs:submit
 	var operation *servicemanagementv1.Operation
@@ -286,13 +396,7 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		// https://cloud.google.com/service-infrastructure/docs/service-management/reference/rest/v1/services.configs/submit
 		operation, err = c.servicemanagementv1Service.Services.Configs.Submit(serviceName, &servicemanagementv1.SubmitConfigSourceRequest{
 			ConfigSource: &servicemanagementv1.ConfigSource{
-				Files: []*servicemanagementv1.ConfigFile{
-					{
-						FileContents: base64.StdEncoding.EncodeToString(openapiSpecBytes),
-						FilePath:     filepath.Base(params.EspOpenAPIYamlPath),
-						FileType:     "OPEN_API_YAML",
-					},
-				},
+				Files: configFiles,
 			},
 			ValidateOnly: false,
 		}).Context(ctx).Do()
@@ -302,7 +406,7 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		return nil
 	}, c.getRetryOptions()...))
 	if err != nil {
-		return fmt.Errorf("Can't submit config for service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
+		return configID, fmt.Errorf("Can't submit config for service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
 	}
 
 	// GET https://servicemanagement.googleapis.com/v1/operations/serviceConfigs.<servicename>%3<config id>
@@ -317,7 +421,7 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		return
 	}
 
-	configID := response.ServiceConfig.Id
+	configID = response.ServiceConfig.Id
 	log.Info().Msgf("Submitted config with id %v for service %v in project %v", configID, serviceName, params.EspEndpointsProjectID)
 
 	log.Info().Msgf("Creating rollout for config with id %v for service %v in project %v...", configID, serviceName, params.EspEndpointsProjectID)
@@ -338,7 +442,7 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		return nil
 	}, c.getRetryOptions()...))
 	if err != nil {
-		return fmt.Errorf("Can't create rollout for service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
+		return configID, fmt.Errorf("Can't create rollout for service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
 	}
 
 	// GET https://servicemanagement.googleapis.com/v1/operations/rollouts.<servicename>%3A9b4bc80c-94a5-49e8-8984-28631648a1d1
@@ -358,13 +462,154 @@ func (c *client) DeployGoogleCloudEndpoints(ctx context.Context, params api.Para
 		return nil
 	}, c.getRetryOptions()...))
 	if err != nil {
-		return fmt.Errorf("Can't get service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
+		return configID, fmt.Errorf("Can't get service %v for project %v: %w", serviceName, params.EspEndpointsProjectID, err)
 	}
 
-	return nil
+	return configID, nil
 	// return foundation.RunCommandWithArgsExtended(ctx, "gcloud", []string{"endpoints", "--project", params.EspEndpointsProjectID, "services", "deploy", params.EspOpenAPIYamlPath, "--log-http"})
 }
 
+// getActiveServiceConfigID returns the id of the config that gets all traffic in the most recent successful rollout
+func (c *client) getActiveServiceConfigID(ctx context.Context, serviceName string) (configID string, err error) {
+	var response *servicemanagementv1.ListServiceRolloutsResponse
+	err = c.substituteErrorsWithPredefinedErrors(foundation.Retry(func() error {
+		// https://cloud.google.com/service-infrastructure/docs/service-management/reference/rest/v1/services.rollouts/list
+		response, err = c.servicemanagementv1Service.Services.Rollouts.List(serviceName).Filter("status=SUCCESS").PageSize(1).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		return nil
+	}, c.getRetryOptions()...))
+	if err != nil {
+		return
+	}
+
+	if len(response.Rollouts) == 0 || response.Rollouts[0].TrafficPercentStrategy == nil {
+		return "", nil
+	}
+	for id, percentage := range response.Rollouts[0].TrafficPercentStrategy.Percentages {
+		if percentage == 100 {
+			return id, nil
+		}
+	}
+
+	return "", nil
+}
+
+// getServiceConfigSourceHash returns the hash of the files a service config was generated from
+func (c *client) getServiceConfigSourceHash(ctx context.Context, serviceName, configID string) (hash string, err error) {
+	var serviceConfig *servicemanagementv1.Service
+	err = c.substituteErrorsWithPredefinedErrors(foundation.Retry(func() error {
+		// https://cloud.google.com/service-infrastructure/docs/service-management/reference/rest/v1/services.configs/get
+		serviceConfig, err = c.servicemanagementv1Service.Services.Configs.Get(serviceName, configID).View("FULL").Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+		return nil
+	}, c.getRetryOptions()...))
+	if err != nil {
+		return
+	}
+
+	if serviceConfig.SourceInfo == nil {
+		return "", nil
+	}
+
+	configFiles := []*servicemanagementv1.ConfigFile{}
+	for _, sourceFile := range serviceConfig.SourceInfo.SourceFiles {
+		var configFile servicemanagementv1.ConfigFile
+		err = json.Unmarshal(sourceFile, &configFile)
+		if err != nil {
+			return
+		}
+		configFiles = append(configFiles, &configFile)
+	}
+
+	return getConfigFilesHash(configFiles)
+}
+
+// getConfigFilesHash returns a hash of the config files that ignores their order, line endings and trailing whitespace
+func getConfigFilesHash(configFiles []*servicemanagementv1.ConfigFile) (hash string, err error) {
+	normalizedFiles := []string{}
+	for _, configFile := range configFiles {
+		contents, err := base64.StdEncoding.DecodeString(configFile.FileContents)
+		if err != nil {
+			return "", err
+		}
+		normalizedContents := string(contents)
+		// a descriptor set is binary, so only text files get their line endings and whitespace normalized
+		if configFile.FileType != "FILE_DESCRIPTOR_SET_PROTO" {
+			lines := strings.Split(strings.ReplaceAll(normalizedContents, "\r\n", "\n"), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimRight(line, " \t")
+			}
+			normalizedContents = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+		}
+		normalizedFiles = append(normalizedFiles, fmt.Sprintf("%v\x00%v\x00%v", filepath.Base(configFile.FilePath), configFile.FileType, normalizedContents))
+	}
+	sort.Strings(normalizedFiles)
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(normalizedFiles, "\x00")))), nil
+}
+
+func (c *client) EnsureWorkloadIdentityBinding(ctx context.Context, gcpServiceAccount, workloadPool, namespace, kubernetesServiceAccount string) (err error) {
+	if gcpServiceAccount == "" {
+		return fmt.Errorf("EnsureWorkloadIdentityBinding argument gcpServiceAccount is empty")
+	}
+	if workloadPool == "" {
+		return fmt.Errorf("EnsureWorkloadIdentityBinding argument workloadPool is empty")
+	}
+	if namespace == "" {
+		return fmt.Errorf("EnsureWorkloadIdentityBinding argument namespace is empty")
+	}
+	if kubernetesServiceAccount == "" {
+		return fmt.Errorf("EnsureWorkloadIdentityBinding argument kubernetesServiceAccount is empty")
+	}
+
+	resource := "projects/-/serviceAccounts/" + gcpServiceAccount
+	role := "roles/iam.workloadIdentityUser"
+	member := fmt.Sprintf("serviceAccount:%v[%v/%v]", workloadPool, namespace, kubernetesServiceAccount)
+
+	log.Info().Msgf("Checking if %v has role %v on service account %v...", member, role, gcpServiceAccount)
+
+	// get and set the policy in one retry, so a conflicting update (409) gets retried with the fresh etag
+	err = c.substituteErrorsWithPredefinedErrors(foundation.Retry(func() error {
+		// https://cloud.google.com/iam/docs/reference/rest/v1/projects.serviceAccounts/getIamPolicy
+		policy, err := c.iamv1Service.Projects.ServiceAccounts.GetIamPolicy(resource).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+
+		var binding *iamv1.Binding
+		for _, b := range policy.Bindings {
+			if b.Role == role && b.Condition == nil {
+				binding = b
+				break
+			}
+		}
+		if binding == nil {
+			binding = &iamv1.Binding{Role: role}
+			policy.Bindings = append(policy.Bindings, binding)
+		}
+		if foundation.StringArrayContains(binding.Members, member) {
+			log.Info().Msgf("Service account %v already has binding for %v, no need to update its policy", gcpServiceAccount, member)
+			return nil
+		}
+		binding.Members = append(binding.Members, member)
+
+		log.Info().Msgf("Adding binding for %v with role %v to service account %v...", member, role, gcpServiceAccount)
+
+		// https://cloud.google.com/iam/docs/reference/rest/v1/projects.serviceAccounts/setIamPolicy
+		_, err = c.iamv1Service.Projects.ServiceAccounts.SetIamPolicy(resource, &iamv1.SetIamPolicyRequest{Policy: policy}).Context(ctx).Do()
+		return err
+	}, c.getRetryOptions(http.StatusConflict)...))
+	if err != nil {
+		return fmt.Errorf("Can't ensure workload identity binding for %v on service account %v: %w", member, gcpServiceAccount, err)
+	}
+
+	return
+}
+
 func (c *client) substituteErrorsWithPredefinedErrors(err error) error {
 	if err == nil {
 		return nil
@@ -493,3 +738,136 @@ func (c *client) sleepForWait(startTime time.Time) {
 	sleepTime := foundation.ApplyJitter(sleepTimeSeconds)
 	time.Sleep(time.Duration(sleepTime) * time.Second)
 }
+
+// getEndpointsConfigFiles returns the service name and the config files to submit; an openapi spec for http services or a service config with its descriptor set for grpc services
+func getEndpointsConfigFiles(params api.Params) (serviceName string, configFiles []*servicemanagementv1.ConfigFile, err error) {
+	if params.UsesEspGRPC() {
+		for _, path := range []string{params.EspServiceConfigYamlPath, params.EspDescriptorSetPath} {
+			if !foundation.FileExists(path) {
+				return serviceName, configFiles, fmt.Errorf("File at path %v does not exist. Did you forget to use `clone: true` for your release?", path)
+			}
+		}
+
+		log.Info().Msgf("Checking if grpc service config at path %v exists...", params.EspServiceConfigYamlPath)
+		serviceConfigBytes, err := ioutil.ReadFile(params.EspServiceConfigYamlPath)
+		if err != nil {
+			return serviceName, configFiles, err
+		}
+
+		log.Info().Msg("Unmarshalling grpc service config to get service name...")
+		serviceName, err = params.GetEspGRPCServiceName()
+		if err != nil {
+			return serviceName, configFiles, err
+		}
+
+		log.Info().Msgf("Checking if proto descriptor set at path %v exists...", params.EspDescriptorSetPath)
+		descriptorSetBytes, err := ioutil.ReadFile(params.EspDescriptorSetPath)
+		if err != nil {
+			return serviceName, configFiles, err
+		}
+
+		log.Info().Msgf("Found service name %v in grpc service config", serviceName)
+
+		configFiles = []*servicemanagementv1.ConfigFile{
+			{
+				FileContents: base64.StdEncoding.EncodeToString(serviceConfigBytes),
+				FilePath:     filepath.Base(params.EspServiceConfigYamlPath),
+				FileType:     "SERVICE_CONFIG_YAML",
+			},
+			{
+				FileContents: base64.StdEncoding.EncodeToString(descriptorSetBytes),
+				FilePath:     filepath.Base(params.EspDescriptorSetPath),
+				FileType:     "FILE_DESCRIPTOR_SET_PROTO",
+			},
+		}
+
+		return serviceName, configFiles, nil
+	}
+
+	// get host from openapi file
+	if !foundation.FileExists(params.EspOpenAPIYamlPath) {
+		return serviceName, configFiles, fmt.Errorf("File at path %v does not exist. Did you forget to use `clone: true` for your release?", params.EspOpenAPIYamlPath)
+	}
+
+	log.Info().Msgf("Checking if openapi spec at path %v exists...", params.EspOpenAPIYamlPath)
+	openapiSpecBytes, err := ioutil.ReadFile(params.EspOpenAPIYamlPath)
+	if err != nil {
+		return
+	}
+
+	var openapiSpec struct {
+		Host string `yaml:"host"`
+	}
+
+	log.Info().Msg("Unmarshalling openapi spec to get service name...")
+	err = yaml.Unmarshal(openapiSpecBytes, &openapiSpec)
+	if err != nil {
+		return
+	}
+
+	if openapiSpec.Host == "" {
+		return serviceName, configFiles, fmt.Errorf("The host field in the openapi spec at %v is empty, please set it", params.EspOpenAPIYamlPath)
+	}
+
+	serviceName = openapiSpec.Host
+	log.Info().Msgf("Found service name %v in openapi", serviceName)
+
+	configFiles = []*servicemanagementv1.ConfigFile{
+		{
+			FileContents: base64.StdEncoding.EncodeToString(openapiSpecBytes),
+			FilePath:     filepath.Base(params.EspOpenAPIYamlPath),
+			FileType:     "OPEN_API_YAML",
+		},
+	}
+
+	return
+}
+
+// ResolveSecretManagerReferences replaces gcpsm:// references in secretEnv and secrets.keys with the secret values and records the resolved versions in params.SecretManagerVersions, keyed by the property path of the reference so the same key in different places doesn't collide; the values themselves are never logged
+func (c *client) ResolveSecretManagerReferences(ctx context.Context, params *api.Params) (err error) {
+	resolvedVersions := map[string]string{}
+
+	resolve := func(source string, secretMap map[string]interface{}, base64Encode bool) error {
+		for key, value := range secretMap {
+			name, isReference := api.GetSecretManagerVersionName(value)
+			if !isReference {
+				continue
+			}
+
+			log.Info().Msgf("Resolving secret %v from secret manager version %v...", key, name)
+			resolvedName, payload, err := c.secretManagerClient.AccessSecretVersion(ctx, name)
+			if err != nil {
+				return fmt.Errorf("Failed resolving secret %v from secret manager version %v: %w", key, name, err)
+			}
+
+			// secrets.keys values are expected to be base64 encoded already, secretEnv values get encoded when generating the template data
+			if base64Encode {
+				secretMap[key] = base64.StdEncoding.EncodeToString(payload)
+			} else {
+				secretMap[key] = string(payload)
+			}
+			resolvedVersions[fmt.Sprintf("%v.%v", source, key)] = resolvedName
+			log.Info().Msgf("Resolved secret %v to secret manager version %v", key, resolvedName)
+		}
+		return nil
+	}
+
+	err = resolve("container.secretEnv", params.Container.SecretEnvironmentVariables, false)
+	if err != nil {
+		return
+	}
+	for i, sc := range params.Sidecars {
+		err = resolve(fmt.Sprintf("sidecars[%v].secretEnv", i), sc.SecretEnvironmentVariables, false)
+		if err != nil {
+			return
+		}
+	}
+	err = resolve("secrets.keys", params.Secrets.Keys, true)
+	if err != nil {
+		return
+	}
+
+	params.SecretManagerVersions = resolvedVersions
+
+	return
+}
@@ -0,0 +1,444 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/stretchr/testify/assert"
+	containerv1 "google.golang.org/api/container/v1beta1"
+	servicemanagementv1 "google.golang.org/api/servicemanagement/v1"
+)
+
+func TestGetConfigFilesHash(t *testing.T) {
+
+	t.Run("ReturnsSameHashIfOnlyLineEndingsAndTrailingWhitespaceDiffer", func(t *testing.T) {
+
+		configFiles := []*servicemanagementv1.ConfigFile{
+			{
+				FileContents: base64.StdEncoding.EncodeToString([]byte("swagger: \"2.0\"\nhost: myapp.endpoints.myproject.cloud.goog\n")),
+				FilePath:     "openapi.yaml",
+				FileType:     "OPEN_API_YAML",
+			},
+		}
+		submittedConfigFiles := []*servicemanagementv1.ConfigFile{
+			{
+				FileContents: base64.StdEncoding.EncodeToString([]byte("swagger: \"2.0\"  \r\nhost: myapp.endpoints.myproject.cloud.goog\r\n\r\n")),
+				FilePath:     "/estafette-work/openapi.yaml",
+				FileType:     "OPEN_API_YAML",
+			},
+		}
+
+		// act
+		hash, err := getConfigFilesHash(configFiles)
+		submittedHash, submittedErr := getConfigFilesHash(submittedConfigFiles)
+
+		assert.Nil(t, err)
+		assert.Nil(t, submittedErr)
+		assert.Equal(t, hash, submittedHash)
+	})
+
+	t.Run("ReturnsDifferentHashIfContentsDiffer", func(t *testing.T) {
+
+		configFiles := []*servicemanagementv1.ConfigFile{
+			{
+				FileContents: base64.StdEncoding.EncodeToString([]byte("swagger: \"2.0\"\nhost: myapp.endpoints.myproject.cloud.goog\n")),
+				FilePath:     "openapi.yaml",
+				FileType:     "OPEN_API_YAML",
+			},
+		}
+		changedConfigFiles := []*servicemanagementv1.ConfigFile{
+			{
+				FileContents: base64.StdEncoding.EncodeToString([]byte("swagger: \"2.0\"\nhost: otherapp.endpoints.myproject.cloud.goog\n")),
+				FilePath:     "openapi.yaml",
+				FileType:     "OPEN_API_YAML",
+			},
+		}
+
+		// act
+		hash, _ := getConfigFilesHash(configFiles)
+		changedHash, _ := getConfigFilesHash(changedConfigFiles)
+
+		assert.NotEqual(t, hash, changedHash)
+	})
+
+	t.Run("ReturnsErrorIfContentsAreNotBase64Encoded", func(t *testing.T) {
+
+		configFiles := []*servicemanagementv1.ConfigFile{
+			{
+				FileContents: "not base64!",
+				FilePath:     "openapi.yaml",
+				FileType:     "OPEN_API_YAML",
+			},
+		}
+
+		// act
+		_, err := getConfigFilesHash(configFiles)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetEndpointsConfigFiles(t *testing.T) {
+
+	t.Run("ReturnsServiceNameFromGRPCServiceConfigAndSubmitsItWithDescriptorSet", func(t *testing.T) {
+
+		dir := t.TempDir()
+		serviceConfigPath := filepath.Join(dir, "api_config.yaml")
+		descriptorSetPath := filepath.Join(dir, "api_descriptor.pb")
+		ioutil.WriteFile(serviceConfigPath, []byte("type: google.api.Service\nconfig_version: 3\nname: myapp.endpoints.myproject.cloud.goog\n"), 0644)
+		ioutil.WriteFile(descriptorSetPath, []byte{0x0a, 0x0d, 0x00, 0xff}, 0644)
+
+		params := api.Params{
+			EspServiceConfigYamlPath: serviceConfigPath,
+			EspDescriptorSetPath:     descriptorSetPath,
+		}
+
+		// act
+		serviceName, configFiles, err := getEndpointsConfigFiles(params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "myapp.endpoints.myproject.cloud.goog", serviceName)
+		if assert.Equal(t, 2, len(configFiles)) {
+			assert.Equal(t, "SERVICE_CONFIG_YAML", configFiles[0].FileType)
+			assert.Equal(t, "api_config.yaml", configFiles[0].FilePath)
+			assert.Equal(t, "FILE_DESCRIPTOR_SET_PROTO", configFiles[1].FileType)
+			assert.Equal(t, "api_descriptor.pb", configFiles[1].FilePath)
+			assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0x0a, 0x0d, 0x00, 0xff}), configFiles[1].FileContents)
+		}
+	})
+
+	t.Run("ReturnsErrorIfGRPCServiceConfigHasNoName", func(t *testing.T) {
+
+		dir := t.TempDir()
+		serviceConfigPath := filepath.Join(dir, "api_config.yaml")
+		descriptorSetPath := filepath.Join(dir, "api_descriptor.pb")
+		ioutil.WriteFile(serviceConfigPath, []byte("type: google.api.Service\nconfig_version: 3\n"), 0644)
+		ioutil.WriteFile(descriptorSetPath, []byte{0x0a}, 0644)
+
+		params := api.Params{
+			EspServiceConfigYamlPath: serviceConfigPath,
+			EspDescriptorSetPath:     descriptorSetPath,
+		}
+
+		// act
+		_, _, err := getEndpointsConfigFiles(params)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsServiceNameFromOpenAPIHost", func(t *testing.T) {
+
+		dir := t.TempDir()
+		openapiPath := filepath.Join(dir, "openapi.yaml")
+		ioutil.WriteFile(openapiPath, []byte("swagger: \"2.0\"\nhost: myapp.endpoints.myproject.cloud.goog\n"), 0644)
+
+		params := api.Params{
+			EspOpenAPIYamlPath: openapiPath,
+		}
+
+		// act
+		serviceName, configFiles, err := getEndpointsConfigFiles(params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "myapp.endpoints.myproject.cloud.goog", serviceName)
+		if assert.Equal(t, 1, len(configFiles)) {
+			assert.Equal(t, "OPEN_API_YAML", configFiles[0].FileType)
+		}
+	})
+}
+
+func TestGetKubeConfigCluster(t *testing.T) {
+
+	cluster := &containerv1.Cluster{
+		Name:     "production",
+		Endpoint: "35.1.2.3",
+		MasterAuth: &containerv1.MasterAuth{
+			ClusterCaCertificate: base64.StdEncoding.EncodeToString([]byte("ca")),
+		},
+		PrivateClusterConfig: &containerv1.PrivateClusterConfig{
+			PrivateEndpoint: "10.0.0.2",
+		},
+	}
+
+	t.Run("ReturnsPublicEndpointByDefault", func(t *testing.T) {
+
+		credential := &api.GKECredentials{}
+
+		// act
+		clusterConfig, err := getKubeConfigCluster(credential, cluster)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "https://35.1.2.3", clusterConfig.Server)
+		assert.Equal(t, []byte("ca"), clusterConfig.CertificateAuthorityData)
+	})
+
+	t.Run("ReturnsPrivateEndpointIfEndpointIsPrivate", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Endpoint: api.GKEClusterEndpointPrivate,
+			},
+		}
+
+		// act
+		clusterConfig, err := getKubeConfigCluster(credential, cluster)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "https://10.0.0.2", clusterConfig.Server)
+		assert.Equal(t, []byte("ca"), clusterConfig.CertificateAuthorityData)
+	})
+
+	t.Run("ReturnsErrorIfEndpointIsPrivateAndClusterHasNoPrivateEndpoint", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Endpoint: api.GKEClusterEndpointPrivate,
+			},
+		}
+		publicCluster := &containerv1.Cluster{
+			Endpoint:   "35.1.2.3",
+			MasterAuth: cluster.MasterAuth,
+		}
+
+		// act
+		_, err := getKubeConfigCluster(credential, publicCluster)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsDNSEndpointWithoutCertificateAuthorityIfEndpointIsDNS", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Endpoint:    api.GKEClusterEndpointDNS,
+				DNSEndpoint: "gke-0123456789abcdef.europe-west1.gke.goog",
+			},
+		}
+
+		// act
+		clusterConfig, err := getKubeConfigCluster(credential, cluster)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "https://gke-0123456789abcdef.europe-west1.gke.goog", clusterConfig.Server)
+		assert.Nil(t, clusterConfig.CertificateAuthorityData)
+	})
+
+	t.Run("ReturnsErrorIfEndpointIsDNSWithoutDNSEndpoint", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Endpoint: api.GKEClusterEndpointDNS,
+			},
+		}
+
+		// act
+		_, err := getKubeConfigCluster(credential, cluster)
+
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetKubeConfigAuthInfo(t *testing.T) {
+
+	t.Run("ReturnsGKEGcloudAuthPluginIfAuthIsExec", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Auth: api.GKEClusterAuthExec,
+			},
+		}
+
+		// act
+		authInfo, err := getKubeConfigAuthInfo(context.Background(), credential)
+
+		assert.Nil(t, err)
+		if assert.NotNil(t, authInfo.Exec) {
+			assert.Equal(t, "gke-gcloud-auth-plugin", authInfo.Exec.Command)
+		}
+		assert.Nil(t, authInfo.AuthProvider)
+	})
+
+	t.Run("ReturnsGCPAuthProviderIfAuthIsGCP", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Auth: api.GKEClusterAuthGCP,
+			},
+		}
+
+		// act
+		authInfo, err := getKubeConfigAuthInfo(context.Background(), credential)
+
+		assert.Nil(t, err)
+		if assert.NotNil(t, authInfo.AuthProvider) {
+			assert.Equal(t, "gcp", authInfo.AuthProvider.Name)
+		}
+	})
+
+	t.Run("ReturnsGKEGcloudAuthPluginIfAuthIsEmpty", func(t *testing.T) {
+
+		credential := &api.GKECredentials{}
+
+		// act
+		authInfo, err := getKubeConfigAuthInfo(context.Background(), credential)
+
+		assert.Nil(t, err)
+		if assert.NotNil(t, authInfo.Exec) {
+			assert.Equal(t, "gke-gcloud-auth-plugin", authInfo.Exec.Command)
+		}
+		assert.Equal(t, "", authInfo.Token)
+	})
+
+	t.Run("ReturnsErrorIfTokenCannotBeMintedBecauseKeyfileIsMissing", func(t *testing.T) {
+
+		t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", filepath.Join(t.TempDir(), "key-file.json"))
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Auth: api.GKEClusterAuthToken,
+			},
+		}
+
+		// act
+		_, err := getKubeConfigAuthInfo(context.Background(), credential)
+
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ReturnsErrorIfAuthIsUnsupported", func(t *testing.T) {
+
+		credential := &api.GKECredentials{
+			AdditionalProperties: api.GKECredentialAdditionalProperties{
+				Auth: "oidc",
+			},
+		}
+
+		// act
+		_, err := getKubeConfigAuthInfo(context.Background(), credential)
+
+		assert.NotNil(t, err)
+	})
+}
+
+type fakeSecretManagerClient struct {
+	payloads      map[string]string
+	resolvedNames map[string]string
+}
+
+func (c *fakeSecretManagerClient) AccessSecretVersion(ctx context.Context, name string) (resolvedName string, payload []byte, err error) {
+	value, ok := c.payloads[name]
+	if !ok {
+		return "", nil, fmt.Errorf("Secret version %v not found", name)
+	}
+	return c.resolvedNames[name], []byte(value), nil
+}
+
+func TestResolveSecretManagerReferences(t *testing.T) {
+
+	secretManagerClient := &fakeSecretManagerClient{
+		payloads: map[string]string{
+			"projects/p/secrets/db-password/versions/latest": "s3cr3t",
+			"projects/p/secrets/api-key/versions/2":          "abc",
+			"projects/p/secrets/config/versions/1":           "key: value",
+		},
+		resolvedNames: map[string]string{
+			"projects/p/secrets/db-password/versions/latest": "projects/123/secrets/db-password/versions/7",
+			"projects/p/secrets/api-key/versions/2":          "projects/123/secrets/api-key/versions/2",
+			"projects/p/secrets/config/versions/1":           "projects/123/secrets/config/versions/1",
+		},
+	}
+
+	t.Run("ReplacesReferencesWithSecretValuesAndRecordsResolvedVersions", func(t *testing.T) {
+
+		c := &client{secretManagerClient: secretManagerClient}
+		params := api.Params{
+			Container: api.ContainerParams{
+				SecretEnvironmentVariables: map[string]interface{}{
+					"DB_PASSWORD": "gcpsm://projects/p/secrets/db-password/versions/latest",
+					"LITERAL":     "plain",
+				},
+			},
+			Sidecars: []*api.SidecarParams{
+				{
+					SecretEnvironmentVariables: map[string]interface{}{
+						"API_KEY": "gcpsm://projects/p/secrets/api-key/versions/2",
+					},
+				},
+			},
+			Secrets: api.SecretsParams{
+				Keys: map[string]interface{}{
+					"config.yaml": "gcpsm://projects/p/secrets/config/versions/1",
+				},
+			},
+		}
+
+		// act
+		err := c.ResolveSecretManagerReferences(context.Background(), &params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "s3cr3t", params.Container.SecretEnvironmentVariables["DB_PASSWORD"])
+		assert.Equal(t, "plain", params.Container.SecretEnvironmentVariables["LITERAL"])
+		assert.Equal(t, "abc", params.Sidecars[0].SecretEnvironmentVariables["API_KEY"])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("key: value")), params.Secrets.Keys["config.yaml"])
+		assert.Equal(t, map[string]string{
+			"container.secretEnv.DB_PASSWORD": "projects/123/secrets/db-password/versions/7",
+			"sidecars[0].secretEnv.API_KEY":   "projects/123/secrets/api-key/versions/2",
+			"secrets.keys.config.yaml":        "projects/123/secrets/config/versions/1",
+		}, params.SecretManagerVersions)
+	})
+
+	t.Run("RecordsResolvedVersionsOfTheSameKeyInDifferentPlacesSeparately", func(t *testing.T) {
+
+		c := &client{secretManagerClient: secretManagerClient}
+		params := api.Params{
+			Container: api.ContainerParams{
+				SecretEnvironmentVariables: map[string]interface{}{
+					"SECRET": "gcpsm://projects/p/secrets/db-password/versions/latest",
+				},
+			},
+			Sidecars: []*api.SidecarParams{
+				{
+					SecretEnvironmentVariables: map[string]interface{}{
+						"SECRET": "gcpsm://projects/p/secrets/api-key/versions/2",
+					},
+				},
+			},
+			Secrets: api.SecretsParams{
+				Keys: map[string]interface{}{
+					"SECRET": "gcpsm://projects/p/secrets/config/versions/1",
+				},
+			},
+		}
+
+		// act
+		err := c.ResolveSecretManagerReferences(context.Background(), &params)
+
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{
+			"container.secretEnv.SECRET":   "projects/123/secrets/db-password/versions/7",
+			"sidecars[0].secretEnv.SECRET": "projects/123/secrets/api-key/versions/2",
+			"secrets.keys.SECRET":          "projects/123/secrets/config/versions/1",
+		}, params.SecretManagerVersions)
+	})
+
+	t.Run("ReturnsErrorIfSecretVersionCannotBeAccessed", func(t *testing.T) {
+
+		c := &client{secretManagerClient: secretManagerClient}
+		params := api.Params{
+			Container: api.ContainerParams{
+				SecretEnvironmentVariables: map[string]interface{}{
+					"DB_PASSWORD": "gcpsm://projects/p/secrets/unknown/versions/1",
+				},
+			},
+		}
+
+		// act
+		err := c.ResolveSecretManagerReferences(context.Background(), &params)
+
+		assert.NotNil(t, err)
+	})
+}
@@ -64,3 +64,46 @@ func (mr *MockClientMockRecorder) GetGKECluster(ctx, projectID, location, cluste
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGKECluster", reflect.TypeOf((*MockClient)(nil).GetGKECluster), ctx, projectID, location, clusterID)
 }
+
+// GetActiveGoogleCloudEndpointsConfigID mocks base method
+func (m *MockClient) GetActiveGoogleCloudEndpointsConfigID(ctx context.Context, params api.Params) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveGoogleCloudEndpointsConfigID", ctx, params)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveGoogleCloudEndpointsConfigID indicates an expected call of GetActiveGoogleCloudEndpointsConfigID
+func (mr *MockClientMockRecorder) GetActiveGoogleCloudEndpointsConfigID(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveGoogleCloudEndpointsConfigID", reflect.TypeOf((*MockClient)(nil).GetActiveGoogleCloudEndpointsConfigID), ctx, params)
+}
+
+// EnsureWorkloadIdentityBinding mocks base method
+func (m *MockClient) EnsureWorkloadIdentityBinding(ctx context.Context, gcpServiceAccount, workloadPool, namespace, kubernetesServiceAccount string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureWorkloadIdentityBinding", ctx, gcpServiceAccount, workloadPool, namespace, kubernetesServiceAccount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnsureWorkloadIdentityBinding indicates an expected call of EnsureWorkloadIdentityBinding
+func (mr *MockClientMockRecorder) EnsureWorkloadIdentityBinding(ctx, gcpServiceAccount, workloadPool, namespace, kubernetesServiceAccount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureWorkloadIdentityBinding", reflect.TypeOf((*MockClient)(nil).EnsureWorkloadIdentityBinding), ctx, gcpServiceAccount, workloadPool, namespace, kubernetesServiceAccount)
+}
+
+// ResolveSecretManagerReferences mocks base method
+func (m *MockClient) ResolveSecretManagerReferences(ctx context.Context, params *api.Params) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveSecretManagerReferences", ctx, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveSecretManagerReferences indicates an expected call of ResolveSecretManagerReferences
+func (mr *MockClientMockRecorder) ResolveSecretManagerReferences(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveSecretManagerReferences", reflect.TypeOf((*MockClient)(nil).ResolveSecretManagerReferences), ctx, params)
+}
@@ -0,0 +1,40 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+
+	secretmanagerv1 "google.golang.org/api/secretmanager/v1"
+)
+
+// SecretManagerClient accesses secret versions in google secret manager
+type SecretManagerClient interface {
+	// AccessSecretVersion returns the payload of a secret version and its resolved name, which has a version number instead of an alias like latest
+	AccessSecretVersion(ctx context.Context, name string) (resolvedName string, payload []byte, err error)
+}
+
+// NewSecretManagerClient returns a SecretManagerClient using the secret manager api
+func NewSecretManagerClient(secretmanagerv1Service *secretmanagerv1.Service) SecretManagerClient {
+	return &secretManagerClient{
+		secretmanagerv1Service: secretmanagerv1Service,
+	}
+}
+
+type secretManagerClient struct {
+	secretmanagerv1Service *secretmanagerv1.Service
+}
+
+func (c *secretManagerClient) AccessSecretVersion(ctx context.Context, name string) (resolvedName string, payload []byte, err error) {
+	// https://cloud.google.com/secret-manager/docs/reference/rest/v1/projects.secrets.versions/access
+	response, err := c.secretmanagerv1Service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if err != nil {
+		return
+	}
+
+	payload, err = base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return
+	}
+
+	return response.Name, payload, nil
+}
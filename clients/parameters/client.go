@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/estafette/estafette-extension-gke/clients/registry"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
 )
@@ -16,12 +18,15 @@ type Client interface {
 	Init(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, err error)
 }
 
-// NewClient returns a new gcp.Client
-func NewClient(ctx context.Context) (Client, error) {
-	return &client{}, nil
+// NewClient returns a new parameters.Client
+func NewClient(ctx context.Context, registryClient registry.Client) (Client, error) {
+	return &client{
+		registryClient: registryClient,
+	}, nil
 }
 
 type client struct {
+	registryClient registry.Client
 }
 
 func (c *client) Init(ctx context.Context, paramsYAML string, credential *api.GKECredentials, gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, releaseAction, releaseID string) (parameters api.Params, err error) {
@@ -54,12 +59,26 @@ func (c *client) Init(ctx context.Context, paramsYAML string, credential *api.GK
 		parameters = *credential.AdditionalProperties.Defaults
 	}
 
+	// sidecar types from the manifest would replace the whole list of the credential defaults when unmarshalling
+	defaultSidecarTypes := parameters.SidecarTypes
+	parameters.SidecarTypes = nil
+
 	log.Info().Msg("Unmarshalling parameters / custom properties...")
 	err = yaml.Unmarshal([]byte(paramsYAML), &parameters)
 	if err != nil {
 		return parameters, fmt.Errorf("Failed unmarshalling parameters: %w", err)
 	}
 
+	parameters.MergeSidecarTypes(defaultSidecarTypes)
+
+	if parameters.SidecarTypesDirectory != "" {
+		log.Info().Msgf("Loading sidecar types from %v...", parameters.SidecarTypesDirectory)
+		err = parameters.LoadSidecarTypes()
+		if err != nil {
+			return parameters, err
+		}
+	}
+
 	log.Info().Msg("Setting defaults for parameters that are not set in the manifest...")
 	parameters.SetDefaults(gitSource, gitOwner, gitName, appLabel, buildVersion, releaseName, api.ActionType(releaseAction), releaseID, estafetteLabels)
 
@@ -73,8 +92,23 @@ func (c *client) Init(ctx context.Context, paramsYAML string, credential *api.GK
 		log.Printf("Warning: %s", warning)
 	}
 
-	// replacing sidecar image tags with digest
-	parameters.ReplaceSidecarTagsWithDigest()
+	// refreshing trusted ip ranges from their providers, only if the service gets limited to them
+	if parameters.LimitsTrustedIPRanges() {
+		parameters.ResolveTrustedIPRanges()
+	}
+
+	// replacing sidecar image tags with digest, cached if the directory is persisted between releases
+	registryClient := c.registryClient
+	if parameters.DigestCache.Directory != "" {
+		ttl, _ := time.ParseDuration(parameters.DigestCache.TTL)
+		registryClient = registry.NewCachingClient(registryClient, parameters.DigestCache.Directory, ttl)
+	}
+	digestErrors := parameters.ReplaceSidecarTagsWithDigest(func(image string) (string, error) {
+		return registryClient.GetDigest(ctx, image, nil)
+	})
+	for _, err := range digestErrors {
+		log.Warn().Err(err).Msg("Deploying sidecar image with tag instead of digest")
+	}
 
 	return
 }
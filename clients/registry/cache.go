@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NewCachingClient returns a registry.Client that stores resolved digests on disk, so tags only get looked up again once the cache expires
+func NewCachingClient(client Client, directory string, ttl time.Duration) Client {
+	return &cachingClient{
+		client:    client,
+		directory: directory,
+		ttl:       ttl,
+	}
+}
+
+type cachingClient struct {
+	client    Client
+	directory string
+	ttl       time.Duration
+}
+
+func (c *cachingClient) GetDigest(ctx context.Context, image string, credentials *Credentials) (digest string, err error) {
+	cacheFile := filepath.Join(c.directory, fmt.Sprintf("%x.txt", sha256.Sum256([]byte(image))))
+
+	// use the cached digest while it's fresh
+	if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < c.ttl {
+		content, err := ioutil.ReadFile(cacheFile)
+		if err == nil && strings.HasPrefix(string(content), "sha256:") {
+			log.Debug().Msgf("Using cached digest %v for image %v", string(content), image)
+			return string(content), nil
+		}
+	}
+
+	digest, err = c.client.GetDigest(ctx, image, credentials)
+	if err != nil {
+		return
+	}
+
+	cacheErr := os.MkdirAll(c.directory, 0755)
+	if cacheErr == nil {
+		cacheErr = ioutil.WriteFile(cacheFile, []byte(digest), 0644)
+	}
+	if cacheErr != nil {
+		log.Warn().Err(cacheErr).Msgf("Failed caching digest for image %v", image)
+	}
+
+	return digest, nil
+}
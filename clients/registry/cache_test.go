@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingClientGetDigest(t *testing.T) {
+
+	t.Run("ResolvesDigestOnceWhileCacheIsFresh", func(t *testing.T) {
+
+		var manifestRequests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&manifestRequests, 1)
+			w.Header().Set("Docker-Content-Digest", "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf")
+		}))
+		defer server.Close()
+
+		registryClient, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+		client := NewCachingClient(registryClient, t.TempDir(), time.Hour)
+		image := fmt.Sprintf("%v/cloudsql-docker/gce-proxy:1.21.0", strings.TrimPrefix(server.URL, "http://"))
+
+		// act
+		digest, err := client.GetDigest(context.Background(), image, nil)
+		cachedDigest, cachedErr := client.GetDigest(context.Background(), image, nil)
+
+		assert.Nil(t, err)
+		assert.Nil(t, cachedErr)
+		assert.Equal(t, "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", digest)
+		assert.Equal(t, digest, cachedDigest)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&manifestRequests))
+	})
+
+	t.Run("ResolvesDigestAgainOnceCacheExpires", func(t *testing.T) {
+
+		var manifestRequests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&manifestRequests, 1)
+			w.Header().Set("Docker-Content-Digest", "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf")
+		}))
+		defer server.Close()
+
+		directory := t.TempDir()
+		registryClient, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+		client := NewCachingClient(registryClient, directory, time.Hour)
+		image := fmt.Sprintf("%v/cloudsql-docker/gce-proxy:1.21.0", strings.TrimPrefix(server.URL, "http://"))
+
+		_, err = client.GetDigest(context.Background(), image, nil)
+		assert.Nil(t, err)
+
+		// age the cache file beyond the ttl
+		files, _ := filepath.Glob(filepath.Join(directory, "*.txt"))
+		if assert.Equal(t, 1, len(files)) {
+			expired := time.Now().Add(-2 * time.Hour)
+			os.Chtimes(files[0], expired, expired)
+		}
+
+		// act
+		_, err = client.GetDigest(context.Background(), image, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&manifestRequests))
+	})
+
+	t.Run("DoesNotCacheFailures", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		directory := t.TempDir()
+		registryClient, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+		client := NewCachingClient(registryClient, directory, time.Hour)
+
+		// act
+		_, err = client.GetDigest(context.Background(), fmt.Sprintf("%v/cloudsql-docker/gce-proxy:0.0.0", strings.TrimPrefix(server.URL, "http://")), nil)
+
+		assert.NotNil(t, err)
+		files, _ := filepath.Glob(filepath.Join(directory, "*.txt"))
+		assert.Equal(t, 0, len(files))
+	})
+}
@@ -0,0 +1,226 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sethgrid/pester"
+	"golang.org/x/oauth2"
+)
+
+// ErrManifestNotFound is returned when the registry doesn't know the tag or digest of an image
+var ErrManifestNotFound = errors.New("Image manifest not found")
+
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+var challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Credentials are used for basic auth against a registry or its token endpoint
+type Credentials struct {
+	Username string
+	Password string
+}
+
+//go:generate mockgen -package=registry -destination ./mock.go -source=client.go
+type Client interface {
+	GetDigest(ctx context.Context, image string, credentials *Credentials) (digest string, err error)
+}
+
+// NewClient returns a new registry.Client; the token source is used for google hosted registries when no credentials are passed
+func NewClient(ctx context.Context, googleTokenSource oauth2.TokenSource) (Client, error) {
+	return &client{
+		googleTokenSource: googleTokenSource,
+	}, nil
+}
+
+type client struct {
+	googleTokenSource oauth2.TokenSource
+}
+
+// GetDigest returns the digest of the manifest an image tag points to, using the token challenge of any v2 registry
+func (c *client) GetDigest(ctx context.Context, image string, credentials *Credentials) (digest string, err error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return
+	}
+
+	if credentials == nil && ref.IsGoogleRegistry() && c.googleTokenSource != nil {
+		// public images can still be resolved anonymously without a token
+		token, tokenErr := c.googleTokenSource.Token()
+		if tokenErr != nil {
+			log.Warn().Err(tokenErr).Msgf("Failed retrieving gcp access token for registry %v, continuing anonymously", ref.Registry)
+		} else {
+			credentials = &Credentials{Username: "oauth2accesstoken", Password: token.AccessToken}
+		}
+	}
+
+	manifestURL := fmt.Sprintf("%v/v2/%v/manifests/%v", getBaseURL(ref.Registry), ref.Repository, ref.Reference())
+
+	response, err := c.requestManifest(ctx, "HEAD", manifestURL, "")
+	if err != nil {
+		return
+	}
+
+	authorization := ""
+	if response.StatusCode == http.StatusUnauthorized {
+		response.Body.Close()
+		authorization, err = c.getAuthorization(ctx, response.Header.Get("WWW-Authenticate"), ref, credentials)
+		if err != nil {
+			return
+		}
+		response, err = c.requestManifest(ctx, "HEAD", manifestURL, authorization)
+		if err != nil {
+			return
+		}
+	}
+
+	// not all registries return the digest header on a head request, so fall back to hashing the manifest
+	if response.StatusCode == http.StatusOK && response.Header.Get("Docker-Content-Digest") == "" {
+		response.Body.Close()
+		response, err = c.requestManifest(ctx, "GET", manifestURL, authorization)
+		if err != nil {
+			return
+		}
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return digest, fmt.Errorf("%w: %v", ErrManifestNotFound, ref)
+	default:
+		return digest, fmt.Errorf("Registry %v responded with status %v for image %v", ref.Registry, response.StatusCode, ref)
+	}
+
+	digest = response.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		body, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return digest, err
+		}
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+
+	log.Debug().Msgf("Resolved image %v to digest %v", ref, digest)
+
+	return digest, nil
+}
+
+func (c *client) requestManifest(ctx context.Context, method, manifestURL, authorization string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	return newHTTPClient().Do(request)
+}
+
+// getAuthorization answers a basic or bearer challenge from the WWW-Authenticate header
+func (c *client) getAuthorization(ctx context.Context, challenge string, ref ImageReference, credentials *Credentials) (string, error) {
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+
+	params := map[string]string{}
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+
+	switch scheme {
+	case "basic":
+		if credentials == nil {
+			return "", fmt.Errorf("Registry %v requires credentials for image %v", ref.Registry, ref)
+		}
+		request, _ := http.NewRequest("GET", "/", nil)
+		request.SetBasicAuth(credentials.Username, credentials.Password)
+		return request.Header.Get("Authorization"), nil
+
+	case "bearer":
+		if params["realm"] == "" {
+			return "", fmt.Errorf("Registry %v returned a bearer challenge without realm", ref.Registry)
+		}
+		if params["scope"] == "" {
+			params["scope"] = fmt.Sprintf("repository:%v:pull", ref.Repository)
+		}
+
+		query := url.Values{}
+		query.Set("scope", params["scope"])
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+
+		request, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%v?%v", params["realm"], query.Encode()), nil)
+		if err != nil {
+			return "", err
+		}
+		if credentials != nil {
+			request.SetBasicAuth(credentials.Username, credentials.Password)
+		}
+
+		response, err := newHTTPClient().Do(request)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("Token endpoint %v responded with status %v for image %v", params["realm"], response.StatusCode, ref)
+		}
+
+		var tokenResponse struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		err = json.NewDecoder(response.Body).Decode(&tokenResponse)
+		if err != nil {
+			return "", err
+		}
+
+		token := tokenResponse.Token
+		if token == "" {
+			token = tokenResponse.AccessToken
+		}
+		if token == "" {
+			return "", fmt.Errorf("Token endpoint %v returned no token for image %v", params["realm"], ref)
+		}
+
+		return fmt.Sprintf("Bearer %v", token), nil
+	}
+
+	return "", fmt.Errorf("Registry %v returned unsupported challenge %v", ref.Registry, challenge)
+}
+
+// getBaseURL uses plain http for registries on localhost, like docker does
+func getBaseURL(registry string) string {
+	host := strings.Split(registry, ":")[0]
+	if host == "localhost" || host == "127.0.0.1" {
+		return fmt.Sprintf("http://%v", registry)
+	}
+	return fmt.Sprintf("https://%v", registry)
+}
+
+func newHTTPClient() *pester.Client {
+	client := pester.New()
+	client.MaxRetries = 3
+	client.Backoff = pester.ExponentialJitterBackoff
+	client.KeepLog = true
+	client.Timeout = time.Second * 10
+	return client
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRegistry returns a v2 registry that hands out bearer tokens for the given credentials and knows a single tag
+func newTestRegistry(t *testing.T, username, password string, sendDigestHeader bool) *httptest.Server {
+	manifest := `{"schemaVersion":2}`
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			user, pass, _ := r.BasicAuth()
+			if username != "" && (user != username || pass != password) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "repository:team/app:pull", r.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"test-token"}`)
+
+		case strings.HasPrefix(r.URL.Path, "/v2/team/app/manifests/"):
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%v/token",service="test-registry",scope="repository:team/app:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if strings.TrimPrefix(r.URL.Path, "/v2/team/app/manifests/") != "1.0.0" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if sendDigestHeader {
+				w.Header().Set("Docker-Content-Digest", "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf")
+			}
+			if r.Method == "GET" {
+				fmt.Fprint(w, manifest)
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func TestGetDigest(t *testing.T) {
+
+	t.Run("ReturnsDigestAfterBearerTokenChallenge", func(t *testing.T) {
+
+		server := newTestRegistry(t, "", "", true)
+		defer server.Close()
+
+		client, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+
+		// act
+		digest, err := client.GetDigest(context.Background(), fmt.Sprintf("%v/team/app:1.0.0", strings.TrimPrefix(server.URL, "http://")), nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", digest)
+	})
+
+	t.Run("PassesCredentialsToTokenEndpoint", func(t *testing.T) {
+
+		server := newTestRegistry(t, "user", "secret", true)
+		defer server.Close()
+
+		client, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+
+		// act
+		digest, err := client.GetDigest(context.Background(), fmt.Sprintf("%v/team/app:1.0.0", strings.TrimPrefix(server.URL, "http://")), &Credentials{Username: "user", Password: "secret"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", digest)
+	})
+
+	t.Run("ReturnsErrorIfCredentialsAreRejected", func(t *testing.T) {
+
+		server := newTestRegistry(t, "user", "secret", true)
+		defer server.Close()
+
+		client, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.GetDigest(context.Background(), fmt.Sprintf("%v/team/app:1.0.0", strings.TrimPrefix(server.URL, "http://")), &Credentials{Username: "user", Password: "wrong"})
+
+		assert.NotNil(t, err)
+		assert.False(t, errors.Is(err, ErrManifestNotFound))
+	})
+
+	t.Run("HashesManifestIfRegistryReturnsNoDigestHeader", func(t *testing.T) {
+
+		server := newTestRegistry(t, "", "", false)
+		defer server.Close()
+
+		client, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+
+		// act
+		digest, err := client.GetDigest(context.Background(), fmt.Sprintf("%v/team/app:1.0.0", strings.TrimPrefix(server.URL, "http://")), nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "sha256:bafebd36189ad3688b7b3915ea55d461e0bfcfbdde11e54b0a123999fb6be50f", digest)
+	})
+
+	t.Run("ReturnsErrManifestNotFoundForUnknownTag", func(t *testing.T) {
+
+		server := newTestRegistry(t, "", "", true)
+		defer server.Close()
+
+		client, err := NewClient(context.Background(), nil)
+		assert.Nil(t, err)
+
+		// act
+		_, err = client.GetDigest(context.Background(), fmt.Sprintf("%v/team/app:1.0.1", strings.TrimPrefix(server.URL, "http://")), nil)
+
+		assert.True(t, errors.Is(err, ErrManifestNotFound))
+	})
+}
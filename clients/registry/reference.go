@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// ImageReference is an image split into registry host, repository path and tag or digest
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference splits an image like eu.gcr.io:443/project/nested/app:1.0.0 into its parts, defaulting to docker hub and the latest tag
+func ParseImageReference(image string) (ref ImageReference, err error) {
+	if image == "" {
+		return ref, fmt.Errorf("Image reference is empty")
+	}
+
+	remainder := image
+	if i := strings.Index(remainder, "@"); i >= 0 {
+		ref.Digest = remainder[i+1:]
+		remainder = remainder[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("Image reference %v has invalid digest %v", image, ref.Digest)
+		}
+	}
+
+	// the first path component is a registry host if it has a dot or port, or is localhost
+	parts := strings.SplitN(remainder, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		remainder = parts[1]
+	} else {
+		ref.Registry = dockerHubRegistry
+	}
+
+	// a tag can only follow the last path component, so a registry port isn't mistaken for one
+	lastSlash := strings.LastIndex(remainder, "/")
+	if i := strings.LastIndex(remainder, ":"); i > lastSlash {
+		ref.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+	}
+	ref.Repository = remainder
+
+	if ref.Repository == "" {
+		return ref, fmt.Errorf("Image reference %v has no repository", image)
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	return ref, nil
+}
+
+// Reference returns the tag or digest to request the manifest for, preferring the digest
+func (r ImageReference) Reference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// IsGoogleRegistry returns true for container registry and artifact registry hosts, which accept gcp access tokens
+func (r ImageReference) IsGoogleRegistry() bool {
+	return r.Registry == "gcr.io" || strings.HasSuffix(r.Registry, ".gcr.io") || strings.HasSuffix(r.Registry, ".pkg.dev")
+}
+
+func (r ImageReference) String() string {
+	if r.Digest != "" {
+		return fmt.Sprintf("%v/%v@%v", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%v/%v:%v", r.Registry, r.Repository, r.Tag)
+}
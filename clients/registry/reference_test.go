@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageReference(t *testing.T) {
+
+	t.Run("DefaultsToDockerHubLibraryAndLatestTag", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("nginx")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "registry-1.docker.io", ref.Registry)
+		assert.Equal(t, "library/nginx", ref.Repository)
+		assert.Equal(t, "latest", ref.Tag)
+	})
+
+	t.Run("ParsesDockerHubRepositoryWithTag", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("estafette/openresty-sidecar:1.13.6.1-alpine")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "registry-1.docker.io", ref.Registry)
+		assert.Equal(t, "estafette/openresty-sidecar", ref.Repository)
+		assert.Equal(t, "1.13.6.1-alpine", ref.Tag)
+	})
+
+	t.Run("ParsesRegistryWithPortAndNestedPath", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("registry.example.com:5000/team/nested/app:1.0.0")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "registry.example.com:5000", ref.Registry)
+		assert.Equal(t, "team/nested/app", ref.Repository)
+		assert.Equal(t, "1.0.0", ref.Tag)
+	})
+
+	t.Run("ParsesRegistryWithPortWithoutTag", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("localhost:5000/app")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "localhost:5000", ref.Registry)
+		assert.Equal(t, "app", ref.Repository)
+		assert.Equal(t, "latest", ref.Tag)
+	})
+
+	t.Run("ParsesGCRImageAsGoogleRegistry", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("gcr.io/endpoints-release/endpoints-runtime:2.25.0")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "gcr.io", ref.Registry)
+		assert.Equal(t, "endpoints-release/endpoints-runtime", ref.Repository)
+		assert.True(t, ref.IsGoogleRegistry())
+	})
+
+	t.Run("ParsesArtifactRegistryImageAsGoogleRegistry", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("europe-docker.pkg.dev/my-project/images/app:1.0.0")
+
+		assert.Nil(t, err)
+		assert.True(t, ref.IsGoogleRegistry())
+	})
+
+	t.Run("ParsesDigest", func(t *testing.T) {
+
+		// act
+		ref, err := ParseImageReference("eu.gcr.io/cloudsql-docker/gce-proxy@sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "eu.gcr.io", ref.Registry)
+		assert.Equal(t, "cloudsql-docker/gce-proxy", ref.Repository)
+		assert.Equal(t, "", ref.Tag)
+		assert.Equal(t, "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", ref.Reference())
+	})
+
+	t.Run("ReturnsErrorForEmptyImage", func(t *testing.T) {
+
+		// act
+		_, err := ParseImageReference("")
+
+		assert.NotNil(t, err)
+	})
+}
@@ -9,11 +9,14 @@ import (
 	"github.com/estafette/estafette-extension-gke/clients/credentials"
 	"github.com/estafette/estafette-extension-gke/clients/gcp"
 	"github.com/estafette/estafette-extension-gke/clients/parameters"
+	"github.com/estafette/estafette-extension-gke/clients/registry"
 	"github.com/estafette/estafette-extension-gke/services/builder"
 	"github.com/estafette/estafette-extension-gke/services/extension"
 	"github.com/estafette/estafette-extension-gke/services/generator"
 	foundation "github.com/estafette/estafette-foundation"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 )
 
 var (
@@ -28,9 +31,10 @@ var (
 
 var (
 	// flags
-	paramsJSON      = kingpin.Flag("params", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES").Required().String()
-	paramsYAML      = kingpin.Flag("params-yaml", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES_YAML").Required().String()
-	credentialsPath = kingpin.Flag("credentials-path", "Path to GKE credentials configured at service level, passed in to this trusted extension.").Default("/credentials/kubernetes_engine.json").String()
+	paramsJSON             = kingpin.Flag("params", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES").Required().String()
+	paramsYAML             = kingpin.Flag("params-yaml", "Extension parameters, created from custom properties.").Envar("ESTAFETTE_EXTENSION_CUSTOM_PROPERTIES_YAML").Required().String()
+	credentialsPath        = kingpin.Flag("credentials-path", "Path to GKE credentials configured at service level, passed in to this trusted extension.").Default("/credentials/kubernetes_engine.json").String()
+	clusterCredentialsPath = kingpin.Flag("cluster-credentials-path", "Path to non-GKE kubernetes cluster credentials configured at service level, passed in to this trusted extension.").Default("/credentials/kubernetes_cluster.json").String()
 
 	// optional flags
 	gitSource     = kingpin.Flag("git-source", "Repository source.").Envar("ESTAFETTE_GIT_SOURCE").String()
@@ -65,19 +69,37 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed creating credentials.Client")
 	}
 
-	credential, err := credentialsClient.Init(ctx, *paramsJSON, *releaseName, *credentialsPath)
+	credential, err := credentialsClient.Init(ctx, *paramsJSON, *releaseName, []string{*credentialsPath, *clusterCredentialsPath})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed initializing credentials")
 	}
 
-	parametersClient, err := parameters.NewClient(ctx)
+	// google hosted registries accept the access token of the gke credential
+	var googleTokenSource oauth2.TokenSource
+	if !credential.IsKubernetesCluster() {
+		googleTokenSource, err = google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/cloud-platform")
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed creating gcp token source for registries")
+		}
+	}
+
+	registryClient, err := registry.NewClient(ctx, googleTokenSource)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed creating parameters.Client")
+		log.Fatal().Err(err).Msg("Failed creating registry.Client")
 	}
 
-	gcpClient, err := gcp.NewClient(ctx)
+	parametersClient, err := parameters.NewClient(ctx, registryClient)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed creating gcp.Client")
+		log.Fatal().Err(err).Msg("Failed creating parameters.Client")
+	}
+
+	// a kubernetes-cluster credential comes without a service account keyfile to call gcp apis with
+	var gcpClient gcp.Client
+	if !credential.IsKubernetesCluster() {
+		gcpClient, err = gcp.NewClient(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed creating gcp.Client")
+		}
 	}
 
 	builderService, err := builder.NewService(ctx)
@@ -90,7 +112,7 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed creating generator.Service")
 	}
 
-	extensionService, err := extension.NewService(ctx, credentialsClient, parametersClient, gcpClient, builderService, generatorService)
+	extensionService, err := extension.NewService(ctx, credentialsClient, parametersClient, gcpClient, registryClient, builderService, generatorService)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed creating extension.Service")
 	}
@@ -31,6 +31,9 @@ func NewService(ctx context.Context) (Service, error) {
 type service struct {
 }
 
+// partialsFile holds the named templates shared between templates; it's parsed into every template set instead of being merged as a manifest
+const partialsFile = "/templates/_partials.tpl"
+
 func (s *service) BuildTemplates(params api.Params, includePodDisruptionBudget bool) (*template.Template, error) {
 
 	// merge templates
@@ -52,9 +55,32 @@ func (s *service) BuildTemplates(params api.Params, includePodDisruptionBudget b
 	}
 	templateString := strings.Join(templateStrings, "\n---\n")
 
+	// parse the shared partials first, so templates from local manifests can still override them
+	partials, err := ioutil.ReadFile(partialsFile)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := template.New("kubernetes.yaml")
+	tmpl, err = tmpl.Funcs(s.getFuncMap(tmpl)).Parse(string(partials))
+	if err != nil {
+		return nil, err
+	}
+
 	// parse templates
 	log.Info().Msg("Parsing merged templates...")
-	return template.New("kubernetes.yaml").Funcs(sprig.TxtFuncMap()).Parse(templateString)
+	return tmpl.Parse(templateString)
+}
+
+// getFuncMap returns the sprig functions plus include, which renders a named template to a string so it can be piped into indent
+func (s *service) getFuncMap(tmpl *template.Template) template.FuncMap {
+	funcMap := sprig.TxtFuncMap()
+	funcMap["include"] = func(name string, data interface{}) (string, error) {
+		var rendered bytes.Buffer
+		err := tmpl.ExecuteTemplate(&rendered, name, data)
+		return rendered.String(), err
+	}
+
+	return funcMap
 }
 
 func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget bool) []string {
@@ -92,7 +118,9 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 			"serviceaccount.yaml",
 			"statefulset.yaml",
 		}...)
-		if params.CertificateSecret == "" {
+		if params.CertificateSecret == "" && params.Certificate.Provider == api.CertificateProviderCertManager {
+			templatesToMerge = append(templatesToMerge, "certificate-cert-manager.yaml")
+		} else if params.CertificateSecret == "" && params.Certificate.Provider != api.CertificateProviderGKEManaged {
 			templatesToMerge = append(templatesToMerge, "certificate-secret.yaml")
 		}
 
@@ -107,7 +135,9 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 			templatesToMerge = append(templatesToMerge, "service.yaml")
 		}
 
-		if params.CertificateSecret == "" {
+		if params.CertificateSecret == "" && params.Certificate.Provider == api.CertificateProviderCertManager {
+			templatesToMerge = append(templatesToMerge, "certificate-cert-manager.yaml")
+		} else if params.CertificateSecret == "" && params.Certificate.Provider != api.CertificateProviderGKEManaged {
 			templatesToMerge = append(templatesToMerge, "certificate-secret.yaml")
 		}
 
@@ -140,26 +170,61 @@ func (s *service) GetTemplates(params api.Params, includePodDisruptionBudget boo
 		templatesToMerge = append(templatesToMerge, "ingress.yaml")
 	}
 
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && len(params.Routes) > 0 && (params.Visibility == api.VisibilityPrivate || params.Visibility == api.VisibilityPublicWhitelist || params.Visibility == api.VisibilityApigee) {
+		templatesToMerge = append(templatesToMerge, "ingress-routes.yaml")
+	}
+
 	if params.Kind == api.KindDeployment && params.Visibility == api.VisibilityApigee {
 		templatesToMerge = append(templatesToMerge, "ingress-apigee.yaml")
 		templatesToMerge = append(templatesToMerge, "ingress.yaml")
 	}
 
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && params.UsesGCEIngress() {
+		templatesToMerge = append(templatesToMerge, "backend-config.yaml")
+	}
 	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && params.Visibility == api.VisibilityIAP {
-		templatesToMerge = append(templatesToMerge, "backend-config.yaml", "iap-oauth-credentials-secret.yaml")
+		templatesToMerge = append(templatesToMerge, "iap-oauth-credentials-secret.yaml")
+	}
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && params.UsesGCEIngress() && params.Certificate.Provider == api.CertificateProviderGKEManaged {
+		templatesToMerge = append(templatesToMerge, "managedcertificate.yaml")
+	}
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && params.Visibility == api.VisibilityGateway {
+		templatesToMerge = append(templatesToMerge, "httproute.yaml")
+		if len(params.InternalHosts) > 0 {
+			templatesToMerge = append(templatesToMerge, "httproute-internal.yaml")
+		}
+	}
+	if params.Kind == api.KindDeployment && params.Visibility == api.VisibilityGateway && (params.Action == api.ActionDeployCanary || params.Action == api.ActionDeployStable || params.Action == api.ActionDiffCanary || params.Action == api.ActionDiffStable) {
+		templatesToMerge = append(templatesToMerge, "service-track.yaml")
+	}
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment || params.Kind == api.KindStatefulset) && params.NetworkPolicy.Enabled != nil && *params.NetworkPolicy.Enabled {
+		templatesToMerge = append(templatesToMerge, "networkpolicy.yaml")
 	}
-	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && len(params.InternalHosts) > 0 {
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && params.HasIstioSidecar() {
+		templatesToMerge = append(templatesToMerge, "virtualservice.yaml", "destinationrule.yaml")
+	}
+	if (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset) && len(params.InternalHosts) > 0 && params.Visibility != api.VisibilityGateway {
 		templatesToMerge = append(templatesToMerge, "ingress-internal.yaml")
 	}
-	if params.HasSecrets() {
-		templatesToMerge = append(templatesToMerge, "application-secrets.yaml")
+	if params.Kind == api.KindConfig {
+		// kind config publishes configs and secrets under a shared name for other applications to use
+		if params.HasConfigs() {
+			templatesToMerge = append(templatesToMerge, "shared-configmap.yaml")
+		}
+		if len(params.Secrets.Keys) > 0 {
+			templatesToMerge = append(templatesToMerge, "shared-secrets.yaml")
+		}
+	} else {
+		if params.HasSecrets() {
+			templatesToMerge = append(templatesToMerge, "application-secrets.yaml")
+		}
+		if params.HasConfigs() {
+			templatesToMerge = append(templatesToMerge, "configmap.yaml")
+		}
 	}
-	if params.UseGoogleCloudCredentials || params.LegacyGoogleCloudServiceAccountKeyFile != "" {
+	if params.UsesServiceAccountKey() {
 		templatesToMerge = append(templatesToMerge, "service-account-secret.yaml")
 	}
-	if len(params.Configs.Files) > 0 || len(params.Configs.InlineFiles) > 0 {
-		templatesToMerge = append(templatesToMerge, "configmap.yaml")
-	}
 
 	// prefix all filenames with templates dir
 	for i, t := range templatesToMerge {
@@ -197,7 +262,7 @@ func (s *service) RenderConfig(params api.Params) (renderedConfigFiles map[strin
 
 	renderedConfigFiles = map[string]string{}
 
-	if params.Action != api.ActionRollbackCanary && (len(params.Configs.Files) > 0 || len(params.Configs.InlineFiles) > 0) {
+	if params.Action != api.ActionRollbackCanary && params.HasConfigs() {
 		log.Info().Msg("Prerendering config files...")
 
 		// render files passed with configs.files property, replacing placeholders with values specified in configs.data property
@@ -9,7 +9,9 @@ import (
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/estafette/estafette-extension-gke/services/generator"
 	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestGetTemplates(t *testing.T) {
@@ -287,35 +289,902 @@ func TestGetTemplates(t *testing.T) {
 		assert.True(t, stringArrayContains(templates, "/templates/ingress-apigee.yaml"))
 		assert.True(t, stringArrayContains(templates, "/templates/ingress.yaml"))
 	})
+
+	t.Run("IncludesSharedConfigmapIfKindIsConfigAndConfigsAreSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindConfig,
+			Configs: api.ConfigsParams{
+				InlineFiles: map[string]string{
+					"config.yaml": "a: b",
+				},
+			},
+			Secrets: api.SecretsParams{
+				Keys: map[string]interface{}{
+					"secret.yaml": "YTogYg==",
+				},
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/shared-configmap.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/shared-secrets.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/configmap.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/application-secrets.yaml"))
+	})
+
+	t.Run("DoesNotIncludeSharedConfigmapIfKindIsDeployment", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDeployment,
+			Configs: api.ConfigsParams{
+				InlineFiles: map[string]string{
+					"config.yaml": "a: b",
+				},
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.False(t, stringArrayContains(templates, "/templates/shared-configmap.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/configmap.yaml"))
+	})
+
+	t.Run("IncludesHTTPRouteIfVisibilityIsGatewayAndKindIsDeployment", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Visibility: api.VisibilityGateway,
+			Kind:       api.KindDeployment,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/httproute.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/service-track.yaml"))
+	})
+
+	t.Run("IncludesHTTPRouteInternalInsteadOfIngressInternalIfVisibilityIsGatewayAndInternalHostsAreSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:        api.ActionDeploySimple,
+			Visibility:    api.VisibilityGateway,
+			Kind:          api.KindDeployment,
+			InternalHosts: []string{"myapp.internal.example.com"},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/httproute-internal.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/ingress-internal.yaml"))
+	})
+
+	t.Run("IncludesServiceTrackIfVisibilityIsGatewayAndActionIsDeployCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeployCanary,
+			Visibility: api.VisibilityGateway,
+			Kind:       api.KindDeployment,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/httproute.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/service-track.yaml"))
+	})
+
+	t.Run("IncludesVirtualServiceAndDestinationRuleIfIstioSidecarIsSpecified", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Visibility: api.VisibilityPrivate,
+			Kind:       api.KindDeployment,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeIstio,
+				},
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/virtualservice.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/destinationrule.yaml"))
+	})
+
+	t.Run("DoesNotIncludeVirtualServiceAndDestinationRuleIfNoIstioSidecarIsSpecified", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Visibility: api.VisibilityPrivate,
+			Kind:       api.KindDeployment,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.False(t, stringArrayContains(templates, "/templates/virtualservice.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/destinationrule.yaml"))
+	})
+
+	t.Run("IncludesNetworkPolicyIfNetworkPolicyIsEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Visibility: api.VisibilityPrivate,
+			Kind:       api.KindDeployment,
+			NetworkPolicy: api.NetworkPolicyParams{
+				Enabled: &trueValue,
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/networkpolicy.yaml"))
+	})
+
+	t.Run("DoesNotIncludeNetworkPolicyIfNetworkPolicyIsNotEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		falseValue := false
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Visibility: api.VisibilityPrivate,
+			Kind:       api.KindDeployment,
+			NetworkPolicy: api.NetworkPolicyParams{
+				Enabled: &falseValue,
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.False(t, stringArrayContains(templates, "/templates/networkpolicy.yaml"))
+	})
+
+	t.Run("IncludesCertManagerCertificateInsteadOfCertificateSecretIfCertificateProviderIsCertManager", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action: api.ActionDeploySimple,
+			Kind:   api.KindDeployment,
+			Certificate: api.CertificateParams{
+				Provider: api.CertificateProviderCertManager,
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/certificate-cert-manager.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+	})
+
+	t.Run("IncludesManagedCertificateWithoutLetsEncryptSecretIfCertificateProviderIsGKEManagedAndVisibilityIsIap", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Kind:       api.KindDeployment,
+			Visibility: api.VisibilityIAP,
+			Certificate: api.CertificateParams{
+				Provider: api.CertificateProviderGKEManaged,
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/managedcertificate.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/certificate-secret.yaml"))
+	})
+
+	t.Run("IncludesBackendConfigWithoutIapOauthSecretIfVisibilityIsPrivateAndIngressControllerIsGCE", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:            api.ActionDeploySimple,
+			Kind:              api.KindDeployment,
+			Visibility:        api.VisibilityPrivate,
+			IngressController: "gce",
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/backend-config.yaml"))
+		assert.False(t, stringArrayContains(templates, "/templates/iap-oauth-credentials-secret.yaml"))
+		assert.True(t, stringArrayContains(templates, "/templates/ingress.yaml"))
+	})
+
+	t.Run("DoesNotIncludeBackendConfigIfVisibilityIsPrivateAndIngressControllerIsNginx", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Kind:       api.KindDeployment,
+			Visibility: api.VisibilityPrivate,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.False(t, stringArrayContains(templates, "/templates/backend-config.yaml"))
+	})
+
+	t.Run("IncludesIngressRoutesIfRoutesAreSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:     api.ActionDeploySimple,
+			Kind:       api.KindDeployment,
+			Visibility: api.VisibilityPrivate,
+			Routes: []api.RouteParams{
+				{
+					Name: "admin",
+					Path: "/admin",
+				},
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/ingress-routes.yaml"))
+	})
+	t.Run("IncludesServiceAccountSecretIfUseGoogleCloudCredentialsIsTrue", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Action:                    api.ActionDeploySimple,
+			Kind:                      api.KindDeployment,
+			UseGoogleCloudCredentials: true,
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.True(t, stringArrayContains(templates, "/templates/service-account-secret.yaml"))
+	})
+
+	t.Run("DoesNotIncludeServiceAccountSecretIfWorkloadIdentityIsEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			Action:                    api.ActionDeploySimple,
+			Kind:                      api.KindDeployment,
+			UseGoogleCloudCredentials: true,
+			WorkloadIdentity: api.WorkloadIdentityParams{
+				Enabled:           &trueValue,
+				GCPServiceAccount: "myapp@myproject.iam.gserviceaccount.com",
+			},
+		}
+
+		// act
+		templates := service.GetTemplates(params, true)
+
+		assert.False(t, stringArrayContains(templates, "/templates/service-account-secret.yaml"))
+	})
 }
 
-func TestInjectSteps(t *testing.T) {
+func TestInjectSteps(t *testing.T) {
+
+	t.Run("RenderNamespace", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Namespace: "mynamespace",
+		}
+		tmpl, err := template.ParseFiles("../../templates/namespace.yaml")
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: mynamespace", renderedTemplate.String())
+		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
+	})
+
+	t.Run("RenderServiceAccount", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:      "myapp",
+			Namespace: "mynamespace",
+			Labels: map[string]string{
+				"app":  "myapp",
+				"team": "myteam",
+			},
+		}
+		tmpl, err := template.New("serviceaccount.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/serviceaccount.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"", renderedTemplate.String())
+		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
+	})
+
+	t.Run("RenderHorizontalPodAutoscaler", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:          "myapp",
+			NameWithTrack: "myapp-canary",
+			Namespace:     "mynamespace",
+			Labels: map[string]string{
+				"app":  "myapp",
+				"team": "myteam",
+			},
+			MinReplicas:         3,
+			MaxReplicas:         19,
+			TargetCPUPercentage: 65,
+		}
+		tmpl, err := template.New("horizontalpodautoscaler.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/horizontalpodautoscaler.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  metrics:\n  - type: Resource\n    resource:\n      name: cpu\n      target:\n        type: Utilization\n        averageUtilization: 65", renderedTemplate.String())
+		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
+	})
+
+	t.Run("RenderHorizontalPodAutoscalerWithAutoscalingV2", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:          "myapp",
+			NameWithTrack: "myapp-canary",
+			Namespace:     "mynamespace",
+			Labels: map[string]string{
+				"app":  "myapp",
+				"team": "myteam",
+			},
+			MinReplicas:         3,
+			MaxReplicas:         19,
+			TargetCPUPercentage: 65,
+			APIVersions: api.APIVersionsData{
+				HorizontalPodAutoscaler: "autoscaling/v2",
+			},
+		}
+		tmpl, err := template.New("horizontalpodautoscaler.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/horizontalpodautoscaler.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  metrics:\n  - type: Resource\n    resource:\n      name: cpu\n      target:\n        type: Utilization\n        averageUtilization: 65", renderedTemplate.String())
+	})
+
+	t.Run("RenderIngressWithNetworkingV1", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:            "myapp",
+			Namespace:       "mynamespace",
+			Hosts:           []string{"myapp.example.com"},
+			IngressPath:     "/api/*",
+			IngressPathType: "ImplementationSpecific",
+			UseGCEIngress:   true,
+			APIVersions: api.APIVersionsData{
+				Ingress: "networking.k8s.io/v1",
+			},
+		}
+		tmpl, err := template.New("ingress.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(renderedTemplate.String(), "apiVersion: networking.k8s.io/v1\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "      - path: /api/*\n        pathType: ImplementationSpecific\n        backend:\n          service:\n            name: myapp\n            port:\n              name: web"))
+		assert.False(t, strings.Contains(renderedTemplate.String(), "serviceName"))
+	})
+
+	t.Run("RenderIngressWithExtensionsV1beta1", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:        "myapp",
+			Namespace:   "mynamespace",
+			Hosts:       []string{"myapp.example.com"},
+			IngressPath: "/",
+			APIVersions: api.APIVersionsData{
+				Ingress: "extensions/v1beta1",
+			},
+		}
+		tmpl, err := template.New("ingress.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(renderedTemplate.String(), "apiVersion: extensions/v1beta1\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "      - path: /\n        backend:\n          serviceName: myapp\n          servicePort: web"))
+		assert.False(t, strings.Contains(renderedTemplate.String(), "pathType"))
+	})
+
+	t.Run("RenderServiceTrack", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:             "myapp",
+			Namespace:        "mynamespace",
+			AppLabelSelector: "myapp",
+			Container: api.ContainerData{
+				Port: 5000,
+			},
+		}
+		tmpl, err := template.New("service-track.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/service-track.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "\napiVersion: v1\nkind: Service\nmetadata:\n  name: myapp-stable\n  namespace: mynamespace\n  labels:\nspec:\n  type: ClusterIP\n  ports:\n  - name: web\n    port: 5000\n    targetPort: web\n    protocol: TCP\n  selector:\n    \"app\": \"myapp\"\n    \"track\": \"stable\"\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\nspec:\n  type: ClusterIP\n  ports:\n  - name: web\n    port: 5000\n    targetPort: web\n    protocol: TCP\n  selector:\n    \"app\": \"myapp\"\n    \"track\": \"canary\"\n", renderedTemplate.String())
+	})
+
+	t.Run("RenderHTTPRoute", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                  "myapp",
+			Namespace:             "mynamespace",
+			Hosts:                 []string{"myapp.example.com"},
+			GatewayName:           "shared-gateway",
+			GatewayNamespace:      "gateways",
+			GatewayPath:           "/api",
+			GatewayRequestTimeout: "60s",
+			GatewayBackendPort:    80,
+			GatewayBackends: []api.GatewayBackendData{
+				{Name: "myapp-stable", Weight: 90},
+				{Name: "myapp-canary", Weight: 10},
+			},
+			APIVersions: api.APIVersionsData{
+				Gateway: "gateway.networking.k8s.io/v1",
+			},
+		}
+		tmpl, err := template.New("httproute.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/httproute.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
 
-	t.Run("RenderNamespace", func(t *testing.T) {
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: gateway.networking.k8s.io/v1\nkind: HTTPRoute\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\nspec:\n  parentRefs:\n  - name: shared-gateway\n    namespace: gateways\n  hostnames:\n  - myapp.example.com\n  rules:\n  - matches:\n    - path:\n        type: PathPrefix\n        value: /api\n    timeouts:\n      request: 60s\n    backendRefs:\n    - name: myapp-stable\n      port: 80\n      weight: 90\n    - name: myapp-canary\n      port: 80\n      weight: 10\n", renderedTemplate.String())
+	})
+
+	t.Run("RenderVirtualService", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:              "myapp",
+			Namespace:         "mynamespace",
+			MeshTimeout:       "60s",
+			MeshRetryAttempts: 2,
+			MeshRetryOn:       "connect-failure",
+			MeshDestinations: []api.MeshDestinationData{
+				{Subset: "stable", Weight: 90},
+				{Subset: "canary", Weight: 10},
+			},
+			APIVersions: api.APIVersionsData{
+				IstioNetworking: "networking.istio.io/v1",
+			},
+		}
+		tmpl, err := template.New("virtualservice.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/virtualservice.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: networking.istio.io/v1\nkind: VirtualService\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\nspec:\n  hosts:\n  - myapp\n  http:\n  - timeout: 60s\n    retries:\n      attempts: 2\n      retryOn: connect-failure\n    route:\n    - destination:\n        host: myapp\n        subset: stable\n      weight: 90\n    - destination:\n        host: myapp\n        subset: canary\n      weight: 10\n", renderedTemplate.String())
+	})
+
+	t.Run("RenderDestinationRuleWithMTLSAndOutlierDetection", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                    "myapp",
+			Namespace:               "mynamespace",
+			IstioMTLS:               true,
+			UseMeshOutlierDetection: true,
+			MeshOutlierDetection: api.MeshOutlierDetectionData{
+				Consecutive5xxErrors: 5,
+				Interval:             "10s",
+				BaseEjectionTime:     "30s",
+				MaxEjectionPercent:   10,
+			},
+			APIVersions: api.APIVersionsData{
+				IstioNetworking: "networking.istio.io/v1",
+			},
+		}
+		tmpl, err := template.New("destinationrule.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/destinationrule.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: networking.istio.io/v1\nkind: DestinationRule\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\nspec:\n  host: myapp\n  trafficPolicy:\n    tls:\n      mode: ISTIO_MUTUAL\n    outlierDetection:\n      consecutive5xxErrors: 5\n      interval: 10s\n      baseEjectionTime: 30s\n      maxEjectionPercent: 10\n  subsets:\n  - name: stable\n    labels:\n      track: stable\n  - name: canary\n    labels:\n      track: canary\n", renderedTemplate.String())
+	})
+
+	t.Run("RenderIngressWithAnnotationsForIngressControllerProfile", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                        "myapp",
+			Namespace:                   "mynamespace",
+			Hosts:                       []string{"myapp.example.com"},
+			IngressPath:                 "/",
+			UseNginxIngress:             true,
+			UseHTTPS:                    true,
+			NginxIngressBackendProtocol: "HTTPS",
+			IngressController: api.IngressControllerData{
+				Class:            "nginx-office",
+				AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+				Features: map[string]bool{
+					api.IngressControllerFeatureBackendProtocol: true,
+				},
+			},
+			APIVersions: api.APIVersionsData{
+				Ingress: "networking.k8s.io/v1",
+			},
+		}
+		tmpl, err := template.New("ingress.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.Contains(renderedTemplate.String(), "  annotations:\n    kubernetes.io/ingress.class: \"nginx-office\"\n    nginx.ingress.kubernetes.io/backend-protocol: \"HTTPS\"\n    nginx.ingress.kubernetes.io/proxy-ssl-verify: \"on\"\n"))
+		assert.False(t, strings.Contains(renderedTemplate.String(), "proxy-body-size"))
+	})
+
+	t.Run("RenderIngressWithGCEIngressControllerProfile", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:          "myapp",
+			Namespace:     "mynamespace",
+			Hosts:         []string{"myapp.example.com"},
+			IngressPath:   "/*",
+			UseGCEIngress: true,
+			UseHTTPS:      true,
+			IngressController: api.IngressControllerData{
+				Class:            "gce",
+				AnnotationPrefix: "kubernetes.io/ingress.",
+				Features: map[string]bool{
+					api.IngressControllerFeatureDisallowHTTP: true,
+				},
+			},
+			APIVersions: api.APIVersionsData{
+				Ingress: "networking.k8s.io/v1",
+			},
+		}
+		tmpl, err := template.New("ingress.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.Contains(renderedTemplate.String(), "  annotations:\n    kubernetes.io/ingress.class: \"gce\"\n    kubernetes.io/ingress.allow-http: \"false\"\n"))
+		assert.False(t, strings.Contains(renderedTemplate.String(), "backend-protocol"))
+	})
+
+	t.Run("RenderIngressWithManagedCertificate", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                  "myapp",
+			Namespace:             "mynamespace",
+			Hosts:                 []string{"myapp.example.com"},
+			IngressPath:           "/*",
+			UseGCEIngress:         true,
+			UseManagedCertificate: true,
+			IngressController: api.IngressControllerData{
+				Class:            "gce",
+				AnnotationPrefix: "kubernetes.io/ingress.",
+			},
+			APIVersions: api.APIVersionsData{
+				Ingress: "networking.k8s.io/v1",
+			},
+		}
+		tmpl, err := template.New("ingress.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.Contains(renderedTemplate.String(), "    networking.gke.io/managed-certificates: \"myapp\"\n"))
+		assert.False(t, strings.Contains(renderedTemplate.String(), "tls:"))
+	})
+
+	t.Run("RenderCertManagerCertificate", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                  "myapp",
+			Namespace:             "mynamespace",
+			AllHosts:              []string{"myapp.example.com", "myapp.internal.example.com"},
+			CertificateSecretName: "myapp-cert-manager-certificate",
+			CertManagerIssuer:     "letsencrypt",
+			CertManagerIssuerKind: "ClusterIssuer",
+		}
+		tmpl, err := template.New("certificate-cert-manager.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/certificate-cert-manager.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(renderedTemplate.String(), "apiVersion: cert-manager.io/v1\nkind: Certificate\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "spec:\n  secretName: myapp-cert-manager-certificate\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "  dnsNames:\n  - myapp.example.com\n  - myapp.internal.example.com\n  issuerRef:\n    name: letsencrypt\n    kind: ClusterIssuer\n"))
+	})
+
+	t.Run("RenderBackendConfigWithCloudArmorCDNAndHealthCheck", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                               "myapp",
+			Namespace:                          "mynamespace",
+			BackendConfigTimeout:               60,
+			BackendConfigSecurityPolicy:        "office-only",
+			UseBackendConfigCDN:                true,
+			BackendConfigCDNCacheMode:          "CACHE_ALL_STATIC",
+			UseBackendConfigCDNTTLs:            true,
+			BackendConfigCDNDefaultTTL:         3600,
+			BackendConfigCDNMaxTTL:             86400,
+			BackendConfigCDNClientTTL:          3600,
+			BackendConfigCDNIncludeQueryString: true,
+			BackendConfigSessionAffinityType:   "GENERATED_COOKIE",
+			BackendConfigSessionAffinityTTL:    300,
+			BackendConfigDrainingTimeout:       30,
+			BackendConfigHealthCheckPath:       "/readiness",
+			BackendConfigHealthCheckType:       "HTTP",
+			UseBackendConfigLogging:            true,
+			BackendConfigLoggingSampleRate:     0.5,
+			APIVersions: api.APIVersionsData{
+				BackendConfig: "cloud.google.com/v1",
+			},
+		}
+		tmpl, err := template.New("backend-config.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/backend-config.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "apiVersion: cloud.google.com/v1\nkind: BackendConfig\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\nspec:\n  timeoutSec: 60\n  securityPolicy:\n    name: office-only\n  cdn:\n    enabled: true\n    cacheMode: CACHE_ALL_STATIC\n    defaultTtl: 3600\n    maxTtl: 86400\n    clientTtl: 3600\n    cachePolicy:\n      includeHost: true\n      includeProtocol: true\n      includeQueryString: true\n  sessionAffinity:\n    affinityType: GENERATED_COOKIE\n    affinityCookieTtlSec: 300\n  connectionDraining:\n    drainingTimeoutSec: 30\n  healthCheck:\n    type: HTTP\n    requestPath: /readiness\n  logging:\n    enable: true\n    sampleRate: 0.5", renderedTemplate.String())
+	})
+
+	t.Run("RenderBackendConfigCDNWithoutTTLsIfCacheModeIsUseOriginHeaders", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                               "myapp",
+			Namespace:                          "mynamespace",
+			BackendConfigTimeout:               60,
+			UseBackendConfigCDN:                true,
+			BackendConfigCDNCacheMode:          "USE_ORIGIN_HEADERS",
+			BackendConfigCDNIncludeQueryString: true,
+			APIVersions: api.APIVersionsData{
+				BackendConfig: "cloud.google.com/v1",
+			},
+		}
+		tmpl, err := template.New("backend-config.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/backend-config.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.HasSuffix(renderedTemplate.String(), "  cdn:\n    enabled: true\n    cacheMode: USE_ORIGIN_HEADERS\n    cachePolicy:\n      includeHost: true\n      includeProtocol: true\n      includeQueryString: true"))
+	})
+
+	t.Run("RenderBackendConfigWithIap", func(t *testing.T) {
+
+		data := api.TemplateData{
+			Name:                 "myapp",
+			Namespace:            "mynamespace",
+			BackendConfigTimeout: 60,
+			UseIAP:               true,
+			APIVersions: api.APIVersionsData{
+				BackendConfig: "cloud.google.com/v1",
+			},
+		}
+		tmpl, err := template.New("backend-config.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/backend-config.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.HasSuffix(renderedTemplate.String(), "spec:\n  iap:\n    enabled: true\n    oauthclientCredentials:\n      secretName: myapp-iap-oauth-credentials\n  timeoutSec: 60"))
+	})
+
+	t.Run("RenderIngressRoutes", func(t *testing.T) {
 
 		data := api.TemplateData{
+			Name:      "myapp",
 			Namespace: "mynamespace",
+			RoutesIngressController: api.IngressControllerData{
+				Class:            "nginx-office",
+				AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+				Features: map[string]bool{
+					api.IngressControllerFeatureProxySettings: true,
+				},
+			},
+			Routes: []api.RouteData{
+				{
+					Name:                 "api",
+					Hosts:                []string{"myapp.example.com"},
+					Path:                 "/api",
+					PathType:             "Prefix",
+					PortName:             "web",
+					ProxyConnectTimeout:  60,
+					ProxySendTimeout:     60,
+					ProxyReadTimeout:     60,
+					ProxyBodySize:        "128m",
+					ClientBodyBufferSize: "8k",
+					ProxyBufferSize:      "4k",
+					ProxyBuffersNumber:   "4",
+				},
+				{
+					Name:                 "admin",
+					Hosts:                []string{"myapp.example.com"},
+					Path:                 "/admin",
+					PathType:             "Prefix",
+					PortName:             "admin",
+					ProxyConnectTimeout:  75,
+					ProxySendTimeout:     300,
+					ProxyReadTimeout:     300,
+					ProxyBodySize:        "1m",
+					ClientBodyBufferSize: "8k",
+					ProxyBufferSize:      "4k",
+					ProxyBuffersNumber:   "4",
+				},
+			},
+			APIVersions: api.APIVersionsData{
+				Ingress: "networking.k8s.io/v1",
+			},
 		}
-		tmpl, err := template.ParseFiles("../../templates/namespace.yaml")
+		tmpl, err := template.New("ingress-routes.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress-routes.yaml")
+		assert.Nil(t, err)
 
 		// act
 		var renderedTemplate bytes.Buffer
 		err = tmpl.Execute(&renderedTemplate, data)
 
 		assert.Nil(t, err)
-		assert.Equal(t, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: mynamespace", renderedTemplate.String())
-		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "  name: myapp-api\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "\n---\napiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: myapp-admin\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "    nginx.ingress.kubernetes.io/proxy-read-timeout: \"300\"\n"))
+		assert.True(t, strings.Contains(renderedTemplate.String(), "      - path: /admin\n        pathType: Prefix\n        backend:\n          service:\n            name: myapp\n            port:\n              name: admin"))
 	})
+	t.Run("RenderIngressWithGRPCBackendProtocol", func(t *testing.T) {
 
-	t.Run("RenderServiceAccount", func(t *testing.T) {
+		data := api.TemplateData{
+			Name:                        "myapp",
+			Namespace:                   "mynamespace",
+			Hosts:                       []string{"myapp.example.com"},
+			IngressPath:                 "/",
+			UseNginxIngress:             true,
+			NginxIngressBackendProtocol: "GRPC",
+			IngressController: api.IngressControllerData{
+				Class:            "nginx-office",
+				AnnotationPrefix: "nginx.ingress.kubernetes.io/",
+				Features: map[string]bool{
+					api.IngressControllerFeatureBackendProtocol: true,
+				},
+			},
+			APIVersions: api.APIVersionsData{
+				Ingress: "networking.k8s.io/v1",
+			},
+		}
+		tmpl, err := template.New("ingress.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/ingress.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.True(t, strings.Contains(renderedTemplate.String(), "  annotations:\n    kubernetes.io/ingress.class: \"nginx-office\"\n    nginx.ingress.kubernetes.io/backend-protocol: \"GRPC\"\n"))
+		assert.False(t, strings.Contains(renderedTemplate.String(), "proxy-ssl-verify"))
+	})
+	t.Run("RenderServiceAccountWithWorkloadIdentityAnnotation", func(t *testing.T) {
 
 		data := api.TemplateData{
 			Name:      "myapp",
 			Namespace: "mynamespace",
 			Labels: map[string]string{
-				"app":  "myapp",
-				"team": "myteam",
+				"app": "myapp",
 			},
+			UseWorkloadIdentity:        true,
+			WorkloadIdentityGCPAccount: "myapp@myproject.iam.gserviceaccount.com",
 		}
 		tmpl, err := template.New("serviceaccount.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/serviceaccount.yaml")
 		assert.Nil(t, err)
@@ -325,34 +1194,207 @@ func TestInjectSteps(t *testing.T) {
 		err = tmpl.Execute(&renderedTemplate, data)
 
 		assert.Nil(t, err)
-		assert.Equal(t, "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"", renderedTemplate.String())
-		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
+		assert.Equal(t, "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: myapp\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n  annotations:\n    iam.gke.io/gcp-service-account: \"myapp@myproject.iam.gserviceaccount.com\"", renderedTemplate.String())
 	})
 
-	t.Run("RenderHorizontalPodAutoscaler", func(t *testing.T) {
+	t.Run("RenderHorizontalPodAutoscalerWithMetricsAndBehavior", func(t *testing.T) {
 
 		data := api.TemplateData{
 			Name:          "myapp",
 			NameWithTrack: "myapp-canary",
 			Namespace:     "mynamespace",
 			Labels: map[string]string{
-				"app":  "myapp",
-				"team": "myteam",
+				"app": "myapp",
 			},
 			MinReplicas:         3,
 			MaxReplicas:         19,
 			TargetCPUPercentage: 65,
+			HpaMetrics: []interface{}{
+				map[string]interface{}{
+					"type": "Resource",
+					"resource": map[string]interface{}{
+						"name": "memory",
+						"target": map[string]interface{}{
+							"type":               "Utilization",
+							"averageUtilization": 70,
+						},
+					},
+				},
+			},
+			HpaBehavior: map[string]interface{}{
+				"scaleDown": map[string]interface{}{
+					"stabilizationWindowSeconds": 300,
+				},
+			},
+			APIVersions: api.APIVersionsData{
+				HorizontalPodAutoscaler: "autoscaling/v2",
+			},
 		}
 		tmpl, err := template.New("horizontalpodautoscaler.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/horizontalpodautoscaler.yaml")
 		assert.Nil(t, err)
 
+		data.ToYAML = func(v interface{}) string {
+			out, _ := yaml.Marshal(v)
+			return string(out)
+		}
+
 		// act
 		var renderedTemplate bytes.Buffer
 		err = tmpl.Execute(&renderedTemplate, data)
 
 		assert.Nil(t, err)
-		assert.Equal(t, "apiVersion: autoscaling/v1\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\n    \"team\": \"myteam\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  targetCPUUtilizationPercentage: 65", renderedTemplate.String())
-		assert.True(t, strings.Contains(renderedTemplate.String(), "mynamespace"))
+		assert.Equal(t, "apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: myapp-canary\n  namespace: mynamespace\n  labels:\n    \"app\": \"myapp\"\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: myapp-canary\n  minReplicas: 3\n  maxReplicas: 19\n  metrics:\n  - resource:\n      name: memory\n      target:\n        averageUtilization: 70\n        type: Utilization\n    type: Resource\n  \n  behavior:\n    scaleDown:\n      stabilizationWindowSeconds: 300\n    ", renderedTemplate.String())
+	})
+
+	t.Run("RenderDeploymentWithCloudSQLProxyV1SidecarAsBeforeV2Support", func(t *testing.T) {
+
+		params := api.Params{
+			Kind:      api.KindDeployment,
+			App:       "myapp",
+			Namespace: "mynamespace",
+			Sidecars: []*api.SidecarParams{
+				{
+					Type:                     api.SidecarTypeCloudSQLProxy,
+					DbInstanceConnectionName: "project:region:db",
+					SQLProxyPort:             5432,
+				},
+			},
+		}
+		params.SetDefaults("github.com", "estafette", "estafette-extension-gke", "myapp", "1.0.0", "production", api.ActionDeployStable, "5", map[string]string{})
+
+		generatorService, err := generator.NewService(context.Background())
+		assert.Nil(t, err)
+		data, err := generatorService.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "5", "")
+		assert.Nil(t, err)
+
+		builderService := &service{}
+		tmpl := template.New("deployment.yaml")
+		tmpl, err = tmpl.Funcs(builderService.getFuncMap(tmpl)).ParseFiles("../../templates/deployment.yaml", "../../templates/_partials.tpl")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Contains(t, renderedTemplate.String(), "      - name: myapp-cloudsql-proxy\n        image: eu.gcr.io/cloudsql-docker/gce-proxy:1.21.0\n        resources:\n          requests:\n            cpu: 50m\n            memory: 30Mi\n          limits:\n            memory: 50Mi\n        command: [\"/cloud_sql_proxy\",\n                  \"-instances=project:region:db=tcp:5432\",\n                  \"-term_timeout=60s\"]\n      - name: myapp-openresty\n")
+	})
+
+	t.Run("RenderJobAndCronJobWithSharedSidecarPartial", func(t *testing.T) {
+
+		params := api.Params{
+			Kind:           api.KindJob,
+			App:            "myapp",
+			Namespace:      "mynamespace",
+			NativeSidecars: api.NativeSidecarsModeDisabled,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type:                     api.SidecarTypeCloudSQLProxy,
+					SQLProxyVersion:          api.CloudSQLProxyVersionV2,
+					DbInstanceConnectionName: "project:region:db",
+					SQLProxyPort:             5432,
+				},
+			},
+		}
+		params.SetDefaults("github.com", "estafette", "estafette-extension-gke", "myapp", "1.0.0", "production", api.ActionDeploySimple, "5", map[string]string{})
+
+		generatorService, err := generator.NewService(context.Background())
+		assert.Nil(t, err)
+		data, err := generatorService.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "5", "")
+		assert.Nil(t, err)
+
+		builderService := &service{}
+		jobTmpl := template.New("job.yaml")
+		jobTmpl, err = jobTmpl.Funcs(builderService.getFuncMap(jobTmpl)).ParseFiles("../../templates/job.yaml", "../../templates/_partials.tpl")
+		assert.Nil(t, err)
+		cronJobTmpl := template.New("cronjob.yaml")
+		cronJobTmpl, err = cronJobTmpl.Funcs(builderService.getFuncMap(cronJobTmpl)).ParseFiles("../../templates/cronjob.yaml", "../../templates/_partials.tpl")
+		assert.Nil(t, err)
+
+		// act
+		var renderedJob, renderedCronJob bytes.Buffer
+		err = jobTmpl.Execute(&renderedJob, data)
+		assert.Nil(t, err)
+		err = cronJobTmpl.Execute(&renderedCronJob, data)
+		assert.Nil(t, err)
+
+		sidecar := "- name: myapp-cloudsql-proxy\n  image: gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.14.1\n  resources:\n    requests:\n      cpu: 50m\n      memory: 30Mi\n    limits:\n      memory: 50Mi\n  command: [\"/cloud-sql-proxy\"]\n  args:\n  - \"project:region:db?port=5432\"\n  - \"--quitquitquit\"\n  - \"--admin-port=9091\"\n"
+		assert.Contains(t, renderedJob.String(), strings.ReplaceAll("\n"+sidecar, "\n", "\n      "))
+		assert.Contains(t, renderedCronJob.String(), strings.ReplaceAll("\n"+sidecar, "\n", "\n          "))
+	})
+
+	t.Run("RenderServiceWithAppProtocolForHTTPSPortOfOpenrestySidecar", func(t *testing.T) {
+
+		params := api.Params{
+			Kind:       api.KindDeployment,
+			App:        "myapp",
+			Namespace:  "mynamespace",
+			Visibility: api.VisibilityPrivate,
+			Container: api.ContainerParams{
+				Protocol: api.ContainerProtocolGRPCS,
+			},
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeOpenresty,
+				},
+			},
+		}
+		params.SetDefaults("github.com", "estafette", "estafette-extension-gke", "myapp", "1.0.0", "production", api.ActionDeployStable, "5", map[string]string{})
+
+		generatorService, err := generator.NewService(context.Background())
+		assert.Nil(t, err)
+		data, err := generatorService.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "5", "")
+		assert.Nil(t, err)
+		assert.True(t, data.HasOpenrestySidecar)
+
+		tmpl, err := template.New("service.yaml").Funcs(sprig.TxtFuncMap()).ParseFiles("../../templates/service.yaml")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Contains(t, renderedTemplate.String(), "    service.alpha.kubernetes.io/app-protocols: '{\"https\":\"HTTP2\"}'\n")
+		assert.Contains(t, renderedTemplate.String(), "  - name: https\n    port: 443\n    targetPort: https\n")
+		assert.NotContains(t, renderedTemplate.String(), "name: web")
+	})
+
+	t.Run("RenderOpenrestySidecarWithoutJaegerEnvironmentVariablesIfTracingProviderIsOpenTelemetry", func(t *testing.T) {
+
+		params := api.Params{
+			Kind:      api.KindDeployment,
+			App:       "myapp",
+			Namespace: "mynamespace",
+			Tracing: api.TracingParams{
+				Provider: api.TracingProviderOpenTelemetry,
+			},
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeOpenresty,
+				},
+			},
+		}
+		params.SetDefaults("github.com", "estafette", "estafette-extension-gke", "myapp", "1.0.0", "production", api.ActionDeployStable, "5", map[string]string{})
+
+		generatorService, err := generator.NewService(context.Background())
+		assert.Nil(t, err)
+		data, err := generatorService.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "5", "")
+		assert.Nil(t, err)
+
+		builderService := &service{}
+		tmpl := template.New("deployment.yaml")
+		tmpl, err = tmpl.Funcs(builderService.getFuncMap(tmpl)).ParseFiles("../../templates/deployment.yaml", "../../templates/_partials.tpl")
+		assert.Nil(t, err)
+
+		// act
+		var renderedTemplate bytes.Buffer
+		err = tmpl.Execute(&renderedTemplate, data)
+
+		assert.Nil(t, err)
+		assert.Contains(t, renderedTemplate.String(), "      - name: myapp-openresty\n")
+		assert.Contains(t, renderedTemplate.String(), "        env:\n        - name: \"OFFLOAD_TO_HOST\"\n")
+		assert.NotContains(t, renderedTemplate.String(), "JAEGER_AGENT_HOST")
 	})
 }
 
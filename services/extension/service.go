@@ -2,16 +2,20 @@ package extension
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/estafette/estafette-extension-gke/api"
 	"github.com/estafette/estafette-extension-gke/clients/credentials"
 	"github.com/estafette/estafette-extension-gke/clients/gcp"
 	"github.com/estafette/estafette-extension-gke/clients/parameters"
+	"github.com/estafette/estafette-extension-gke/clients/registry"
 	"github.com/estafette/estafette-extension-gke/services/builder"
 	"github.com/estafette/estafette-extension-gke/services/generator"
 	foundation "github.com/estafette/estafette-foundation"
@@ -24,11 +28,12 @@ type Service interface {
 }
 
 // NewService returns a new extension.Service
-func NewService(ctx context.Context, credentialsClient credentials.Client, parametersClient parameters.Client, gcpClient gcp.Client, builderService builder.Service, generatorService generator.Service) (Service, error) {
+func NewService(ctx context.Context, credentialsClient credentials.Client, parametersClient parameters.Client, gcpClient gcp.Client, registryClient registry.Client, builderService builder.Service, generatorService generator.Service) (Service, error) {
 	return &service{
 		credentialsClient: credentialsClient,
 		parametersClient:  parametersClient,
 		gcpClient:         gcpClient,
+		registryClient:    registryClient,
 		builderService:    builderService,
 		generatorService:  generatorService,
 	}, nil
@@ -38,6 +43,7 @@ type service struct {
 	credentialsClient credentials.Client
 	parametersClient  parameters.Client
 	gcpClient         gcp.Client
+	registryClient    registry.Client
 	builderService    builder.Service
 	generatorService  generator.Service
 
@@ -52,9 +58,41 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 		log.Fatal().Err(err).Msg("Failed initializing parameters")
 	}
 
-	_, err = s.gcpClient.LoadGKEClusterKubeConfig(ctx, credential)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed creating kube config for gke cluster")
+	if credential.IsKubernetesCluster() {
+		if params.UsesWorkloadIdentity() || params.Visibility == api.VisibilityESP || params.Visibility == api.VisibilityESPv2 || params.HasSecretManagerReferences() {
+			log.Fatal().Msgf("Credential %v of type %v can't be used with workloadIdentity, visibility esp or gcpsm:// secrets, since those need gcp apis", credential.Name, credential.Type)
+		}
+
+		_, err = s.credentialsClient.LoadKubernetesClusterKubeConfig(ctx, credential)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed creating kube config for kubernetes cluster")
+		}
+	} else {
+		_, err = s.gcpClient.LoadGKEClusterKubeConfig(ctx, credential)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed creating kube config for gke cluster")
+		}
+	}
+
+	// replace gcpsm:// secret references with their values from secret manager
+	if params.HasSecretManagerReferences() {
+		err = s.gcpClient.ResolveSecretManagerReferences(ctx, &params)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed resolving secrets from secret manager")
+		}
+	}
+
+	// retrieve api versions served by the cluster to render resources with the newest supported api version
+	params.ServedAPIVersions = s.getServedAPIVersions(ctx)
+
+	// retrieve the kubernetes version to decide whether job sidecars can run as native sidecars
+	if params.NativeSidecars == api.NativeSidecarsModeAuto && (params.Kind == api.KindJob || params.Kind == api.KindCronJob) {
+		params.KubernetesVersion = s.getKubernetesVersion(ctx)
+		if !params.UsesNativeSidecars() {
+			for _, sidecar := range params.GetSidecarsKeepingJobRunning() {
+				log.Warn().Msgf("Sidecar %v keeps the job from completing, since kubernetes %v doesn't run it as native sidecar; make sure it exits once the job container is done", sidecar, params.KubernetesVersion)
+			}
+		}
 	}
 
 	// combine templates
@@ -79,18 +117,44 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 		return
 	}
 
+	// look up the images in their registry to fail fast on a non-existing tag
+	if params.Action != api.ActionDelete && params.Action != api.ActionRollbackCanary {
+		s.checkImagesIfRequired(ctx, &params)
+	}
+
 	// checking number of replicas for existing deployment to make switching deployment type safe
 	currentReplicas := params.Replicas
 	if params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment {
 		currentReplicas = s.getExistingNumberOfReplicas(ctx, params)
 	}
 
+	// pin esp for stable releases to the config the canary rolled out, so stable pods never follow a config submission ahead of their manifest
+	if tmpl != nil {
+		s.pinGoogleEndpointsConfigForStableIfRequired(ctx, &params)
+	}
+
 	// generate the data required for rendering the templates
-	templateData := s.generatorService.GenerateTemplateData(params, currentReplicas, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+	templateData, err := s.generatorService.GenerateTemplateData(params, currentReplicas, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed generating template data")
+	}
 
 	if params.Action == api.ActionDelete {
 		log.Info().Msgf("Deleting all resources with label app=%v in namespace %v...", templateData.AppLabelSelector, templateData.Namespace)
-		args := []string{"delete", "svc,ing,deploy,sts,cronjob,job,cm,secret,hpa,pdb,sa,backendconfig", "-l", fmt.Sprintf("app=%v", templateData.AppLabelSelector), "-n", templateData.Namespace, "--ignore-not-found=true"}
+		resourceTypes := "svc,ing,deploy,sts,cronjob,job,cm,secret,hpa,pdb,sa,backendconfig,netpol"
+		if templateData.UseGatewayRoute {
+			resourceTypes += ",httproute"
+		}
+		if templateData.UseIstio {
+			resourceTypes += ",virtualservice,destinationrule"
+		}
+		if templateData.UseCertManagerCertificate {
+			resourceTypes += ",certificates.cert-manager.io"
+		}
+		if templateData.UseManagedCertificate {
+			resourceTypes += ",managedcertificates.networking.gke.io"
+		}
+		args := []string{"delete", resourceTypes, "-l", fmt.Sprintf("app=%v", templateData.AppLabelSelector), "-n", templateData.Namespace, "--ignore-not-found=true"}
 		if params.DryRun {
 			args = append(args, "--dry-run=client")
 		}
@@ -100,30 +164,7 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 	}
 
 	// render the template
-	renderedTemplate, err := s.builderService.RenderTemplate(tmpl, templateData, true)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed rendering templates")
-	}
-	renderedNoPDBTemplate, err := s.builderService.RenderTemplate(tmplNoPDB, templateData, false)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed rendering templates without poddisruptionbudget")
-	}
-
-	if tmpl != nil {
-		log.Info().Msg("Storing rendered manifest on disk...")
-		err = ioutil.WriteFile("/kubernetes.yaml", renderedTemplate.Bytes(), 0600)
-		if err != nil {
-			log.Fatal().Err(err).Msg("Failed writing manifest")
-		}
-	}
-
-	if tmplNoPDB != nil {
-		log.Info().Msg("Storing rendered manifest without poddisruptionbudget on disk...")
-		err = ioutil.WriteFile("/kubernetes-no-pdb.yaml", renderedNoPDBTemplate.Bytes(), 0600)
-		if err != nil {
-			log.Fatal().Err(err).Msg("Failed writing manifest without poddisruptionbudget")
-		}
-	}
+	s.renderAndStoreManifests(tmpl, tmplNoPDB, templateData)
 
 	if tmpl != nil {
 		// visibility public is deprecated, so fail if creating new public service
@@ -145,14 +186,30 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 
 	if !params.DryRun && params.Action != api.ActionDiffSimple && params.Action != api.ActionDiffCanary && params.Action != api.ActionDiffStable {
 
+		// deploy the endpoints service only now the manifests passed the dryrun, so a broken manifest never leaves a new config live without a matching deployment
+		if tmpl != nil {
+			if configID := s.deployGoogleEndpointsServiceIfRequired(ctx, params); configID != "" && params.EspConfigID == "" {
+				// pin esp to the deployed config instead of following managed rollouts
+				log.Info().Msgf("Pinning esp to endpoints config id %v...", configID)
+				params.EspConfigID = configID
+
+				templateData, err = s.generatorService.GenerateTemplateData(params, currentReplicas, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
+				if err != nil {
+					log.Fatal().Err(err).Msg("Failed generating template data")
+				}
+				s.renderAndStoreManifests(tmpl, tmplNoPDB, templateData)
+			}
+		}
+
 		// ensure that from now on any error runs the troubleshooting assistant
 		s.assistTroubleshootingOnError = true
 		s.paramsForTroubleshooting = params
 
 		if tmpl != nil {
-			s.deployGoogleEndpointsServiceIfRequired(ctx, params)
+			s.ensureWorkloadIdentityBindingIfRequired(ctx, credential, params, templateData.Name, templateData.Namespace)
 			s.removePoddisruptionBudgetIfRequired(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 			s.removeIngressIfRequired(ctx, params, templateData, templateData.Name, templateData.Namespace)
+			s.logTrustedIPRangesDiff(ctx, params, templateData, templateData.Name, templateData.Namespace)
 
 			log.Info().Msg("Applying the manifests for real...")
 			foundation.RunCommandWithArgs(ctx, "kubectl", []string{"apply", "-f", "/kubernetes.yaml", "-n", templateData.Namespace})
@@ -188,7 +245,12 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				s.deleteNetworkPolicyForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteRouteIngressesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteGatewayRoutesForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteMeshResourcesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteCertificatesForProviderChange(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.removeNegAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
@@ -196,6 +258,8 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 				break
 			case api.ActionRollbackCanary:
+				s.routeGatewayTrafficToStable(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.routeMeshTrafficToStable(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.scaleCanaryDeployment(ctx, templateData.Name, templateData.Namespace, 0)
 				break
 			case api.ActionRestartCanary:
@@ -213,7 +277,12 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 				s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
 				s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
 				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				s.deleteNetworkPolicyForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteRouteIngressesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteGatewayRoutesForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteMeshResourcesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteCertificatesForProviderChange(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.removeNegAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
@@ -236,6 +305,7 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				s.deleteNetworkPolicyForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.NameWithTrack, templateData.Namespace)
 				break
 			case api.ActionRollbackCanary:
@@ -256,18 +326,60 @@ func (s *service) Run(ctx context.Context, credential *api.GKECredentials, relea
 				s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
 				s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
 				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				s.deleteNetworkPolicyForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
 				s.deleteHorizontalPodAutoscaler(ctx, params, templateData.Name, templateData.Namespace)
 				break
 			}
 			break
 		case api.KindStatefulset:
-			s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-			s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
-			s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
-			s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
-			s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
-			s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
-			s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
+			switch params.Action {
+			case api.ActionDeployCanary:
+				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				break
+			case api.ActionRollbackCanary:
+				err = s.rollbackCanaryStatefulset(ctx, templateData.Name, templateData.Namespace)
+				if err == nil {
+					s.deleteCanaryConfigsAndSecrets(ctx, templateData.Name, templateData.Namespace)
+				}
+				break
+			case api.ActionDeployStable:
+				s.deleteCanaryConfigsAndSecrets(ctx, templateData.Name, templateData.Namespace)
+				s.deleteStatefulsetResourcesForTypeSwitch(ctx, params.Action, templateData.Name, templateData.Namespace)
+				s.deleteConfigsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				s.deleteSecretsForParamsChange(ctx, params, templateData.NameWithTrack, templateData.Namespace)
+				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				s.deleteNetworkPolicyForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteRouteIngressesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteGatewayRoutesForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteMeshResourcesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteCertificatesForProviderChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
+				break
+			case api.ActionDeploySimple:
+				s.deleteStatefulsetResourcesForTypeSwitch(ctx, params.Action, templateData.Name, templateData.Namespace)
+				s.deleteConfigsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+				s.deleteSecretsForParamsChange(ctx, params, templateData.Name, templateData.Namespace)
+				s.deleteServiceAccountSecretForParamsChange(ctx, params, templateData.GoogleCloudCredentialsAppName, templateData.Namespace)
+				s.deleteNetworkPolicyForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteIngressForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteRouteIngressesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteGatewayRoutesForVisibilityChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteMeshResourcesForParamsChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteCertificatesForProviderChange(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.removeEstafetteCloudflareAnnotations(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.removeBackendConfigAnnotation(ctx, templateData, templateData.Name, templateData.Namespace)
+				s.deleteBackendConfigAndIAPOauthSecret(ctx, templateData, templateData.Name, templateData.Namespace)
+				break
+			}
+			break
+
+		case api.KindConfig:
+			s.deleteSharedConfigsForParamsChange(ctx, params, templateData.AppLabelSelector, templateData.Namespace)
+			s.showSharedConfigConsumers(ctx, templateData.SharedConfigName, templateData.Namespace)
 			break
 		}
 
@@ -303,6 +415,60 @@ func (s *service) scaleCanaryDeployment(ctx context.Context, name, namespace str
 	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"scale", "deploy", fmt.Sprintf("%v-canary", name), "-n", namespace, fmt.Sprintf("--replicas=%v", replicas)})
 }
 
+func (s *service) rollbackCanaryStatefulset(ctx context.Context, name, namespace string) (err error) {
+	// the pods above the partition run the canary revision, undoing the rollout moves them back to the previous revision
+	log.Info().Msgf("Rolling back canary pods of statefulset %v to the previous revision...", name)
+	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"rollout", "undo", "statefulset", name, "-n", namespace})
+	err = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"rollout", "status", "statefulset", name, "-n", namespace})
+	if err != nil {
+		// keep the partition, so a failing rollback doesn't get rolled out to the stable pods as well
+		return fmt.Errorf("Failed rolling back canary pods of statefulset %v: %w", name, err)
+	}
+
+	log.Info().Msgf("Removing partition from statefulset %v now that all pods run the same revision...", name)
+	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"patch", "statefulset", name, "-n", namespace, "--type", "json", "--patch", "[{\"op\": \"replace\", \"path\": \"/spec/updateStrategy/rollingUpdate\", \"value\": {\"partition\": 0}}]"})
+
+	return nil
+}
+
+func (s *service) deleteCanaryConfigsAndSecrets(ctx context.Context, name, namespace string) {
+	// only the canary pods of a statefulset mount these, so after a stable release or rollback nothing uses them anymore
+	log.Info().Msgf("Deleting canary configs and secrets of statefulset %v if they exist...", name)
+	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap", fmt.Sprintf("%v-canary-configs", name), "-n", namespace, "--ignore-not-found=true"})
+	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-canary-secrets", name), "-n", namespace, "--ignore-not-found=true"})
+}
+
+func (s *service) deleteStatefulsetResourcesForTypeSwitch(ctx context.Context, action api.ActionType, name, namespace string) {
+	// clean up resources in case a switch from simple to canary releases or vice versa has been made; otherwise two pdbs select the same pods and block evictions
+	log.Info().Msgf("Deleting configmap, secret and pdb of statefulset %v left behind by the other release type...", name)
+	for _, resource := range getStatefulsetResourcesForTypeSwitch(action, name) {
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", resource, "-n", namespace, "--ignore-not-found=true"})
+	}
+}
+
+// getStatefulsetResourcesForTypeSwitch returns the resources of the other release type; the statefulset keeps its name, but its configmap, secret and pdb are named by track
+func getStatefulsetResourcesForTypeSwitch(action api.ActionType, name string) []string {
+	switch action {
+	case api.ActionDeployStable:
+		return []string{
+			fmt.Sprintf("configmap/%v-configs", name),
+			fmt.Sprintf("secret/%v-secrets", name),
+			fmt.Sprintf("pdb/%v", name),
+		}
+	case api.ActionDeploySimple:
+		resources := []string{}
+		for _, track := range []string{"stable", "canary"} {
+			resources = append(resources,
+				fmt.Sprintf("configmap/%v-%v-configs", name, track),
+				fmt.Sprintf("secret/%v-%v-secrets", name, track),
+				fmt.Sprintf("pdb/%v-%v", name, track),
+			)
+		}
+		return resources
+	}
+	return []string{}
+}
+
 func (s *service) restartDeployment(ctx context.Context, name, namespace string) {
 	log.Info().Msgf("Restarting deployment rollout...")
 	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"rollout", "restart", "deployment", name, "-n", namespace})
@@ -320,7 +486,7 @@ func (s *service) deleteResourcesForTypeSwitch(ctx context.Context, name, namesp
 }
 
 func (s *service) deleteConfigsForParamsChange(ctx context.Context, params api.Params, name, namespace string) {
-	if len(params.Configs.Files) == 0 && len(params.Configs.InlineFiles) == 0 {
+	if !params.HasConfigs() {
 		log.Info().Msg("Deleting application configs if it exists, because no configs are specified...")
 		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap", fmt.Sprintf("%v-configs", name), "-n", namespace, "--ignore-not-found=true"})
 	}
@@ -333,8 +499,34 @@ func (s *service) deleteSecretsForParamsChange(ctx context.Context, params api.P
 	}
 }
 
+func (s *service) deleteSharedConfigsForParamsChange(ctx context.Context, params api.Params, appLabelSelector, namespace string) {
+	if !params.HasConfigs() {
+		log.Info().Msg("Deleting shared configs if they exist, because no configs are specified...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap", "-l", fmt.Sprintf("app=%v,type=shared", appLabelSelector), "-n", namespace, "--ignore-not-found=true"})
+	} else {
+		log.Info().Msgf("Deleting shared configs published under a previous sharedconfigname than %v if they exist...", params.SharedConfigName)
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "configmap", "-l", fmt.Sprintf("app=%v,type=shared", appLabelSelector), "--field-selector", fmt.Sprintf("metadata.name!=%v", params.SharedConfigName), "-n", namespace, "--ignore-not-found=true"})
+	}
+	if len(params.Secrets.Keys) == 0 {
+		log.Info().Msg("Deleting shared secrets if they exist, because no secrets are specified...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", "-l", fmt.Sprintf("app=%v,type=shared", appLabelSelector), "-n", namespace, "--ignore-not-found=true"})
+	} else {
+		log.Info().Msgf("Deleting shared secrets published under a previous sharedconfigname than %v if they exist...", params.SharedConfigName)
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", "-l", fmt.Sprintf("app=%v,type=shared", appLabelSelector), "--field-selector", fmt.Sprintf("metadata.name!=%v", params.SharedConfigName), "-n", namespace, "--ignore-not-found=true"})
+	}
+}
+
+func (s *service) showSharedConfigConsumers(ctx context.Context, name, namespace string) {
+	// applications mounting or referencing a shared config or secret are labeled by the generator
+	log.Info().Msgf("Showing applications consuming shared configs and secrets %v...", name)
+	_ = foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"get", "deploy,sts,cronjob,job", "-l", fmt.Sprintf("%v%v=true", api.SharedConfigLabelPrefix, api.SanitizeLabel(name)), "-n", namespace})
+}
+
 func (s *service) deleteServiceAccountSecretForParamsChange(ctx context.Context, params api.Params, name, namespace string) {
-	if !params.UseGoogleCloudCredentials && params.LegacyGoogleCloudServiceAccountKeyFile == "" {
+	if params.UsesWorkloadIdentity() {
+		log.Info().Msg("Deleting service account secret if it exists, because workload identity is used instead...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-gcp-service-account", name), "-n", namespace, "--ignore-not-found=true"})
+	} else if !params.UsesServiceAccountKey() {
 		log.Info().Msg("Deleting service account secret if it exists, because no use of service account is specified...")
 		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-gcp-service-account", name), "-n", namespace, "--ignore-not-found=true"})
 	}
@@ -348,11 +540,124 @@ func (s *service) deleteIngressForVisibilityChange(ctx context.Context, template
 	}
 }
 
+func (s *service) deleteGatewayRoutesForVisibilityChange(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	if !templateData.UseGatewayRoute {
+		log.Info().Msg("Deleting httproutes if they exist, which are used for visibility gateway...")
+		err := foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"delete", "httproute", name, fmt.Sprintf("%v-internal", name), "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			log.Info().Msgf("Deleting httproutes failed, gateway api is probably not installed in the cluster: %v", err)
+		}
+	} else {
+		log.Info().Msg("Deleting internal ingress if it exists, because internal hosts are routed by the gateway...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", fmt.Sprintf("%v-internal", name), "-n", namespace, "--ignore-not-found=true"})
+		if len(templateData.InternalHosts) == 0 {
+			log.Info().Msg("Deleting internal httproute if it exists, because no internal hosts are specified...")
+			foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "httproute", fmt.Sprintf("%v-internal", name), "-n", namespace, "--ignore-not-found=true"})
+		}
+	}
+
+	if !templateData.UseGatewayRoute || !templateData.IncludeTrackLabel {
+		log.Info().Msg("Deleting per track services if they exist, which are used for canary releases with visibility gateway...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "service", fmt.Sprintf("%v-stable", name), fmt.Sprintf("%v-canary", name), "-n", namespace, "--ignore-not-found=true"})
+	}
+}
+
+func (s *service) routeGatewayTrafficToStable(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	if templateData.UseGatewayRoute {
+		// the canary gets scaled to zero, so stop sending its weight of the traffic to it first
+		patchStr := fmt.Sprintf("[{\"op\": \"replace\", \"path\": \"/spec/rules/0/backendRefs\", \"value\": [{\"name\": \"%v-stable\", \"port\": %v, \"weight\": 100}]}]", name, templateData.GatewayBackendPort)
+
+		routes := []string{name}
+		if len(templateData.InternalHosts) > 0 {
+			routes = append(routes, fmt.Sprintf("%v-internal", name))
+		}
+		for _, r := range routes {
+			log.Info().Msgf("Routing all traffic for httproute %v to the stable track...", r)
+			foundation.RunCommandWithArgs(ctx, "kubectl", []string{"patch", "httproute", r, "-n", namespace, "--type", "json", "--patch", patchStr})
+		}
+	}
+}
+
+func (s *service) deleteNetworkPolicyForParamsChange(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	if !templateData.UseNetworkPolicy {
+		log.Info().Msg("Deleting network policy if it exists, because network policy isn't enabled...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "networkpolicy", name, "-n", namespace, "--ignore-not-found=true"})
+	}
+}
+
+func (s *service) deleteMeshResourcesForParamsChange(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	if !templateData.UseIstio {
+		log.Info().Msg("Deleting virtualservice and destinationrule if they exist, because no istio sidecar is specified...")
+		err := foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"delete", "virtualservice,destinationrule", name, "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			log.Info().Msgf("Deleting virtualservice and destinationrule failed, istio is probably not installed in the cluster: %v", err)
+		}
+	}
+}
+
+func (s *service) deleteRouteIngressesForParamsChange(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	// route ingresses carry the estafette.io/route label, so the ones for routes that have been removed can be found
+	routeNames, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "ing", "-l", fmt.Sprintf("app=%v,estafette.io/route", templateData.AppLabelSelector), "-n", namespace, "-o=jsonpath={.items[*].metadata.labels.estafette\\.io/route}"})
+	if err != nil {
+		log.Info().Msgf("Failed retrieving route ingresses for app %v: %v", templateData.AppLabelSelector, err)
+		return
+	}
+
+	for _, routeName := range strings.Fields(routeNames) {
+		routeExists := false
+		for _, route := range templateData.Routes {
+			if route.Name == routeName {
+				routeExists = true
+			}
+		}
+		if !routeExists {
+			log.Info().Msgf("Deleting ingress for route %v, because the route no longer exists...", routeName)
+			foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", fmt.Sprintf("%v-%v", name, routeName), "-n", namespace, "--ignore-not-found=true"})
+		}
+	}
+}
+
+func (s *service) deleteCertificatesForProviderChange(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	if !templateData.UseCertManagerCertificate {
+		log.Info().Msg("Deleting cert-manager certificate and its secret if they exist, because certificate provider isn't cert-manager...")
+		err := foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"delete", "certificates.cert-manager.io", name, "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			log.Info().Msgf("Deleting cert-manager certificate failed, cert-manager is probably not installed in the cluster: %v", err)
+		}
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-cert-manager-certificate", name), "-n", namespace, "--ignore-not-found=true"})
+	}
+
+	if templateData.UseCertManagerCertificate || templateData.UseManagedCertificate {
+		// the pods and ingresses have moved to the cert-manager secret or the load balancer to the managed certificate, so the estafette-letsencrypt-certificate controller no longer needs to renew this one
+		log.Info().Msg("Deleting letsencrypt certificate secret if it exists, because certificate provider isn't letsencrypt-annotation...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-letsencrypt-certificate", name), "-n", namespace, "--ignore-not-found=true"})
+	}
+
+	if !templateData.UseManagedCertificate {
+		log.Info().Msg("Deleting managed certificate if it exists, because certificate provider isn't gke-managed...")
+		err := foundation.RunCommandWithArgsExtended(ctx, "kubectl", []string{"delete", "managedcertificates.networking.gke.io", name, "-n", namespace, "--ignore-not-found=true"})
+		if err != nil {
+			log.Info().Msgf("Deleting managed certificate failed, the cluster probably doesn't support managed certificates: %v", err)
+		}
+	}
+}
+
+func (s *service) routeMeshTrafficToStable(ctx context.Context, templateData api.TemplateData, name, namespace string) {
+	if templateData.UseIstio {
+		// the canary gets scaled to zero, so stop sending its weight of the traffic to it first
+		log.Info().Msgf("Routing all traffic for virtualservice %v to the stable subset...", name)
+		patchStr := fmt.Sprintf("[{\"op\": \"replace\", \"path\": \"/spec/http/0/route\", \"value\": [{\"destination\": {\"host\": \"%v\", \"subset\": \"stable\"}, \"weight\": 100}]}]", name)
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"patch", "virtualservice", name, "-n", namespace, "--type", "json", "--patch", patchStr})
+	}
+}
+
 func (s *service) deleteBackendConfigAndIAPOauthSecret(ctx context.Context, templateData api.TemplateData, name, namespace string) {
-	if !templateData.UseBackendConfigAnnotationOnService {
+	if !templateData.UseIAP {
 		log.Info().Msg("Deleting iap oauth secret if it exists, because visibility is not set to iap...")
-		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v--iap-oauth-credentials", name), "-n", namespace, "--ignore-not-found=true"})
-		log.Info().Msg("Deleting iap backend config if it exists, because visibility is not set to iap...")
+		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "secret", fmt.Sprintf("%v-iap-oauth-credentials", name), "-n", namespace, "--ignore-not-found=true"})
+	}
+	if !templateData.UseBackendConfigAnnotationOnService {
+		log.Info().Msg("Deleting backend config if it exists, because the gce ingress controller isn't used...")
 		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "backendconfig", name, "-n", namespace, "--ignore-not-found=true"})
 	}
 }
@@ -388,45 +693,220 @@ func (s *service) removePoddisruptionBudgetIfRequired(ctx context.Context, param
 
 func (s *service) removeIngressIfRequired(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
 	if params.Kind == api.KindDeployment && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployCanary || params.Action == api.ActionDeployStable) {
-		if templateData.UseNginxIngress {
-			// check if ingress exists and has kubernetes.io/ingress.class: gce, then delete it because of https://github.com/kubernetes/ingress-gce/issues/481
-			ingressClass, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{index .metadata.annotations \"kubernetes.io/ingress.class\"}}"})
-			if err == nil {
-				if ingressClass == "gce" {
-					// delete the ingress so all related load balancers, etc get deleted
-					log.Info().Msg("Deleting ingress so the gce ingress controller removes the related load balancer...")
-					foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
-				} else {
-					log.Info().Msgf("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
-				}
-			} else {
-				log.Info().Msgf("Ingress %v or kubernetes.io/ingress.class annotation doesn't exist, no need to delete the ingress: %v", name, err)
-			}
-		} else if templateData.UseGCEIngress {
-			// check if ingress exists and has kubernetes.io/ingress.class: gce, then delete it to ensure there's no nginx ingress annotations lingering around
-			ingressClass, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{index .metadata.annotations \"kubernetes.io/ingress.class\"}}"})
-			if err == nil {
-				if ingressClass == "nginx" {
-					// delete the ingress so all related nginx ingress config gets deleted
-					log.Info().Msg("Deleting ingress so the nginx ingress controller removes related config...")
-					foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
-				} else {
-					log.Info().Msgf("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
-				}
-			} else {
-				log.Info().Msgf("Ingress %v or kubernetes.io/ingress.class annotation doesn't exist, no need to delete the ingress: %v", name, err)
-			}
+		if templateData.UseNginxIngress || templateData.UseGCEIngress {
+			s.removeIngressForControllerSwitch(ctx, params, templateData.IngressController, name, namespace)
+		}
+		if len(templateData.InternalHosts) > 0 {
+			s.removeIngressForControllerSwitch(ctx, params, templateData.InternalIngressController, name+"-internal", namespace)
+		}
+		if params.Visibility == api.VisibilityApigee {
+			s.removeIngressForControllerSwitch(ctx, params, templateData.ApigeeIngressController, name+"-apigee", namespace)
+		}
+	}
+}
+
+// removeIngressForControllerSwitch deletes an existing ingress when it moves to or from a controller that needs to recreate it, so the old controller removes its load balancer or config, see https://github.com/kubernetes/ingress-gce/issues/481
+func (s *service) removeIngressForControllerSwitch(ctx context.Context, params api.Params, ingressController api.IngressControllerData, name, namespace string) {
+	ingressClass, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "ing", name, "-n", namespace, "-o=go-template={{index .metadata.annotations \"kubernetes.io/ingress.class\"}}"})
+	if err != nil {
+		log.Info().Msgf("Ingress %v or kubernetes.io/ingress.class annotation doesn't exist, no need to delete the ingress: %v", name, err)
+		return
+	}
+	if ingressClass == ingressController.Class {
+		log.Info().Msgf("Ingress %v already has kubernetes.io/ingress.class: %v annotation, no need to delete the ingress", name, ingressClass)
+		return
+	}
+
+	currentIngressController, _ := params.GetIngressControllerByClass(ingressClass)
+	if !currentIngressController.HasFeature(api.IngressControllerFeatureRecreateOnSwitch) && !ingressController.Features[api.IngressControllerFeatureRecreateOnSwitch] {
+		log.Info().Msgf("Ingress %v switches from kubernetes.io/ingress.class: %v to %v, no need to delete the ingress", name, ingressClass, ingressController.Class)
+		return
+	}
+
+	// delete the ingress so all related load balancers and config get deleted
+	log.Info().Msgf("Deleting ingress %v so the %v ingress controller removes related load balancers and config...", name, ingressClass)
+	foundation.RunCommandWithArgs(ctx, "kubectl", []string{"delete", "ingress", name, "-n", namespace, "--ignore-not-found=true"})
+}
+
+// logTrustedIPRangesDiff logs which trusted ip ranges get added to or removed from the service's loadBalancerSourceRanges, since refreshed provider lists can change them without any change to the manifest
+func (s *service) logTrustedIPRangesDiff(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
+	if !templateData.LimitTrustedIPRanges {
+		return
+	}
+
+	output, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"get", "service", name, "-n", namespace, "-o=jsonpath={.spec.loadBalancerSourceRanges[*]}"})
+	if err != nil {
+		log.Info().Msgf("Service %v doesn't exist, no trusted ip ranges to compare: %v", name, err)
+		return
+	}
+
+	currentRanges := map[string]bool{}
+	for _, r := range strings.Fields(output) {
+		currentRanges[r] = true
+	}
+	desiredRanges := map[string]bool{}
+	for _, r := range templateData.TrustedIPRanges {
+		desiredRanges[r] = true
+		if !currentRanges[r] {
+			log.Info().Msgf("Adding trusted ip range %v to service %v", r, name)
+		}
+	}
+	for _, r := range strings.Fields(output) {
+		if !desiredRanges[r] {
+			log.Info().Msgf("Removing trusted ip range %v from service %v", r, name)
+		}
+	}
+}
+
+// checkImagesIfRequired fails if the container image doesn't exist and pins it, and optionally the init container images, to their digest
+func (s *service) checkImagesIfRequired(ctx context.Context, params *api.Params) {
+	verify := params.Container.ImageCheck.Verify != nil && *params.Container.ImageCheck.Verify
+	pin := params.Container.ImageCheck.Pin != nil && *params.Container.ImageCheck.Pin
+	if !verify && !pin {
+		return
+	}
+	if params.Kind != api.KindDeployment && params.Kind != api.KindHeadlessDeployment && params.Kind != api.KindStatefulset && params.Kind != api.KindJob && params.Kind != api.KindCronJob {
+		return
+	}
+
+	image := fmt.Sprintf("%v/%v:%v", params.Container.ImageRepository, params.Container.ImageName, params.Container.ImageTag)
+	if digest, ok := s.getImageDigest(ctx, image, *params, pin); ok && pin {
+		params.Container.ImageDigest = digest
+	}
+
+	if params.Container.ImageCheck.InitContainers == nil || !*params.Container.ImageCheck.InitContainers {
+		return
+	}
+	for _, initContainer := range params.InitContainers {
+		if initContainer == nil {
+			continue
+		}
+		initContainerImage, ok := (*initContainer)["image"].(string)
+		if !ok || strings.Contains(initContainerImage, "@") {
+			continue
+		}
+		if digest, ok := s.getImageDigest(ctx, initContainerImage, *params, pin); ok && pin {
+			(*initContainer)["image"] = fmt.Sprintf("%v@%v", initContainerImage, digest)
+		}
+	}
+}
+
+// getImageDigest fails if the image doesn't exist or can't be pinned, but only warns if the registry can't be reached to verify it
+func (s *service) getImageDigest(ctx context.Context, image string, params api.Params, pin bool) (digest string, ok bool) {
+	log.Info().Msgf("Checking if image %v exists...", image)
+
+	credentials, authenticated := getImageCheckCredentials(image, params)
+
+	digest, err := s.registryClient.GetDigest(ctx, image, credentials)
+	if errors.Is(err, registry.ErrManifestNotFound) && authenticated {
+		log.Fatal().Err(err).Msgf("Image %v does not exist; check its repository, name and tag", image)
+	}
+	if err != nil && pin {
+		log.Fatal().Err(err).Msgf("Failed resolving digest of image %v to pin it", image)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed checking if image %v exists, deploying it unverified", image)
+		return "", false
+	}
+
+	log.Info().Msgf("Image %v exists with digest %v", image, digest)
+
+	return digest, true
+}
+
+// getImageCheckCredentials returns the credentials for looking up an image and whether the lookup is authenticated; private registries can answer an anonymous lookup with not found
+func getImageCheckCredentials(image string, params api.Params) (credentials *registry.Credentials, authenticated bool) {
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return nil, false
+	}
+
+	// google hosted registries use the service account of the credential in the registry client
+	if ref.IsGoogleRegistry() {
+		return nil, true
+	}
+
+	// the image pull secret is only used for docker hub, like in the rendered docker config
+	if ref.Registry == "registry-1.docker.io" && params.ImagePullSecretUser != "" && params.ImagePullSecretPassword != "" {
+		return &registry.Credentials{Username: params.ImagePullSecretUser, Password: params.ImagePullSecretPassword}, true
+	}
+
+	return nil, false
+}
+
+// ensureWorkloadIdentityBindingIfRequired allows the kubernetes service account to act as the google service account, using the workload pool of the cluster
+func (s *service) ensureWorkloadIdentityBindingIfRequired(ctx context.Context, credential *api.GKECredentials, params api.Params, name, namespace string) {
+	if !params.UsesWorkloadIdentity() {
+		return
+	}
+
+	cluster, err := s.gcpClient.GetGKECluster(ctx, credential.AdditionalProperties.Project, credential.GetLocation(), credential.AdditionalProperties.Cluster)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed retrieving cluster %v to get its workload pool", credential.AdditionalProperties.Cluster)
+	}
+	if cluster.WorkloadIdentityConfig == nil || cluster.WorkloadIdentityConfig.WorkloadPool == "" {
+		log.Fatal().Msgf("Workload identity is not enabled on cluster %v; enable it or set workloadIdentity.enabled: false on this stage", credential.AdditionalProperties.Cluster)
+	}
+
+	err = s.gcpClient.EnsureWorkloadIdentityBinding(ctx, params.WorkloadIdentity.GCPServiceAccount, cluster.WorkloadIdentityConfig.WorkloadPool, namespace, name)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed ensuring workload identity binding on service account %v", params.WorkloadIdentity.GCPServiceAccount)
+	}
+}
+
+// pinGoogleEndpointsConfigForStableIfRequired pins esp of a stable release to the config of the active rollout without submitting a new one
+func (s *service) pinGoogleEndpointsConfigForStableIfRequired(ctx context.Context, params *api.Params) {
+	if params.Kind == api.KindDeployment && (params.Visibility == api.VisibilityESP || params.Visibility == api.VisibilityESPv2) && (params.Action == api.ActionDeployStable || params.Action == api.ActionDiffStable) && params.EspConfigID == "" {
+		configID, err := s.gcpClient.GetActiveGoogleCloudEndpointsConfigID(ctx, *params)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed retrieving active endpoints config in project %v", params.EspEndpointsProjectID)
+		}
+		if configID != "" {
+			log.Info().Msgf("Pinning esp to active endpoints config id %v...", configID)
+			params.EspConfigID = configID
 		}
 	}
 }
 
-func (s *service) deployGoogleEndpointsServiceIfRequired(ctx context.Context, params api.Params) {
+// renderAndStoreManifests renders the templates with and without poddisruptionbudget and stores them on disk for kubectl
+func (s *service) renderAndStoreManifests(tmpl, tmplNoPDB *template.Template, templateData api.TemplateData) {
+	renderedTemplate, err := s.builderService.RenderTemplate(tmpl, templateData, true)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed rendering templates")
+	}
+	renderedNoPDBTemplate, err := s.builderService.RenderTemplate(tmplNoPDB, templateData, false)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed rendering templates without poddisruptionbudget")
+	}
+
+	if tmpl != nil {
+		log.Info().Msg("Storing rendered manifest on disk...")
+		err = ioutil.WriteFile("/kubernetes.yaml", renderedTemplate.Bytes(), 0600)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed writing manifest")
+		}
+	}
+
+	if tmplNoPDB != nil {
+		log.Info().Msg("Storing rendered manifest without poddisruptionbudget on disk...")
+		err = ioutil.WriteFile("/kubernetes-no-pdb.yaml", renderedNoPDBTemplate.Bytes(), 0600)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed writing manifest without poddisruptionbudget")
+		}
+	}
+}
+
+// deployGoogleEndpointsServiceIfRequired returns the id of the active endpoints config, so esp can be pinned to it
+func (s *service) deployGoogleEndpointsServiceIfRequired(ctx context.Context, params api.Params) (configID string) {
 	if params.Kind == api.KindDeployment && (params.Visibility == api.VisibilityESP || params.Visibility == api.VisibilityESPv2) && (params.Action == api.ActionDeploySimple || params.Action == api.ActionDeployCanary) {
-		err := s.gcpClient.DeployGoogleCloudEndpoints(ctx, params)
+		var err error
+		configID, err = s.gcpClient.DeployGoogleCloudEndpoints(ctx, params)
 		if err != nil {
 			log.Fatal().Err(err).Msgf("Failed deploying endpoints service in project %v", params.EspEndpointsProjectID)
 		}
+		return
 	}
+	return ""
 }
 
 func (s *service) failIfCreatingNewPublicService(ctx context.Context, params api.Params, templateData api.TemplateData, name, namespace string) {
@@ -488,6 +968,45 @@ func (s *service) cleanupJobIfRequired(ctx context.Context, params api.Params, t
 	}
 }
 
+func (s *service) getServedAPIVersions(ctx context.Context) []string {
+	output, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"api-versions"})
+	if err != nil {
+		log.Info().Msgf("Failed retrieving served api versions: %v; rendering resources with newest api versions...", err)
+		return []string{}
+	}
+
+	servedAPIVersions := []string{}
+	for _, v := range strings.Split(output, "\n") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			servedAPIVersions = append(servedAPIVersions, v)
+		}
+	}
+
+	return servedAPIVersions
+}
+
+func (s *service) getKubernetesVersion(ctx context.Context) string {
+	output, err := foundation.GetCommandWithArgsOutput(ctx, "kubectl", []string{"version", "-o", "json"})
+	if err != nil {
+		log.Info().Msgf("Failed retrieving kubernetes version: %v; rendering sidecars without native sidecar support...", err)
+		return ""
+	}
+
+	var version struct {
+		ServerVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	err = json.Unmarshal([]byte(output), &version)
+	if err != nil {
+		log.Info().Msgf("Failed unmarshalling kubernetes version: %v; rendering sidecars without native sidecar support...", err)
+		return ""
+	}
+
+	return version.ServerVersion.GitVersion
+}
+
 func (s *service) getExistingNumberOfReplicas(ctx context.Context, params api.Params) int {
 	if params.Kind == api.KindDeployment || params.Kind == api.KindHeadlessDeployment {
 		if params.StrategyType == api.StrategyTypeAtomicUpdate {
@@ -563,8 +1082,8 @@ func (s *service) removeEstafetteCloudflareAnnotations(ctx context.Context, temp
 
 func (s *service) removeBackendConfigAnnotation(ctx context.Context, templateData api.TemplateData, name, namespace string) {
 	if !templateData.UseBackendConfigAnnotationOnService {
-		// iap is not used, so the beta.cloud.google.com/backend-config annotations should be removed from the service
-		log.Info().Msg("Removing beta.cloud.google.com/backend-config annotations on the service if they exists, since visibility is not set to iap...")
+		// the gce ingress controller is not used, so the beta.cloud.google.com/backend-config annotations should be removed from the service
+		log.Info().Msg("Removing beta.cloud.google.com/backend-config annotations on the service if they exists, since the gce ingress controller isn't used...")
 		foundation.RunCommandWithArgs(ctx, "kubectl", []string{"annotate", "svc", name, "-n", namespace, "beta.cloud.google.com/backend-config-"})
 	}
 }
@@ -0,0 +1,86 @@
+package extension
+
+import (
+	"testing"
+
+	"github.com/estafette/estafette-extension-gke/api"
+	"github.com/estafette/estafette-extension-gke/clients/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStatefulsetResourcesForTypeSwitch(t *testing.T) {
+	t.Run("ReturnsUntrackedConfigsSecretsAndPodDisruptionBudgetOnSwitchFromSimpleToStable", func(t *testing.T) {
+
+		// act
+		resources := getStatefulsetResourcesForTypeSwitch(api.ActionDeployStable, "myapp")
+
+		assert.Equal(t, []string{"configmap/myapp-configs", "secret/myapp-secrets", "pdb/myapp"}, resources)
+	})
+
+	t.Run("ReturnsTrackedConfigsSecretsAndPodDisruptionBudgetsOnSwitchFromStableToSimple", func(t *testing.T) {
+
+		// act
+		resources := getStatefulsetResourcesForTypeSwitch(api.ActionDeploySimple, "myapp")
+
+		assert.Equal(t, []string{
+			"configmap/myapp-stable-configs",
+			"secret/myapp-stable-secrets",
+			"pdb/myapp-stable",
+			"configmap/myapp-canary-configs",
+			"secret/myapp-canary-secrets",
+			"pdb/myapp-canary",
+		}, resources)
+	})
+
+	t.Run("ReturnsNothingForCanary", func(t *testing.T) {
+
+		// act
+		resources := getStatefulsetResourcesForTypeSwitch(api.ActionDeployCanary, "myapp")
+
+		assert.Equal(t, 0, len(resources))
+	})
+}
+
+func TestGetImageCheckCredentials(t *testing.T) {
+	t.Run("ReturnsImagePullSecretForDockerHub", func(t *testing.T) {
+
+		params := api.Params{ImagePullSecretUser: "user", ImagePullSecretPassword: "password"}
+
+		// act
+		credentials, authenticated := getImageCheckCredentials("estafette/myapp:1.0.0", params)
+
+		assert.True(t, authenticated)
+		assert.Equal(t, &registry.Credentials{Username: "user", Password: "password"}, credentials)
+	})
+
+	t.Run("ReturnsAuthenticatedWithoutCredentialsForGoogleRegistry", func(t *testing.T) {
+
+		params := api.Params{ImagePullSecretUser: "user", ImagePullSecretPassword: "password"}
+
+		// act
+		credentials, authenticated := getImageCheckCredentials("eu.gcr.io/myproject/myapp:1.0.0", params)
+
+		assert.True(t, authenticated)
+		assert.Nil(t, credentials)
+	})
+
+	t.Run("ReturnsUnauthenticatedForOtherRegistries", func(t *testing.T) {
+
+		params := api.Params{ImagePullSecretUser: "user", ImagePullSecretPassword: "password"}
+
+		// act
+		credentials, authenticated := getImageCheckCredentials("registry.example.com/myapp:1.0.0", params)
+
+		assert.False(t, authenticated)
+		assert.Nil(t, credentials)
+	})
+
+	t.Run("ReturnsUnauthenticatedForDockerHubWithoutImagePullSecret", func(t *testing.T) {
+
+		// act
+		credentials, authenticated := getImageCheckCredentials("estafette/myapp:1.0.0", api.Params{})
+
+		assert.False(t, authenticated)
+		assert.Nil(t, credentials)
+	})
+}
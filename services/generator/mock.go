@@ -34,11 +34,12 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // GenerateTemplateData mocks base method
-func (m *MockService) GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) api.TemplateData {
+func (m *MockService) GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) (api.TemplateData, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GenerateTemplateData", params, currentReplicas, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy)
 	ret0, _ := ret[0].(api.TemplateData)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GenerateTemplateData indicates an expected call of GenerateTemplateData
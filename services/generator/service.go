@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -15,7 +16,7 @@ import (
 
 //go:generate mockgen -package=generator -destination ./mock.go -source=service.go
 type Service interface {
-	GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) api.TemplateData
+	GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) (api.TemplateData, error)
 	BuildSidecar(sidecar *api.SidecarParams, params api.Params) api.SidecarData
 	AddEnvironmentVariableIfNotSet(environmentVariables map[string]interface{}, name, value string) map[string]interface{}
 	IsSimpleEnvvarValue(i interface{}) bool
@@ -31,7 +32,7 @@ func NewService(ctx context.Context) (Service, error) {
 type service struct {
 }
 
-func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) api.TemplateData {
+func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, gitSource, gitOwner, gitName, gitBranch, gitRevision, releaseID, triggeredBy string) (api.TemplateData, error) {
 
 	data := api.TemplateData{
 		Name:                    params.App,
@@ -75,7 +76,7 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		MountSslCertificate:     params.Kind == api.KindDeployment,
 		MountApplicationSecrets: params.HasSecrets(),
 		SecretMountPath:         params.Secrets.MountPath,
-		MountConfigmap:          len(params.Configs.Files) > 0 || len(params.Configs.InlineFiles) > 0,
+		MountConfigmap:          params.HasConfigs(),
 		ConfigMountPath:         params.Configs.MountPath,
 		Tolerations:             []*map[string]interface{}{},
 
@@ -87,8 +88,10 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 
 		PreferPreemptibles:            params.ChaosProof,
 		UseWindowsNodes:               params.OperatingSystem == api.OperatingSystemWindows,
-		MountServiceAccountSecret:     params.UseGoogleCloudCredentials || params.LegacyGoogleCloudServiceAccountKeyFile != "",
+		MountServiceAccountSecret:     params.UsesServiceAccountKey(),
 		UseLegacyServiceAccountKey:    params.LegacyGoogleCloudServiceAccountKeyFile != "",
+		UseWorkloadIdentity:           params.UsesWorkloadIdentity(),
+		WorkloadIdentityGCPAccount:    params.WorkloadIdentity.GCPServiceAccount,
 		GoogleCloudCredentialsAppName: params.GoogleCloudCredentialsApp,
 		GoogleCloudCredentialsLabels:  api.SanitizeLabels(params.Labels),
 
@@ -101,6 +104,7 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 			Repository:      params.Container.ImageRepository,
 			Name:            params.Container.ImageName,
 			Tag:             params.Container.ImageTag,
+			Digest:          params.Container.ImageDigest,
 			ImagePullPolicy: params.Container.ImagePullPolicy,
 			Port:            params.Container.Port,
 
@@ -158,6 +162,15 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		}
 	}
 
+	// record which secret manager versions the gcpsm:// references resolved to, without their values
+	if len(params.SecretManagerVersions) > 0 {
+		secretManagerVersions, err := json.Marshal(params.SecretManagerVersions)
+		if err == nil {
+			data.HasSecretManagerVersions = true
+			data.SecretManagerVersions = string(secretManagerVersions)
+		}
+	}
+
 	if params.BackoffLimit != nil {
 		data.BackoffLimit = *params.BackoffLimit
 	}
@@ -180,21 +193,25 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.Labels["app"] = data.AppLabelSelector
 	}
 
-	// set tracing service name
-	data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "JAEGER_SERVICE_NAME", params.App)
-
-	if params.Action == api.ActionDeployCanary || params.Action == api.ActionDiffCanary {
-		data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "JAEGER_SAMPLER_TYPE", "probabilistic")
-		data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "JAEGER_SAMPLER_PARAM", "0.1")
-		data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "JAEGER_TAGS", "track=canary")
-	} else {
-		data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "JAEGER_SAMPLER_TYPE", "remote")
-		data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "JAEGER_SAMPLER_PARAM", "0.001")
-	}
+	// set tracing env for the configured provider
+	data.UseJaegerTracing = params.Tracing.Provider == api.TracingProviderJaeger || params.Tracing.Provider == api.TracingProviderUnknown
+	data.UseOpenTelemetryNodeIP = params.Tracing.Provider == api.TracingProviderOpenTelemetry && params.Tracing.Endpoint == ""
+	data.Container.EnvironmentVariables = s.addTracingEnvironmentVariables(data.Container.EnvironmentVariables, params, releaseID)
 
 	data.HasOpenrestySidecar = false
+	data.UseNativeSidecars = params.UsesNativeSidecars()
 	for _, sidecarParams := range params.Sidecars {
 		sidecar := s.BuildSidecar(sidecarParams, params)
+		if sidecar.Type == string(api.SidecarTypeIstio) {
+			// the istio proxy gets injected by the mesh, its resources are set through annotations
+			data.IstioProxy = sidecar
+			continue
+		}
+		if (params.Kind == api.KindJob || params.Kind == api.KindCronJob) && !data.UseNativeSidecars && sidecarParams.Type == api.SidecarTypeCloudSQLProxy && sidecarParams.SQLProxyVersion == api.CloudSQLProxyVersionV2 {
+			// without native sidecars the proxy keeps the job running, unless the job container tells it to quit when done
+			sidecar.SidecarSpecificProperties["sqlproxyadminport"] = 9091
+			data.Container.EnvironmentVariables = s.AddEnvironmentVariableIfNotSet(data.Container.EnvironmentVariables, "CLOUD_SQL_PROXY_QUITQUITQUIT_URL", "http://127.0.0.1:9091/quitquitquit")
+		}
 		data.Sidecars = append(data.Sidecars, sidecar)
 		if sidecar.Type == string(api.SidecarTypeOpenresty) {
 			data.HasOpenrestySidecar = true
@@ -211,6 +228,23 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	if (params.Visibility == api.VisibilityESP || params.Visibility == api.VisibilityESPv2) && len(params.Hosts) > 0 {
 		data.EspService = params.Hosts[0]
 	}
+	// a grpc service config names the endpoints service itself, which doesn't have to match the first host
+	if params.UsesEspGRPC() {
+		espService, err := params.GetEspGRPCServiceName()
+		if err != nil {
+			return data, err
+		}
+		data.EspService = espService
+	}
+	// esp forwards to openresty, unless it transcodes to a grpc backend which it then calls directly
+	data.EspBackend = "http://127.0.0.1:80"
+	if params.UsesEspGRPC() {
+		scheme := "grpc"
+		if params.Container.Protocol.UsesTLS() {
+			scheme = "grpcs"
+		}
+		data.EspBackend = fmt.Sprintf("%v://127.0.0.1:%v", scheme, params.Container.Port)
+	}
 
 	if data.PreferPreemptibles {
 		data.HasTolerations = true
@@ -244,27 +278,24 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	data.Container.Readiness.IncludeOnContainer = params.Container.ReadinessProbe.Enabled != nil && *params.Container.ReadinessProbe.Enabled && (!data.HasOpenrestySidecar || params.Container.ReadinessProbe.Port != params.Container.Port || params.Container.ReadinessProbe.Path != params.Sidecar.HealthCheckPath)
 
 	// if container port is set to 443, we always use https named port
-	data.UseHTTPS = data.HasOpenrestySidecar || params.Container.Port == 443
+	data.UseHTTPS = data.HasOpenrestySidecar || params.Container.Port == 443 || params.Container.Protocol.UsesTLS()
+
+	// set the protocol the ingresses, load balancers and probes use to reach the container
+	data.Container.Protocol = string(params.Container.Protocol)
+	// with esp or openresty in front the service exposes the https port of the proxy instead of the web port of the container
+	servicePortName := "web"
+	if data.HasOpenrestySidecar || data.UseESP {
+		servicePortName = "https"
+	}
+	data.NginxIngressBackendProtocol, data.NginxIngressApigeeBackendProtocol, data.ServiceAppProtocols, data.ServiceAppProtocol = s.getBackendProtocols(params.Container.Protocol, data.UseHTTPS, servicePortName)
+	data.Container.Liveness.Type, data.Container.Liveness.Scheme = s.getProbeTypeAndScheme(params.Container.Protocol)
+	data.Container.Readiness.Type, data.Container.Readiness.Scheme = s.getProbeTypeAndScheme(params.Container.Protocol)
 
 	// set request params on the nginx ingress
 	requestTimeout, requestTimeoutConvertError := strconv.Atoi(strings.Trim(params.Request.Timeout, "s"))
 	data.EspRequestTimeout = requestTimeout
 
-	data.NginxIngressProxyConnectTimeout = requestTimeout
-	if requestTimeoutConvertError != nil {
-		data.NginxIngressProxyConnectTimeout = 60
-	}
-	if data.NginxIngressProxyConnectTimeout > 75 {
-		data.NginxIngressProxyConnectTimeout = 75
-	}
-	data.NginxIngressProxySendTimeout = requestTimeout
-	if requestTimeoutConvertError != nil {
-		data.NginxIngressProxySendTimeout = 60
-	}
-	data.NginxIngressProxyReadTimeout = requestTimeout
-	if requestTimeoutConvertError != nil {
-		data.NginxIngressProxyReadTimeout = 60
-	}
+	data.NginxIngressProxyConnectTimeout, data.NginxIngressProxySendTimeout, data.NginxIngressProxyReadTimeout = s.getNginxIngressProxyTimeouts(params.Request.Timeout)
 	data.NginxIngressProxyBodySize = params.Request.MaxBodySize
 	data.NginxIngressClientBodyBufferSize = params.Request.ClientBodyBufferSize
 	data.NginxIngressProxyBufferSize = params.Request.ProxyBufferSize
@@ -336,6 +367,23 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.TrackLabel = "stable"
 	}
 
+	// statefulsets don't get a separate resource per track; instead a canary only updates the pods with the highest ordinals
+	if params.Kind == api.KindStatefulset {
+		switch params.Action {
+		case api.ActionDeployCanary,
+			api.ActionDiffCanary:
+			data.UsePartition = true
+			data.Partition = data.Replicas - params.CanaryReplicas
+			if data.Partition < 0 {
+				data.Partition = 0
+			}
+		case api.ActionDeployStable,
+			api.ActionDiffStable:
+			data.UsePartition = true
+			data.Partition = 0
+		}
+	}
+
 	switch params.StrategyType {
 	case api.StrategyTypeRollingUpdate:
 		data.StrategyType = string(params.StrategyType)
@@ -361,6 +409,14 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	data.PodLabels["app.kubernetes.io/managed-by"] = "estafette"
 
 	data.ConfigmapFiles = params.Configs.RenderedFileContent
+	data.SharedConfigName = params.SharedConfigName
+
+	// label consumers of configmaps and secrets published by kind config, so a release of those can show which applications use them
+	if params.Kind != api.KindConfig && params.Kind != api.KindConfigToFile {
+		for _, name := range params.GetSharedConfigReferences() {
+			data.Labels[api.SharedConfigLabelPrefix+api.SanitizeLabel(name)] = "true"
+		}
+	}
 
 	data.ManifestData = map[string]interface{}{}
 	for k, v := range params.Manifests.Data {
@@ -425,6 +481,25 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		}
 		data.ApigeeHostsJoined = strings.Join(data.ApigeeHosts, ",")
 
+	case api.VisibilityGateway:
+		data.ServiceType = "ClusterIP"
+		data.UseNginxIngress = false
+		data.UseGCEIngress = false
+		data.UseGatewayRoute = true
+		data.UseDNSAnnotationsOnIngress = false
+		data.UseDNSAnnotationsOnService = false
+		data.UseCloudflareProxy = false
+		data.UseBackendConfigAnnotationOnService = false
+		data.UseNegAnnotationOnService = false
+		data.LimitTrustedIPRanges = false
+		data.OverrideDefaultWhitelist = false
+		data.GatewayName = params.Gateway.Name
+		data.GatewayNamespace = params.Gateway.Namespace
+		data.GatewaySectionName = params.Gateway.SectionName
+		data.InternalGatewayName = params.Gateway.InternalName
+		data.InternalGatewayNamespace = params.Gateway.InternalNamespace
+		data.InternalGatewaySectionName = params.Gateway.InternalSectionName
+
 	case api.VisibilityESP,
 		api.VisibilityESPv2:
 		data.ServiceType = "LoadBalancer"
@@ -447,6 +522,47 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		data.OverrideDefaultWhitelist = false
 	}
 
+	// visibility private and public-whitelist use the gce ingress controller if its profile configures the load balancer from a backendconfig
+	if params.UsesGCEIngress() && !data.UseGCEIngress {
+		data.ServiceType = "NodePort"
+		data.UseNginxIngress = false
+		data.UseGCEIngress = true
+		data.UseBackendConfigAnnotationOnService = true
+		data.UseNegAnnotationOnService = params.ContainerNativeLoadBalancing
+		data.OverrideDefaultWhitelist = false
+	}
+	data.UseIAP = params.Visibility == api.VisibilityIAP
+
+	if data.UseGCEIngress {
+		data.BackendConfigSecurityPolicy = params.BackendConfig.SecurityPolicy
+		data.UseBackendConfigCDN = params.BackendConfig.CDN.Enabled != nil && *params.BackendConfig.CDN.Enabled
+		data.BackendConfigCDNCacheMode = params.BackendConfig.CDN.CacheMode
+		data.UseBackendConfigCDNTTLs = params.UsesCDNTTLs()
+		data.BackendConfigCDNDefaultTTL = params.BackendConfig.CDN.DefaultTTL
+		data.BackendConfigCDNMaxTTL = params.BackendConfig.CDN.MaxTTL
+		data.BackendConfigCDNClientTTL = params.BackendConfig.CDN.ClientTTL
+		data.BackendConfigCDNIncludeQueryString = params.BackendConfig.CDN.IncludeQueryString != nil && *params.BackendConfig.CDN.IncludeQueryString
+		data.BackendConfigSessionAffinityType = params.BackendConfig.SessionAffinity.Type
+		data.BackendConfigSessionAffinityTTL = params.BackendConfig.SessionAffinity.CookieTTL
+		data.BackendConfigDrainingTimeout = params.BackendConfig.ConnectionDraining
+		data.BackendConfigHealthCheckPath = params.BackendConfig.HealthCheck.Path
+		data.BackendConfigHealthCheckPort = params.BackendConfig.HealthCheck.Port
+		data.BackendConfigHealthCheckType = params.BackendConfig.HealthCheck.Type
+		if data.BackendConfigHealthCheckType == "" {
+			// without a port the health check goes to the serving port of the service, which is https with the openresty sidecar
+			data.BackendConfigHealthCheckType = "HTTP"
+			if params.Container.Protocol.UsesTLS() && data.BackendConfigHealthCheckPort == 0 {
+				data.BackendConfigHealthCheckType = "HTTP2"
+			} else if data.UseHTTPS && data.BackendConfigHealthCheckPort == 0 {
+				data.BackendConfigHealthCheckType = "HTTPS"
+			}
+		}
+		data.UseBackendConfigLogging = params.BackendConfig.Logging.SampleRate != nil
+		if data.UseBackendConfigLogging {
+			data.BackendConfigLoggingSampleRate = *params.BackendConfig.Logging.SampleRate
+		}
+	}
+
 	// add extra hosts for routing in ingress, without setting their dns records
 	data.Hosts = append(data.Hosts, params.HostsRouteOnly...)
 	data.InternalHosts = append(data.InternalHosts, params.InternalHostsRouteOnly...)
@@ -454,13 +570,114 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	if !strings.HasSuffix(data.IngressPath, "/") && !strings.HasSuffix(data.IngressPath, "*") {
 		data.IngressPath += "/"
 	}
-	if data.UseGCEIngress && !strings.HasSuffix(data.IngressPath, "*") {
+	data.IngressController = s.getIngressControllerData(params, params.GetIngressControllerName())
+	data.InternalIngressController = s.getIngressControllerData(params, params.GetInternalIngressControllerName())
+	data.ApigeeIngressController = s.getIngressControllerData(params, params.GetApigeeIngressControllerName())
+
+	if (data.UseNginxIngress || data.UseGCEIngress) && data.IngressController.Features[api.IngressControllerFeatureWildcardPath] && !strings.HasSuffix(data.IngressPath, "*") {
 		data.IngressPath += "*"
 	}
 	if !strings.HasSuffix(data.InternalIngressPath, "/") && !strings.HasSuffix(data.InternalIngressPath, "*") {
 		data.InternalIngressPath += "/"
 	}
 
+	if data.UseGatewayRoute {
+		data.GatewayPath = s.getGatewayPath(params.Basepath)
+		data.GatewayRequestTimeout = fmt.Sprintf("%vs", data.NginxIngressProxyReadTimeout)
+		data.GatewayBackendPort = data.Container.Port
+		if data.HasOpenrestySidecar {
+			data.GatewayBackendPort = 80
+		}
+
+		// deployments get a service per track, so the weight of traffic to the canary doesn't depend on its number of replicas
+		data.GatewayBackends = []api.GatewayBackendData{{Name: data.Name, Weight: 100}}
+		if params.Kind == api.KindDeployment {
+			switch params.Action {
+			case api.ActionDeployCanary,
+				api.ActionDiffCanary:
+				canaryWeight := 0
+				if params.Gateway.CanaryWeight != nil {
+					canaryWeight = *params.Gateway.CanaryWeight
+				}
+				data.GatewayBackends = []api.GatewayBackendData{
+					{Name: data.Name + "-stable", Weight: 100 - canaryWeight},
+					{Name: data.Name + "-canary", Weight: canaryWeight},
+				}
+			case api.ActionDeployStable,
+				api.ActionDiffStable,
+				api.ActionRollbackCanary:
+				data.GatewayBackends = []api.GatewayBackendData{{Name: data.Name + "-stable", Weight: 100}}
+			}
+		}
+	}
+
+	data.UseIstio = params.HasIstioSidecar() && (params.Kind == api.KindDeployment || params.Kind == api.KindStatefulset)
+	if data.UseIstio {
+		data.PodLabels["sidecar.istio.io/inject"] = "true"
+		data.IstioMTLS = params.Mesh.MTLS != nil && *params.Mesh.MTLS
+		data.MeshTimeout = params.Mesh.Timeout
+		data.MeshRetryAttempts = params.Mesh.Retries.Attempts
+		data.MeshRetryPerTryTimeout = params.Mesh.Retries.PerTryTimeout
+		data.MeshRetryOn = params.Mesh.Retries.RetryOn
+		data.UseMeshOutlierDetection = params.Mesh.OutlierDetection.Consecutive5xxErrors > 0
+		data.MeshOutlierDetection = api.MeshOutlierDetectionData{
+			Consecutive5xxErrors: params.Mesh.OutlierDetection.Consecutive5xxErrors,
+			Interval:             params.Mesh.OutlierDetection.Interval,
+			BaseEjectionTime:     params.Mesh.OutlierDetection.BaseEjectionTime,
+			MaxEjectionPercent:   params.Mesh.OutlierDetection.MaxEjectionPercent,
+		}
+
+		// route to the track subsets of the destinationrule, so the weight of traffic to the canary doesn't depend on its number of replicas
+		data.MeshDestinations = []api.MeshDestinationData{{Weight: 100}}
+		if params.Kind == api.KindDeployment {
+			switch params.Action {
+			case api.ActionDeployCanary,
+				api.ActionDiffCanary:
+				data.MeshDestinations = []api.MeshDestinationData{
+					{Subset: "stable", Weight: 100 - params.Mesh.CanaryWeight},
+					{Subset: "canary", Weight: params.Mesh.CanaryWeight},
+				}
+			case api.ActionDeployStable,
+				api.ActionDiffStable,
+				api.ActionRollbackCanary:
+				data.MeshDestinations = []api.MeshDestinationData{{Subset: "stable", Weight: 100}}
+			}
+		}
+	}
+
+	data.UseNetworkPolicy = params.NetworkPolicy.Enabled != nil && *params.NetworkPolicy.Enabled
+	if data.UseNetworkPolicy {
+		data.NetworkPolicyIngress = s.getNetworkPolicyIngressRules(params, data)
+		data.NetworkPolicyEgress = s.getNetworkPolicyEgressRules(params)
+		data.NetworkPolicyRestrictEgress = len(data.NetworkPolicyEgress) > 0
+	}
+
+	// wildcard paths as used by gce ingress are only valid for the implementation specific path type
+	data.IngressPathType = s.getIngressPathType(data.IngressPath)
+
+	if data.UseNginxIngress || data.UseGCEIngress {
+		// apigee routes are served next to the apigee hosts, by the same ingress controller
+		data.RoutesIngressController = data.IngressController
+		if params.Visibility == api.VisibilityApigee {
+			data.RoutesIngressController = data.ApigeeIngressController
+		}
+		data.Routes = s.getRoutes(params, data)
+	}
+	data.InternalIngressPathType = s.getIngressPathType(data.InternalIngressPath)
+
+	data.APIVersions = api.APIVersionsData{
+		Ingress:                 s.selectAPIVersion(params.ServedAPIVersions, "networking.k8s.io/v1", "networking.k8s.io/v1beta1", "extensions/v1beta1"),
+		PodDisruptionBudget:     s.selectAPIVersion(params.ServedAPIVersions, "policy/v1", "policy/v1beta1"),
+		CronJob:                 s.selectAPIVersion(params.ServedAPIVersions, "batch/v1", "batch/v1beta1"),
+		HorizontalPodAutoscaler: s.selectAPIVersion(params.ServedAPIVersions, "autoscaling/v2", "autoscaling/v2beta2"),
+		BackendConfig:           s.selectAPIVersion(params.ServedAPIVersions, "cloud.google.com/v1", "cloud.google.com/v1beta1"),
+		Gateway:                 s.selectAPIVersion(params.ServedAPIVersions, "gateway.networking.k8s.io/v1", "gateway.networking.k8s.io/v1beta1"),
+		IstioNetworking:         s.selectAPIVersion(params.ServedAPIVersions, "networking.istio.io/v1", "networking.istio.io/v1beta1"),
+	}
+
+	data.HpaMetrics = s.getHorizontalPodAutoscalerMetrics(params)
+	data.HpaBehavior = s.getHorizontalPodAutoscalerBehavior(params)
+
 	data.TrustedIPRanges = params.TrustedIPRanges
 
 	data.AdditionalVolumeMounts = []api.VolumeMountData{}
@@ -486,7 +703,13 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		}
 		data.AdditionalContainerPorts = append(data.AdditionalContainerPorts, additionalPortData)
 
+		// additional ports targeted by a route need to be on the service for the ingress to reach them
 		includeAsServicePort := ap.Visibility == params.Visibility
+		for _, route := range params.Routes {
+			if route.Port == ap.Name {
+				includeAsServicePort = true
+			}
+		}
 
 		if includeAsServicePort {
 			data.AdditionalServicePorts = append(data.AdditionalServicePorts, additionalPortData)
@@ -497,6 +720,19 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 	if params.CertificateSecret != "" {
 		data.UseCertificateSecret = true
 		data.CertificateSecretName = params.CertificateSecret
+	} else if params.Certificate.Provider == api.CertificateProviderCertManager {
+		// cert-manager writes tls.crt and tls.key instead of ssl.crt and ssl.key, so it gets a secret of its own
+		data.UseCertificateSecret = true
+		data.CertificateSecretName = data.Name + "-cert-manager-certificate"
+		data.UseCertManagerCertificate = true
+		data.CertManagerIssuer = params.Certificate.Issuer
+		data.CertManagerIssuerKind = params.Certificate.IssuerKind
+	}
+	data.UseManagedCertificate = params.Certificate.Provider == api.CertificateProviderGKEManaged && data.UseGCEIngress
+
+	// a gke managed certificate only lives on the load balancer, so without a certificate secret the pods have no certificate to mount
+	if data.UseManagedCertificate && !data.UseCertificateSecret {
+		data.MountSslCertificate = false
 	}
 
 	data.MountVolumes = data.MountSslCertificate || data.MountApplicationSecrets || data.MountConfigmap || data.MountPayloadLogging || data.MountServiceAccountSecret || data.MountAdditionalVolumes
@@ -514,7 +750,77 @@ func (s *service) GenerateTemplateData(params api.Params, currentReplicas int, g
 		}
 	}
 
-	return data
+	// render the container templates of declared sidecar types once all other template data is known
+	for i, sidecar := range data.Sidecars {
+		if sidecar.ContainerTemplate != "" {
+			containerYAML, err := s.renderSidecarContainerTemplate(sidecar, data)
+			if err != nil {
+				return data, err
+			}
+			data.Sidecars[i].ContainerYAML = containerYAML
+		}
+	}
+
+	return data, nil
+}
+
+func (s *service) addTracingEnvironmentVariables(environmentVariables map[string]interface{}, params api.Params, releaseID string) map[string]interface{} {
+	isCanary := params.Action == api.ActionDeployCanary || params.Action == api.ActionDiffCanary
+
+	switch params.Tracing.Provider {
+	case api.TracingProviderJaeger, api.TracingProviderUnknown:
+		environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "JAEGER_SERVICE_NAME", params.App)
+
+		if isCanary {
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "JAEGER_SAMPLER_TYPE", "probabilistic")
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "JAEGER_SAMPLER_PARAM", params.Tracing.CanarySamplingRatio)
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "JAEGER_TAGS", "track=canary")
+		} else {
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "JAEGER_SAMPLER_TYPE", "remote")
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "JAEGER_SAMPLER_PARAM", params.Tracing.SamplingRatio)
+		}
+
+	case api.TracingProviderOpenTelemetry:
+		endpoint := params.Tracing.Endpoint
+		if endpoint == "" {
+			// OTEL_NODE_IP is set from the pod's host ip in the templates, so it can be expanded here; 4317 is the collector's grpc port
+			endpoint = "http://$(OTEL_NODE_IP):4317"
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+		}
+
+		samplingRatio := params.Tracing.SamplingRatio
+		if isCanary {
+			samplingRatio = params.Tracing.CanarySamplingRatio
+		}
+
+		resourceAttributes := []string{}
+		if params.BuildVersion != "" {
+			resourceAttributes = append(resourceAttributes, fmt.Sprintf("service.version=%v", params.BuildVersion))
+		}
+		switch params.Action {
+		case api.ActionDeploySimple, api.ActionDiffSimple:
+			resourceAttributes = append(resourceAttributes, "deployment.track=simple")
+		case api.ActionDeployCanary, api.ActionDiffCanary:
+			resourceAttributes = append(resourceAttributes, "deployment.track=canary")
+		case api.ActionDeployStable, api.ActionDiffStable:
+			resourceAttributes = append(resourceAttributes, "deployment.track=stable")
+		}
+		if releaseID != "" {
+			resourceAttributes = append(resourceAttributes, fmt.Sprintf("estafette.release.id=%v", releaseID))
+		}
+
+		environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "OTEL_SERVICE_NAME", params.App)
+		environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "OTEL_EXPORTER_OTLP_ENDPOINT", endpoint)
+		if len(resourceAttributes) > 0 {
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "OTEL_RESOURCE_ATTRIBUTES", strings.Join(resourceAttributes, ","))
+		}
+		if samplingRatio != "" {
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "OTEL_TRACES_SAMPLER", "parentbased_traceidratio")
+			environmentVariables = s.AddEnvironmentVariableIfNotSet(environmentVariables, "OTEL_TRACES_SAMPLER_ARG", samplingRatio)
+		}
+	}
+
+	return environmentVariables
 }
 
 func (s *service) BuildSidecar(sidecar *api.SidecarParams, params api.Params) api.SidecarData {
@@ -533,6 +839,12 @@ func (s *service) BuildSidecar(sidecar *api.SidecarParams, params api.Params) ap
 			"dbinstanceconnectionname":          sidecar.DbInstanceConnectionName,
 			"sqlproxyport":                      sidecar.SQLProxyPort,
 			"sqlproxyterminationtimeoutseconds": sidecar.SQLProxyTerminationTimeoutSeconds,
+			"sqlproxyversion":                   string(sidecar.SQLProxyVersion),
+			"sqlproxyinstances":                 sidecar.SQLProxyInstances,
+			"sqlproxyprivateip":                 sidecar.SQLProxyPrivateIP,
+			"sqlproxyautoiamauthn":              sidecar.SQLProxyAutoIAMAuthn,
+			"sqlproxystructuredlogs":            sidecar.SQLProxyStructuredLogs,
+			"sqlproxyhealthcheckport":           sidecar.SQLProxyHealthCheckPort,
 		},
 	}
 
@@ -558,8 +870,25 @@ func (s *service) BuildSidecar(sidecar *api.SidecarParams, params api.Params) ap
 		}
 	}
 
-	if sidecar.CustomProperties != nil {
-		yamlBytes, err := yaml.Marshal(sidecar.CustomProperties)
+	// properties of a declared sidecar type are passed to its template instead of being rendered as container fields
+	customProperties := sidecar.CustomProperties
+	if definition := params.GetSidecarTypeDefinition(sidecar.Type); definition != nil {
+		customProperties = nil
+		for key, value := range sidecar.CustomProperties {
+			if definition.HasProperty(key) {
+				builtSidecar.SidecarSpecificProperties[key] = value
+				continue
+			}
+			if customProperties == nil {
+				customProperties = map[string]interface{}{}
+			}
+			customProperties[key] = value
+		}
+		builtSidecar.ContainerTemplate = definition.Template
+	}
+
+	if customProperties != nil {
+		yamlBytes, err := yaml.Marshal(customProperties)
 		if err == nil {
 			builtSidecar.CustomPropertiesYAML = string(yamlBytes)
 			builtSidecar.HasCustomProperties = true
@@ -569,6 +898,417 @@ func (s *service) BuildSidecar(sidecar *api.SidecarParams, params api.Params) ap
 	return builtSidecar
 }
 
+func (s *service) renderSidecarContainerTemplate(sidecar api.SidecarData, data api.TemplateData) (string, error) {
+	tmpl, err := template.New(sidecar.Type).Parse(sidecar.ContainerTemplate)
+	if err != nil {
+		return "", fmt.Errorf("Failed parsing container template of sidecar type %v: %w", sidecar.Type, err)
+	}
+
+	var renderedTemplate bytes.Buffer
+	err = tmpl.Execute(&renderedTemplate, api.SidecarTemplateData{Sidecar: sidecar, Deployment: data})
+	if err != nil {
+		return "", fmt.Errorf("Failed rendering container template of sidecar type %v: %w", sidecar.Type, err)
+	}
+
+	return strings.TrimRight(renderedTemplate.String(), "\n"), nil
+}
+
+// getNetworkPolicyIngressRules allows traffic from wherever the visibility routes requests from, from declared sources and from the metrics scraper
+func (s *service) getNetworkPolicyIngressRules(params api.Params, data api.TemplateData) []interface{} {
+
+	peers := []interface{}{}
+	if data.UseNginxIngress || (len(data.InternalHosts) > 0 && !data.UseGatewayRoute) {
+		for _, ns := range params.NetworkPolicy.IngressControllerNamespaces {
+			peers = append(peers, s.getNetworkPolicyNamespacePeer(ns))
+		}
+	}
+	if data.UseGCEIngress {
+		// google cloud load balancer and health check ranges
+		peers = append(peers, s.getNetworkPolicyCIDRPeer("130.211.0.0/22"), s.getNetworkPolicyCIDRPeer("35.191.0.0/16"))
+	}
+	if data.ServiceType == "LoadBalancer" {
+		// access is limited by the loadBalancerSourceRanges on the service instead
+		peers = append(peers, s.getNetworkPolicyCIDRPeer("0.0.0.0/0"))
+	}
+	if data.UseGatewayRoute {
+		gatewayNamespace := data.GatewayNamespace
+		if gatewayNamespace == "" {
+			gatewayNamespace = data.Namespace
+		}
+		peers = append(peers, s.getNetworkPolicyNamespacePeer(gatewayNamespace))
+
+		internalGatewayNamespace := data.InternalGatewayNamespace
+		if internalGatewayNamespace == "" {
+			internalGatewayNamespace = data.Namespace
+		}
+		if len(data.InternalHosts) > 0 && internalGatewayNamespace != gatewayNamespace {
+			peers = append(peers, s.getNetworkPolicyNamespacePeer(internalGatewayNamespace))
+		}
+	}
+	for _, app := range params.NetworkPolicy.Apps {
+		peers = append(peers, s.getNetworkPolicyAppPeer(app))
+	}
+	for _, ns := range params.NetworkPolicy.Namespaces {
+		peers = append(peers, s.getNetworkPolicyNamespacePeer(ns))
+	}
+	for _, cidr := range params.NetworkPolicy.CIDRs {
+		peers = append(peers, s.getNetworkPolicyCIDRPeer(cidr))
+	}
+
+	// with the openresty or esp sidecar in front of the application requests shouldn't reach the application directly; these match the service ports
+	ports := []interface{}{}
+	if data.HasOpenrestySidecar || data.UseESP {
+		if !data.UseESP {
+			ports = append(ports, s.getNetworkPolicyPort("http", "TCP"))
+		}
+		ports = append(ports, s.getNetworkPolicyPort("https", "TCP"))
+	} else {
+		ports = append(ports, s.getNetworkPolicyPort("web", "TCP"))
+	}
+	for _, ap := range data.AdditionalServicePorts {
+		ports = append(ports, s.getNetworkPolicyPort(ap.Name, ap.Protocol))
+	}
+
+	rules := []interface{}{}
+	if len(peers) > 0 {
+		rules = append(rules, map[string]interface{}{
+			"from":  peers,
+			"ports": ports,
+		})
+	}
+
+	if data.Container.Metrics.Scrape && len(params.NetworkPolicy.MetricsNamespaces) > 0 {
+		metricsPeers := []interface{}{}
+		for _, ns := range params.NetworkPolicy.MetricsNamespaces {
+			metricsPeers = append(metricsPeers, s.getNetworkPolicyNamespacePeer(ns))
+		}
+		metricsPorts := []interface{}{s.getNetworkPolicyPort(data.Container.Metrics.Port, "TCP")}
+		if data.HasOpenrestySidecar {
+			metricsPorts = append(metricsPorts, s.getNetworkPolicyPort("nginx-prom", "TCP"))
+		}
+		rules = append(rules, map[string]interface{}{
+			"from":  metricsPeers,
+			"ports": metricsPorts,
+		})
+	}
+
+	return rules
+}
+
+// getNetworkPolicyEgressRules allows traffic to declared destinations and dns; if no destinations are declared egress isn't restricted
+func (s *service) getNetworkPolicyEgressRules(params api.Params) []interface{} {
+
+	peers := []interface{}{}
+	for _, app := range params.NetworkPolicy.Egress.Apps {
+		peers = append(peers, s.getNetworkPolicyAppPeer(app))
+	}
+	for _, ns := range params.NetworkPolicy.Egress.Namespaces {
+		peers = append(peers, s.getNetworkPolicyNamespacePeer(ns))
+	}
+	for _, cidr := range params.NetworkPolicy.Egress.CIDRs {
+		peers = append(peers, s.getNetworkPolicyCIDRPeer(cidr))
+	}
+
+	if len(peers) == 0 {
+		return []interface{}{}
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"to":    []interface{}{s.getNetworkPolicyNamespacePeer("kube-system")},
+			"ports": []interface{}{s.getNetworkPolicyPort(53, "UDP"), s.getNetworkPolicyPort(53, "TCP")},
+		},
+		map[string]interface{}{
+			"to": peers,
+		},
+	}
+}
+
+// getHorizontalPodAutoscalerMetrics returns the autoscaling/v2 metrics; without metrics the template scales on cpu utilization only
+func (s *service) getHorizontalPodAutoscalerMetrics(params api.Params) []interface{} {
+
+	metrics := []interface{}{}
+	for _, m := range params.Autoscale.Metrics {
+		target := map[string]interface{}{}
+		switch {
+		case m.Type.IsResource() && m.AverageUtilization > 0:
+			target["type"] = "Utilization"
+			target["averageUtilization"] = m.AverageUtilization
+		case m.AverageValue != "":
+			target["type"] = "AverageValue"
+			target["averageValue"] = m.AverageValue
+		default:
+			target["type"] = "Value"
+			target["value"] = m.Value
+		}
+
+		metric := map[string]interface{}{
+			"name": m.Name,
+		}
+		if len(m.Selector) > 0 {
+			metric["selector"] = map[string]interface{}{
+				"matchLabels": m.Selector,
+			}
+		}
+
+		switch m.Type {
+		case api.AutoscaleMetricTypeCPU, api.AutoscaleMetricTypeMemory:
+			metrics = append(metrics, map[string]interface{}{
+				"type": "Resource",
+				"resource": map[string]interface{}{
+					"name":   string(m.Type),
+					"target": target,
+				},
+			})
+		case api.AutoscaleMetricTypePods:
+			metrics = append(metrics, map[string]interface{}{
+				"type": "Pods",
+				"pods": map[string]interface{}{
+					"metric": metric,
+					"target": target,
+				},
+			})
+		case api.AutoscaleMetricTypeExternal:
+			metrics = append(metrics, map[string]interface{}{
+				"type": "External",
+				"external": map[string]interface{}{
+					"metric": metric,
+					"target": target,
+				},
+			})
+		}
+	}
+
+	return metrics
+}
+
+// getHorizontalPodAutoscalerBehavior returns the autoscaling/v2 behavior, or nil to keep the kubernetes defaults
+func (s *service) getHorizontalPodAutoscalerBehavior(params api.Params) map[string]interface{} {
+
+	if params.Autoscale.Behavior.ScaleUp == nil && params.Autoscale.Behavior.ScaleDown == nil {
+		return nil
+	}
+
+	behavior := map[string]interface{}{}
+	if params.Autoscale.Behavior.ScaleUp != nil {
+		behavior["scaleUp"] = s.getHorizontalPodAutoscalerScalingRules(params.Autoscale.Behavior.ScaleUp)
+	}
+	if params.Autoscale.Behavior.ScaleDown != nil {
+		behavior["scaleDown"] = s.getHorizontalPodAutoscalerScalingRules(params.Autoscale.Behavior.ScaleDown)
+	}
+
+	return behavior
+}
+
+func (s *service) getHorizontalPodAutoscalerScalingRules(rules *api.AutoscaleScalingRulesParams) map[string]interface{} {
+
+	scalingRules := map[string]interface{}{}
+	if rules.StabilizationWindowSeconds != nil {
+		scalingRules["stabilizationWindowSeconds"] = *rules.StabilizationWindowSeconds
+	}
+	if rules.SelectPolicy != "" {
+		scalingRules["selectPolicy"] = rules.SelectPolicy
+	}
+	if len(rules.Policies) > 0 {
+		policies := []interface{}{}
+		for _, p := range rules.Policies {
+			policies = append(policies, map[string]interface{}{
+				"type":          p.Type,
+				"value":         p.Value,
+				"periodSeconds": p.PeriodSeconds,
+			})
+		}
+		scalingRules["policies"] = policies
+	}
+
+	return scalingRules
+}
+
+func (s *service) getNetworkPolicyNamespacePeer(namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"namespaceSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				"kubernetes.io/metadata.name": namespace,
+			},
+		},
+	}
+}
+
+func (s *service) getNetworkPolicyAppPeer(app string) map[string]interface{} {
+	return map[string]interface{}{
+		"podSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				"app": app,
+			},
+		},
+	}
+}
+
+func (s *service) getNetworkPolicyCIDRPeer(cidr string) map[string]interface{} {
+	return map[string]interface{}{
+		"ipBlock": map[string]interface{}{
+			"cidr": cidr,
+		},
+	}
+}
+
+func (s *service) getNetworkPolicyPort(port interface{}, protocol string) map[string]interface{} {
+	return map[string]interface{}{
+		"port":     port,
+		"protocol": protocol,
+	}
+}
+
+// selectAPIVersion returns the first of the preferred api versions that is served by the cluster; if the served api versions are unknown or none of them match it returns the most preferred one
+func (s *service) selectAPIVersion(servedAPIVersions []string, preferredAPIVersions ...string) string {
+	for _, p := range preferredAPIVersions {
+		for _, v := range servedAPIVersions {
+			if p == v {
+				return p
+			}
+		}
+	}
+
+	return preferredAPIVersions[0]
+}
+
+// getGatewayPath returns the basepath as prefix for httproute path matching, which matches on path elements and doesn't support wildcards
+func (s *service) getGatewayPath(basepath string) string {
+	path := strings.TrimSuffix(basepath, "*")
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	return path
+}
+
+// getIngressControllerData returns the class, annotation prefix and features of the ingress controller profile to use in the ingress templates
+func (s *service) getIngressControllerData(params api.Params, name string) api.IngressControllerData {
+	ingressController, _ := params.GetIngressController(name)
+
+	features := map[string]bool{}
+	for _, f := range ingressController.Features {
+		features[f] = true
+	}
+
+	return api.IngressControllerData{
+		Name:             ingressController.Name,
+		Class:            ingressController.Class,
+		AnnotationPrefix: ingressController.AnnotationPrefix,
+		Features:         features,
+	}
+}
+
+// getBackendProtocols returns the backend protocol for the nginx ingresses and the app protocols for the service, so http2 and grpc reach the container unchanged
+func (s *service) getBackendProtocols(protocol api.ContainerProtocol, useHTTPS bool, servicePortName string) (nginxIngressBackendProtocol, nginxIngressApigeeBackendProtocol, serviceAppProtocols, serviceAppProtocol string) {
+	switch protocol {
+	case api.ContainerProtocolGRPC:
+		return "GRPC", "GRPC", fmt.Sprintf(`{"%v":"HTTP"}`, servicePortName), "grpc"
+	case api.ContainerProtocolGRPCS:
+		return "GRPCS", "GRPCS", fmt.Sprintf(`{"%v":"HTTP2"}`, servicePortName), "https"
+	case api.ContainerProtocolHTTP2:
+		return "HTTPS", "HTTPS", fmt.Sprintf(`{"%v":"HTTP2"}`, servicePortName), "https"
+	}
+
+	if useHTTPS {
+		nginxIngressBackendProtocol = "HTTPS"
+	}
+	return nginxIngressBackendProtocol, "HTTPS", `{"https":"HTTPS"}`, "http"
+}
+
+// getProbeTypeAndScheme returns the kind of probe for the protocol; grpc gets kubernetes' native grpc probe, which doesn't do tls, so grpcs only gets a tcp check
+func (s *service) getProbeTypeAndScheme(protocol api.ContainerProtocol) (probeType, scheme string) {
+	switch protocol {
+	case api.ContainerProtocolGRPC:
+		return "grpc", ""
+	case api.ContainerProtocolGRPCS:
+		return "tcpSocket", ""
+	case api.ContainerProtocolHTTP2:
+		return "httpGet", "HTTPS"
+	}
+	return "httpGet", ""
+}
+
+// getNginxIngressProxyTimeouts returns the connect, send and read timeouts for the nginx ingress from the request timeout; nginx doesn't allow a connect timeout over 75 seconds
+func (s *service) getNginxIngressProxyTimeouts(timeout string) (connectTimeout, sendTimeout, readTimeout int) {
+	requestTimeout, err := strconv.Atoi(strings.Trim(timeout, "s"))
+	if err != nil {
+		return 60, 60, 60
+	}
+
+	connectTimeout = requestTimeout
+	if connectTimeout > 75 {
+		connectTimeout = 75
+	}
+
+	return connectTimeout, requestTimeout, requestTimeout
+}
+
+// getRoutes returns an ingress per route, which routes its path on the hosts to the main or an additional port with its own request settings
+func (s *service) getRoutes(params api.Params, data api.TemplateData) (routes []api.RouteData) {
+	for _, route := range params.Routes {
+		// the prefix path type already matches subpaths, so only wildcard paths need a trailing /*
+		path := route.Path
+		if data.RoutesIngressController.Features[api.IngressControllerFeatureWildcardPath] && !strings.HasSuffix(path, "*") {
+			if !strings.HasSuffix(path, "/") {
+				path += "/"
+			}
+			path += "*"
+		}
+
+		hosts := route.Hosts
+		backendProtocol := data.NginxIngressBackendProtocol
+		if params.Visibility == api.VisibilityApigee {
+			hosts = []string{}
+			for _, h := range route.Hosts {
+				hparts := strings.Split(h, ".")
+				hparts[0] = hparts[0] + "-" + params.ApigeeSuffix
+				hosts = append(hosts, strings.Join(hparts, "."))
+			}
+			backendProtocol = data.NginxIngressApigeeBackendProtocol
+		}
+
+		portName := "web"
+		if data.HasOpenrestySidecar {
+			portName = "https"
+		}
+		if route.Port != "" {
+			portName = route.Port
+			backendProtocol = ""
+		}
+
+		routeData := api.RouteData{
+			Name:                 route.Name,
+			Hosts:                hosts,
+			Path:                 path,
+			PathType:             s.getIngressPathType(path),
+			PortName:             portName,
+			BackendProtocol:      backendProtocol,
+			ProxyBodySize:        route.Request.MaxBodySize,
+			ClientBodyBufferSize: route.Request.ClientBodyBufferSize,
+			ProxyBufferSize:      route.Request.ProxyBufferSize,
+			ProxyBuffersNumber:   strconv.Itoa(route.Request.ProxyBuffersNumber),
+			LoadBalanceAlgorithm: route.Request.LoadBalanceAlgorithm,
+		}
+		routeData.ProxyConnectTimeout, routeData.ProxySendTimeout, routeData.ProxyReadTimeout = s.getNginxIngressProxyTimeouts(route.Request.Timeout)
+
+		routes = append(routes, routeData)
+	}
+
+	return
+}
+
+func (s *service) getIngressPathType(path string) string {
+	if strings.HasSuffix(path, "*") {
+		return "ImplementationSpecific"
+	}
+
+	return "Prefix"
+}
+
 func (s *service) AddEnvironmentVariableIfNotSet(environmentVariables map[string]interface{}, name, value string) map[string]interface{} {
 
 	if environmentVariables == nil {
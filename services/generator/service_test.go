@@ -2,6 +2,8 @@ package generator
 
 import (
 	"context"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/estafette/estafette-extension-gke/api"
@@ -26,7 +28,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "myapp", templateData.Name)
 	})
@@ -42,7 +45,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "mynamespace", templateData.Namespace)
 	})
@@ -61,7 +65,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.Labels))
 		assert.Equal(t, "myapp", templateData.Labels["app"])
@@ -79,7 +84,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "myapp", templateData.AppLabelSelector)
 	})
@@ -99,7 +105,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.Labels))
 		assert.Equal(t, "yourapp", templateData.Labels["app"])
@@ -119,7 +126,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.Labels))
 		assert.Equal(t, "yourapp", templateData.Labels["app"])
@@ -138,7 +146,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "myproject", templateData.Container.Repository)
 	})
@@ -156,7 +165,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "my-app", templateData.Container.Name)
 	})
@@ -174,7 +184,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1.0.0", templateData.Container.Tag)
 	})
@@ -190,7 +201,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "ClusterIP", templateData.ServiceType)
 	})
@@ -206,7 +218,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "ClusterIP", templateData.ServiceType)
 	})
@@ -222,7 +235,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "NodePort", templateData.ServiceType)
 	})
@@ -238,7 +252,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "LoadBalancer", templateData.ServiceType)
 	})
@@ -254,7 +269,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseDNSAnnotationsOnIngress)
 	})
@@ -270,7 +286,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseDNSAnnotationsOnIngress)
 	})
@@ -286,7 +303,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseDNSAnnotationsOnIngress)
 	})
@@ -302,7 +320,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseDNSAnnotationsOnService)
 	})
@@ -318,7 +337,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseDNSAnnotationsOnService)
 	})
@@ -334,7 +354,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseDNSAnnotationsOnService)
 	})
@@ -350,7 +371,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseCloudflareProxy)
 	})
@@ -366,7 +388,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseCloudflareProxy)
 	})
@@ -382,7 +405,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseCloudflareProxy)
 	})
@@ -398,7 +422,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseCloudflareProxy)
 	})
@@ -418,7 +443,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1200m", templateData.Container.CPURequest)
 	})
@@ -438,7 +464,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1500m", templateData.Container.CPULimit)
 	})
@@ -458,7 +485,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1024Mi", templateData.Container.MemoryRequest)
 	})
@@ -478,7 +506,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "2048Mi", templateData.Container.MemoryLimit)
 	})
@@ -496,7 +525,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 3080, templateData.Container.Port)
 	})
@@ -515,7 +545,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.Hosts))
 		assert.Equal(t, "gke.estafette.io", templateData.Hosts[0])
@@ -536,7 +567,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "gke.estafette.io,gke-deploy.estafette.io", templateData.HostsJoined)
 	})
@@ -555,7 +587,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.InternalHosts))
 		assert.Equal(t, "gke.estafette.io", templateData.InternalHosts[0])
@@ -576,7 +609,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "gke.estafette.io,gke-deploy.estafette.io", templateData.InternalHostsJoined)
 	})
@@ -594,7 +628,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 5, templateData.MinReplicas)
 	})
@@ -612,7 +647,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 16, templateData.MaxReplicas)
 	})
@@ -628,7 +664,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseNginxIngress)
 	})
@@ -644,7 +681,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseNginxIngress)
 	})
@@ -660,7 +698,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseNginxIngress)
 	})
@@ -676,7 +715,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseNginxIngress)
 	})
@@ -692,7 +732,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseGCEIngress)
 	})
@@ -708,7 +749,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseGCEIngress)
 	})
@@ -724,7 +766,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseGCEIngress)
 	})
@@ -740,7 +783,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.UseGCEIngress)
 	})
@@ -760,7 +804,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/liveness", templateData.Container.Liveness.Path)
 	})
@@ -780,7 +825,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 5001, templateData.Container.Liveness.Port)
 	})
@@ -800,7 +846,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 30, templateData.Container.Liveness.InitialDelaySeconds)
 	})
@@ -820,7 +867,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, templateData.Container.Liveness.TimeoutSeconds)
 	})
@@ -840,7 +888,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, templateData.Container.Liveness.FailureThreshold)
 	})
@@ -860,7 +909,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 7, templateData.Container.Liveness.SuccessThreshold)
 	})
@@ -880,7 +930,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/readiness", templateData.Container.Readiness.Path)
 	})
@@ -900,7 +951,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 5002, templateData.Container.Readiness.Port)
 	})
@@ -920,7 +972,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 30, templateData.Container.Readiness.InitialDelaySeconds)
 	})
@@ -940,7 +993,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, templateData.Container.Readiness.TimeoutSeconds)
 	})
@@ -960,7 +1014,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 6, templateData.Container.Readiness.FailureThreshold)
 	})
@@ -980,7 +1035,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 3, templateData.Container.Readiness.SuccessThreshold)
 	})
@@ -1001,7 +1057,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "value1", templateData.Container.EnvironmentVariables["MY_CUSTOM_ENV"])
 		assert.Equal(t, "value2", templateData.Container.EnvironmentVariables["MY_OTHER_CUSTOM_ENV"])
@@ -1021,7 +1078,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "my-app", templateData.Container.EnvironmentVariables["JAEGER_SERVICE_NAME"])
 	})
@@ -1041,7 +1099,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/readiness", templateData.Container.Metrics.Path)
 	})
@@ -1061,7 +1120,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 3080, templateData.Container.Metrics.Port)
 	})
@@ -1081,7 +1141,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, true, templateData.Container.Metrics.Scrape)
 	})
@@ -1101,7 +1162,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, true, templateData.Container.UseLifecyclePreStopSleepCommand)
 	})
@@ -1123,7 +1185,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 25, templateData.Container.PreStopSleepSeconds)
 	})
@@ -1143,7 +1206,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, len(templateData.Sidecars))
 	})
@@ -1163,7 +1227,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "openresty", templateData.Sidecars[0].Type)
 	})
@@ -1183,7 +1248,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "estafette/openresty-sidecar:1.13.6.1-alpine", templateData.Sidecars[0].Image)
 	})
@@ -1203,7 +1269,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/readiness", templateData.Sidecars[0].SidecarSpecificProperties["healthcheckpath"])
 	})
@@ -1225,7 +1292,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1200m", templateData.Sidecars[0].CPURequest)
 	})
@@ -1247,7 +1315,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1500m", templateData.Sidecars[0].CPULimit)
 	})
@@ -1269,7 +1338,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1024Mi", templateData.Sidecars[0].MemoryRequest)
 	})
@@ -1291,7 +1361,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "2048Mi", templateData.Sidecars[0].MemoryLimit)
 	})
@@ -1314,7 +1385,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		// assert.Equal(t, 2, len(templateData.Sidecar.EnvironmentVariables))
 		assert.Equal(t, "value1", templateData.Sidecars[0].EnvironmentVariables["MY_CUSTOM_ENV"])
@@ -1339,9 +1411,10 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
-		assert.Equal(t, 4, len(templateData.Sidecars[0].SidecarSpecificProperties))
+		assert.Equal(t, 10, len(templateData.Sidecars[0].SidecarSpecificProperties))
 		assert.Equal(t, "testHealthCheckPath", templateData.Sidecars[0].SidecarSpecificProperties["healthcheckpath"])
 		assert.Equal(t, "testDbInstanceConnectionName", templateData.Sidecars[0].SidecarSpecificProperties["dbinstanceconnectionname"])
 		assert.Equal(t, 15, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyport"])
@@ -1364,7 +1437,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.Secrets))
 		assert.Equal(t, "c29tZSBzZWNyZXQgdmFsdWU=", templateData.Secrets["secret-file-1.json"])
@@ -1387,7 +1461,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.MountApplicationSecrets)
 	})
@@ -1405,7 +1480,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.MountApplicationSecrets)
 	})
@@ -1421,7 +1497,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/", templateData.IngressPath)
 	})
@@ -1437,7 +1514,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/api/", templateData.IngressPath)
 	})
@@ -1454,7 +1532,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/api/*", templateData.IngressPath)
 	})
@@ -1470,7 +1549,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/", templateData.InternalIngressPath)
 	})
@@ -1486,7 +1566,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/api/", templateData.InternalIngressPath)
 	})
@@ -1503,7 +1584,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/api/", templateData.InternalIngressPath)
 	})
@@ -1519,7 +1601,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.MountPayloadLogging)
 	})
@@ -1535,7 +1618,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.MountPayloadLogging)
 	})
@@ -1551,7 +1635,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.AddSafeToEvictAnnotation)
 	})
@@ -1567,7 +1652,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.AddSafeToEvictAnnotation)
 	})
@@ -1585,7 +1671,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "25%", templateData.RollingUpdateMaxSurge)
 	})
@@ -1603,7 +1690,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "15%", templateData.RollingUpdateMaxUnavailable)
 	})
@@ -1619,7 +1707,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1.2.3", templateData.PodLabels["version"])
 	})
@@ -1635,7 +1724,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.PreferPreemptibles)
 	})
@@ -1651,7 +1741,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.PreferPreemptibles)
 	})
@@ -1667,7 +1758,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.HasTolerations)
 	})
@@ -1683,7 +1775,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, len(templateData.Tolerations))
 		assert.Equal(t, &map[string]interface{}{
@@ -1713,7 +1806,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.Tolerations))
 		assert.Equal(t, &map[string]interface{}{
@@ -1745,7 +1839,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.MountConfigmap)
 	})
@@ -1765,7 +1860,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.MountConfigmap)
 	})
@@ -1784,7 +1880,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.MountConfigmap)
 	})
@@ -1802,7 +1899,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/configs", templateData.ConfigMountPath)
 	})
@@ -1820,7 +1918,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "/secrets", templateData.SecretMountPath)
 	})
@@ -1836,7 +1935,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.LimitTrustedIPRanges)
 	})
@@ -1852,7 +1952,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.LimitTrustedIPRanges)
 	})
@@ -1868,7 +1969,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.LimitTrustedIPRanges)
 	})
@@ -1884,7 +1986,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.LimitTrustedIPRanges)
 	})
@@ -1915,7 +2018,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 14, len(templateData.TrustedIPRanges))
 	})
@@ -1941,7 +2045,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 3, len(templateData.ManifestData))
 		assert.Equal(t, "value 1", templateData.ManifestData["property1"])
@@ -1961,7 +2066,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "myapp-canary", templateData.NameWithTrack)
 	})
@@ -1978,7 +2084,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "myapp-stable", templateData.NameWithTrack)
 	})
@@ -1995,7 +2102,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "myapp", templateData.NameWithTrack)
 	})
@@ -2013,7 +2121,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		releaseID := ""
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", releaseID, "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", releaseID, "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "", templateData.PodLabels["estafette.io/release-id"])
 	})
@@ -2031,7 +2140,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		releaseID := "1"
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", releaseID, "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", releaseID, "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "1", templateData.PodLabels["estafette.io/release-id"])
 	})
@@ -2049,7 +2159,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		triggeredBy := ""
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", triggeredBy)
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", triggeredBy)
+		assert.Nil(t, err)
 
 		assert.Equal(t, "", templateData.PodLabels["estafette.io/triggered-by"])
 	})
@@ -2067,7 +2178,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		triggeredBy := "user@estafette.io"
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", triggeredBy)
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", triggeredBy)
+		assert.Nil(t, err)
 
 		assert.Equal(t, "user-at-estafette.io", templateData.PodLabels["estafette.io/triggered-by"])
 	})
@@ -2084,7 +2196,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.IncludeTrackLabel)
 	})
@@ -2101,7 +2214,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.IncludeTrackLabel)
 	})
@@ -2118,7 +2232,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.IncludeTrackLabel)
 	})
@@ -2135,7 +2250,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "canary", templateData.TrackLabel)
 	})
@@ -2152,7 +2268,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "stable", templateData.TrackLabel)
 	})
@@ -2189,7 +2306,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, len(templateData.AdditionalVolumeMounts))
 		assert.Equal(t, "client-certs", templateData.AdditionalVolumeMounts[0].Name)
@@ -2223,7 +2341,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.AdditionalContainerPorts))
 	})
@@ -2255,7 +2374,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, len(templateData.AdditionalServicePorts))
 		assert.Equal(t, "grpc", templateData.AdditionalServicePorts[0].Name)
@@ -2277,7 +2397,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.OverrideDefaultWhitelist)
 	})
@@ -2294,7 +2415,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.OverrideDefaultWhitelist)
 	})
@@ -2313,7 +2435,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.OverrideDefaultWhitelist)
 	})
@@ -2332,7 +2455,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.OverrideDefaultWhitelist)
 	})
@@ -2351,7 +2475,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.OverrideDefaultWhitelist)
 	})
@@ -2372,7 +2497,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16", templateData.NginxIngressWhitelist)
 	})
@@ -2386,7 +2512,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		params := api.Params{}
 
 		// act
-		templateData := service.GenerateTemplateData(params, 1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, 1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.IncludeReplicas)
 	})
@@ -2400,7 +2527,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		params := api.Params{}
 
 		// act
-		templateData := service.GenerateTemplateData(params, 0, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, 0, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.False(t, templateData.IncludeReplicas)
 	})
@@ -2414,7 +2542,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		params := api.Params{}
 
 		// act
-		templateData := service.GenerateTemplateData(params, 15, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, 15, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 15, templateData.Replicas)
 	})
@@ -2434,7 +2563,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, templateData.Replicas)
 	})
@@ -2454,7 +2584,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 5, templateData.Replicas)
 	})
@@ -2472,7 +2603,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, 0, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, 0, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 3, templateData.Replicas)
 	})
@@ -2488,7 +2620,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "*/5 * * * *", templateData.Schedule)
 	})
@@ -2508,7 +2641,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseHpaScaler)
 	})
@@ -2528,7 +2662,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "sum(rate(nginx_http_requests_total{app='my-app'}[5m])) by (app)", templateData.HpaScalerPromQuery)
 	})
@@ -2548,7 +2683,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "0.25", templateData.HpaScalerRequestsPerReplica)
 	})
@@ -2568,7 +2704,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "-2.7584", templateData.HpaScalerDelta)
 	})
@@ -2588,7 +2725,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "0.2", templateData.HpaScalerScaleDownMaxRatio)
 	})
@@ -2609,7 +2747,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 2, len(templateData.AllHosts))
 		assert.Equal(t, "ci.estafette.io", templateData.AllHosts[0])
@@ -2632,7 +2771,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "ci.estafette.io,ci.internal.estafette.io", templateData.AllHostsJoined)
 	})
@@ -2650,7 +2790,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, len(templateData.AllHosts))
 		assert.Equal(t, "ci.estafette.io", templateData.AllHosts[0])
@@ -2669,7 +2810,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "ci.estafette.io", templateData.AllHostsJoined)
 	})
@@ -2687,7 +2829,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 1, len(templateData.AllHosts))
 		assert.Equal(t, "ci.internal.estafette.io", templateData.AllHosts[0])
@@ -2706,7 +2849,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "ci.internal.estafette.io", templateData.AllHostsJoined)
 	})
@@ -2724,7 +2868,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 75, templateData.NginxIngressProxyConnectTimeout)
 	})
@@ -2742,7 +2887,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 75, templateData.NginxIngressProxyConnectTimeout)
 	})
@@ -2760,7 +2906,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 75, templateData.NginxIngressProxyConnectTimeout)
 	})
@@ -2778,7 +2925,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 300, templateData.NginxIngressProxySendTimeout)
 	})
@@ -2796,7 +2944,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 300, templateData.NginxIngressProxySendTimeout)
 	})
@@ -2814,7 +2963,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 300, templateData.NginxIngressProxyReadTimeout)
 	})
@@ -2832,7 +2982,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 300, templateData.NginxIngressProxyReadTimeout)
 	})
@@ -2848,7 +2999,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.True(t, templateData.UseCertificateSecret)
 	})
@@ -2864,7 +3016,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "ClusterIP", templateData.ServiceType)
 	})
@@ -2880,7 +3033,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, true, templateData.UseCloudflareProxy)
 	})
@@ -2896,7 +3050,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, false, templateData.UseGCEIngress)
 	})
@@ -2915,7 +3070,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, "protected/some-secret", templateData.NginxAuthTLSSecret)
 	})
@@ -2934,7 +3090,8 @@ func TestGenerateTemplateData(t *testing.T) {
 		}
 
 		// act
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, 5, templateData.NginxAuthTLSVerifyDepth)
 	})
@@ -2952,9 +3109,1760 @@ func TestGenerateTemplateData(t *testing.T) {
 
 		// act
 		params.SetDefaults("github.com", "estafette", "estafette-extension-gke", "sample-app", "0.1.0", "test", "deploy", "", nil)
-		templateData := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
 
 		assert.Equal(t, []string{"google-apigee.com", "estafette-apigee.io", "test-app-apigee"}, templateData.ApigeeHosts)
 		assert.Equal(t, "google-apigee.com,estafette-apigee.io,test-app-apigee", templateData.ApigeeHostsJoined)
 	})
+
+	t.Run("SetsPartitionToReplicasMinusCanaryReplicasIfKindIsStatefulsetAndActionIsDeployCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:            "myapp",
+			Kind:           api.KindStatefulset,
+			Action:         api.ActionDeployCanary,
+			Replicas:       5,
+			CanaryReplicas: 2,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, 5, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UsePartition)
+		assert.Equal(t, 3, templateData.Partition)
+		assert.Equal(t, "canary", templateData.TrackLabel)
+	})
+
+	t.Run("SetsPartitionToZeroIfKindIsStatefulsetAndCanaryReplicasExceedReplicas", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:            "myapp",
+			Kind:           api.KindStatefulset,
+			Action:         api.ActionDeployCanary,
+			Replicas:       1,
+			CanaryReplicas: 3,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, 1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UsePartition)
+		assert.Equal(t, 0, templateData.Partition)
+	})
+
+	t.Run("SetsPartitionToZeroIfKindIsStatefulsetAndActionIsDeployStable", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:            "myapp",
+			Kind:           api.KindStatefulset,
+			Action:         api.ActionDeployStable,
+			Replicas:       5,
+			CanaryReplicas: 2,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, 5, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UsePartition)
+		assert.Equal(t, 0, templateData.Partition)
+	})
+
+	t.Run("DoesNotUsePartitionIfKindIsStatefulsetAndActionIsDeploySimple", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:      "myapp",
+			Kind:     api.KindStatefulset,
+			Action:   api.ActionDeploySimple,
+			Replicas: 5,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, 5, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UsePartition)
+	})
+
+	t.Run("DoesNotUsePartitionIfKindIsDeploymentAndActionIsDeployCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:      "myapp",
+			Kind:     api.KindDeployment,
+			Action:   api.ActionDeployCanary,
+			Replicas: 5,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, 5, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UsePartition)
+	})
+
+	t.Run("AddsSharedConfigLabelForConfigMapsMountedFromVolumeMounts", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindDeployment,
+			VolumeMounts: []api.VolumeMountParams{
+				{
+					Name:      "shared-configs",
+					MountPath: "/shared-configs",
+					Volume: map[string]interface{}{
+						"configMap": map[interface{}]interface{}{
+							"name": "myconfig",
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "true", templateData.Labels["shared-config.estafette.io/myconfig"])
+	})
+
+	t.Run("SetsSharedConfigNameToSharedConfigNameParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:              "myapp",
+			Kind:             api.KindConfig,
+			SharedConfigName: "myconfig",
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "myconfig", templateData.SharedConfigName)
+	})
+
+	t.Run("SetsIngressPathTypeToImplementationSpecificIfUseGCEIngressIsTrue", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Basepath:   "/api",
+			Visibility: api.VisibilityIAP,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "ImplementationSpecific", templateData.IngressPathType)
+		assert.Equal(t, "Prefix", templateData.InternalIngressPathType)
+	})
+
+	t.Run("SetsIngressPathTypeToPrefixIfUseNginxIngressIsTrue", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Basepath:   "/api",
+			Visibility: api.VisibilityPrivate,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "Prefix", templateData.IngressPathType)
+	})
+
+	t.Run("SetsAPIVersionsToNewestIfServedAPIVersionsAreUnknown", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "networking.k8s.io/v1", templateData.APIVersions.Ingress)
+		assert.Equal(t, "policy/v1", templateData.APIVersions.PodDisruptionBudget)
+		assert.Equal(t, "batch/v1", templateData.APIVersions.CronJob)
+		assert.Equal(t, "autoscaling/v2", templateData.APIVersions.HorizontalPodAutoscaler)
+		assert.Equal(t, "cloud.google.com/v1", templateData.APIVersions.BackendConfig)
+	})
+
+	t.Run("SetsAPIVersionsToNewestServedAPIVersions", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			ServedAPIVersions: []string{
+				"autoscaling/v1",
+				"autoscaling/v2beta1",
+				"autoscaling/v2beta2",
+				"batch/v1",
+				"batch/v1beta1",
+				"cloud.google.com/v1beta1",
+				"extensions/v1beta1",
+				"networking.k8s.io/v1beta1",
+				"policy/v1beta1",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "networking.k8s.io/v1beta1", templateData.APIVersions.Ingress)
+		assert.Equal(t, "policy/v1beta1", templateData.APIVersions.PodDisruptionBudget)
+		assert.Equal(t, "batch/v1", templateData.APIVersions.CronJob)
+		assert.Equal(t, "autoscaling/v2beta2", templateData.APIVersions.HorizontalPodAutoscaler)
+		assert.Equal(t, "cloud.google.com/v1beta1", templateData.APIVersions.BackendConfig)
+	})
+
+	t.Run("SetsUseGatewayRouteToTrueIfVisibilityIsGateway", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityGateway,
+			Gateway: api.GatewayParams{
+				Name:         "shared-gateway",
+				Namespace:    "gateways",
+				InternalName: "internal-gateway",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseGatewayRoute)
+		assert.False(t, templateData.UseNginxIngress)
+		assert.False(t, templateData.UseGCEIngress)
+		assert.Equal(t, "ClusterIP", templateData.ServiceType)
+		assert.Equal(t, "shared-gateway", templateData.GatewayName)
+		assert.Equal(t, "gateways", templateData.GatewayNamespace)
+		assert.Equal(t, "internal-gateway", templateData.InternalGatewayName)
+	})
+
+	t.Run("SetsGatewayPathToBasepathWithoutTrailingSlash", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Basepath:   "/api/",
+			Visibility: api.VisibilityGateway,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "/api", templateData.GatewayPath)
+	})
+
+	t.Run("SetsGatewayPathToSlashIfBasepathIsSlash", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Basepath:   "/",
+			Visibility: api.VisibilityGateway,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "/", templateData.GatewayPath)
+	})
+
+	t.Run("SetsGatewayRequestTimeoutFromRequestTimeout", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityGateway,
+			Request: api.RequestParams{
+				Timeout: "120s",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "120s", templateData.GatewayRequestTimeout)
+	})
+
+	t.Run("SetsGatewayBackendsToServiceIfActionIsDeploySimple", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:        "myapp",
+			Kind:       api.KindDeployment,
+			Action:     api.ActionDeploySimple,
+			Visibility: api.VisibilityGateway,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, []api.GatewayBackendData{{Name: "myapp", Weight: 100}}, templateData.GatewayBackends)
+	})
+
+	t.Run("SetsWeightedGatewayBackendsIfActionIsDeployCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		canaryWeight := 20
+		params := api.Params{
+			App:        "myapp",
+			Kind:       api.KindDeployment,
+			Action:     api.ActionDeployCanary,
+			Visibility: api.VisibilityGateway,
+			Gateway: api.GatewayParams{
+				CanaryWeight: &canaryWeight,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, []api.GatewayBackendData{{Name: "myapp-stable", Weight: 80}, {Name: "myapp-canary", Weight: 20}}, templateData.GatewayBackends)
+	})
+
+	t.Run("SendsNoTrafficToCanaryIfGatewayCanaryWeightIsZero", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		canaryWeight := 0
+		params := api.Params{
+			App:        "myapp",
+			Kind:       api.KindDeployment,
+			Action:     api.ActionDeployCanary,
+			Visibility: api.VisibilityGateway,
+			Gateway: api.GatewayParams{
+				CanaryWeight: &canaryWeight,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, []api.GatewayBackendData{{Name: "myapp-stable", Weight: 100}, {Name: "myapp-canary", Weight: 0}}, templateData.GatewayBackends)
+	})
+
+	t.Run("SetsGatewayBackendsToStableServiceIfActionIsDeployStable", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:        "myapp",
+			Kind:       api.KindDeployment,
+			Action:     api.ActionDeployStable,
+			Visibility: api.VisibilityGateway,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, []api.GatewayBackendData{{Name: "myapp-stable", Weight: 100}}, templateData.GatewayBackends)
+	})
+
+	t.Run("SetsUseIstioAndInjectionLabelIfIstioSidecarIsSpecified", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindDeployment,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeIstio,
+					CPU: api.CPUParams{
+						Request: "100m",
+					},
+				},
+			},
+			Mesh: api.MeshParams{
+				MTLS:    &trueValue,
+				Timeout: "30s",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseIstio)
+		assert.True(t, templateData.IstioMTLS)
+		assert.Equal(t, "true", templateData.PodLabels["sidecar.istio.io/inject"])
+		assert.Equal(t, "30s", templateData.MeshTimeout)
+		assert.Equal(t, "100m", templateData.IstioProxy.CPURequest)
+		assert.Equal(t, 0, len(templateData.Sidecars))
+	})
+
+	t.Run("SetsUseIstioToFalseIfKindIsJob", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindJob,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeIstio,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UseIstio)
+		assert.Equal(t, "", templateData.PodLabels["sidecar.istio.io/inject"])
+	})
+
+	t.Run("SetsWeightedMeshDestinationsIfActionIsDeployCanary", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "myapp",
+			Kind:   api.KindDeployment,
+			Action: api.ActionDeployCanary,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeIstio,
+				},
+			},
+			Mesh: api.MeshParams{
+				CanaryWeight: 25,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, []api.MeshDestinationData{{Subset: "stable", Weight: 75}, {Subset: "canary", Weight: 25}}, templateData.MeshDestinations)
+	})
+
+	t.Run("SetsMeshDestinationsWithoutSubsetIfActionIsDeploySimple", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "myapp",
+			Kind:   api.KindDeployment,
+			Action: api.ActionDeploySimple,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeIstio,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, []api.MeshDestinationData{{Weight: 100}}, templateData.MeshDestinations)
+	})
+
+	t.Run("SetsUseNetworkPolicyToFalseIfNetworkPolicyIsNotEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UseNetworkPolicy)
+	})
+
+	t.Run("AllowsIngressControllerNamespacesToOpenrestyPortsIfVisibilityIsPrivate", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			App:        "myapp",
+			Kind:       api.KindDeployment,
+			Visibility: api.VisibilityPrivate,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeOpenresty,
+				},
+			},
+			NetworkPolicy: api.NetworkPolicyParams{
+				Enabled:                     &trueValue,
+				IngressControllerNamespaces: []string{"ingress-nginx"},
+				Apps:                        []string{"otherapp"},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseNetworkPolicy)
+		assert.False(t, templateData.NetworkPolicyRestrictEgress)
+		if assert.Equal(t, 1, len(templateData.NetworkPolicyIngress)) {
+			rule := templateData.NetworkPolicyIngress[0].(map[string]interface{})
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"kubernetes.io/metadata.name": "ingress-nginx"}}},
+				map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "otherapp"}}},
+			}, rule["from"])
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"port": "http", "protocol": "TCP"},
+				map[string]interface{}{"port": "https", "protocol": "TCP"},
+			}, rule["ports"])
+		}
+	})
+
+	t.Run("AllowsOnlyHttpsPortIfVisibilityIsESPv2WithoutOpenrestySidecar", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			App:        "myapp",
+			Kind:       api.KindDeployment,
+			Visibility: api.VisibilityESPv2,
+			Container: api.ContainerParams{
+				Protocol: api.ContainerProtocolGRPC,
+			},
+			NetworkPolicy: api.NetworkPolicyParams{
+				Enabled: &trueValue,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.HasOpenrestySidecar)
+		if assert.Equal(t, 1, len(templateData.NetworkPolicyIngress)) {
+			rule := templateData.NetworkPolicyIngress[0].(map[string]interface{})
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"port": "https", "protocol": "TCP"},
+			}, rule["ports"])
+		}
+	})
+
+	t.Run("AllowsMetricsNamespacesToMetricsPortIfScrapeIsEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindHeadlessDeployment,
+			Container: api.ContainerParams{
+				Metrics: api.MetricsParams{
+					Scrape: &trueValue,
+					Port:   9102,
+				},
+			},
+			NetworkPolicy: api.NetworkPolicyParams{
+				Enabled:           &trueValue,
+				MetricsNamespaces: []string{"monitoring"},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		if assert.Equal(t, 1, len(templateData.NetworkPolicyIngress)) {
+			rule := templateData.NetworkPolicyIngress[0].(map[string]interface{})
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"kubernetes.io/metadata.name": "monitoring"}}},
+			}, rule["from"])
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"port": 9102, "protocol": "TCP"},
+			}, rule["ports"])
+		}
+	})
+
+	t.Run("RestrictsEgressAndAllowsDNSIfEgressDestinationsAreSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			App:  "myapp",
+			Kind: api.KindDeployment,
+			NetworkPolicy: api.NetworkPolicyParams{
+				Enabled: &trueValue,
+				Egress: api.NetworkPolicyEgressParams{
+					CIDRs: []string{"10.0.0.0/8"},
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.NetworkPolicyRestrictEgress)
+		if assert.Equal(t, 2, len(templateData.NetworkPolicyEgress)) {
+			dnsRule := templateData.NetworkPolicyEgress[0].(map[string]interface{})
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"port": 53, "protocol": "UDP"},
+				map[string]interface{}{"port": 53, "protocol": "TCP"},
+			}, dnsRule["ports"])
+			rule := templateData.NetworkPolicyEgress[1].(map[string]interface{})
+			assert.Equal(t, []interface{}{
+				map[string]interface{}{"ipBlock": map[string]interface{}{"cidr": "10.0.0.0/8"}},
+			}, rule["to"])
+		}
+	})
+
+	t.Run("SetsIngressControllersToDefaultProfilesForVisibility", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityIAP,
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "gce", templateData.IngressController.Class)
+		assert.Equal(t, "kubernetes.io/ingress.", templateData.IngressController.AnnotationPrefix)
+		assert.True(t, templateData.IngressController.Features[api.IngressControllerFeatureDisallowHTTP])
+		assert.Equal(t, "nginx-internal", templateData.InternalIngressController.Class)
+		assert.Equal(t, "nginx-open", templateData.ApigeeIngressController.Class)
+	})
+
+	t.Run("SetsIngressControllerToProfileSelectedByIngressControllerParam", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Basepath:   "/api",
+			Visibility: api.VisibilityPrivate,
+			IngressControllers: []api.IngressControllerParams{
+				{
+					Name:             "traefik",
+					Class:            "traefik-office",
+					AnnotationPrefix: "traefik.ingress.kubernetes.io/",
+					Features:         []string{api.IngressControllerFeatureWhitelist},
+				},
+			},
+			IngressController: "traefik",
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "traefik", templateData.IngressController.Name)
+		assert.Equal(t, "traefik-office", templateData.IngressController.Class)
+		assert.Equal(t, "traefik.ingress.kubernetes.io/", templateData.IngressController.AnnotationPrefix)
+		assert.True(t, templateData.IngressController.Features[api.IngressControllerFeatureWhitelist])
+		assert.False(t, templateData.IngressController.Features[api.IngressControllerFeatureProxySettings])
+		assert.Equal(t, "/api/", templateData.IngressPath)
+	})
+
+	t.Run("SetsCertificateSecretNameToCertManagerSecretIfCertificateProviderIsCertManager", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App: "myapp",
+			Certificate: api.CertificateParams{
+				Provider:   api.CertificateProviderCertManager,
+				Issuer:     "letsencrypt",
+				IssuerKind: "ClusterIssuer",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseCertManagerCertificate)
+		assert.True(t, templateData.UseCertificateSecret)
+		assert.Equal(t, "myapp-cert-manager-certificate", templateData.CertificateSecretName)
+		assert.Equal(t, "letsencrypt", templateData.CertManagerIssuer)
+	})
+
+	t.Run("SetsUseManagedCertificateToTrueIfCertificateProviderIsGKEManagedAndVisibilityIsIap", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityIAP,
+			Certificate: api.CertificateParams{
+				Provider: api.CertificateProviderGKEManaged,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseManagedCertificate)
+		assert.False(t, templateData.UseCertManagerCertificate)
+	})
+
+	t.Run("SetsMountSslCertificateToFalseIfCertificateProviderIsGKEManagedWithoutCertificateSecret", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Kind:       api.KindDeployment,
+			Visibility: api.VisibilityIAP,
+			Certificate: api.CertificateParams{
+				Provider: api.CertificateProviderGKEManaged,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.MountSslCertificate)
+	})
+
+	t.Run("KeepsMountSslCertificateIfCertificateProviderIsGKEManagedWithCertificateSecret", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Kind:              api.KindDeployment,
+			Visibility:        api.VisibilityIAP,
+			CertificateSecret: "my-certificate",
+			Certificate: api.CertificateParams{
+				Provider: api.CertificateProviderGKEManaged,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.MountSslCertificate)
+		assert.Equal(t, "my-certificate", templateData.CertificateSecretName)
+	})
+
+	t.Run("SetsUseGCEIngressToTrueIfVisibilityIsPrivateAndIngressControllerIsGCE", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility:        api.VisibilityPrivate,
+			IngressController: "gce",
+			BackendConfig: api.BackendConfigParams{
+				SecurityPolicy: "office-only",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseGCEIngress)
+		assert.False(t, templateData.UseNginxIngress)
+		assert.False(t, templateData.UseIAP)
+		assert.Equal(t, "NodePort", templateData.ServiceType)
+		assert.True(t, templateData.UseBackendConfigAnnotationOnService)
+		assert.Equal(t, "office-only", templateData.BackendConfigSecurityPolicy)
+	})
+
+	t.Run("SetsBackendConfigHealthCheckTypeToHTTPSIfOpenrestySidecarIsUsedAndNoPortIsSet", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityIAP,
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeOpenresty,
+				},
+			},
+			BackendConfig: api.BackendConfigParams{
+				HealthCheck: api.BackendConfigHealthCheckParams{
+					Path: "/readiness",
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "HTTPS", templateData.BackendConfigHealthCheckType)
+		assert.Equal(t, "/readiness", templateData.BackendConfigHealthCheckPath)
+	})
+
+	t.Run("SetsRoutesWithRequestSettingsAndPortPerRoute", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityPrivate,
+			Container: api.ContainerParams{
+				AdditionalPorts: []*api.AdditionalPortParams{
+					{
+						Name:       "admin",
+						Port:       8081,
+						Protocol:   "TCP",
+						Visibility: api.VisibilityPrivate,
+					},
+				},
+			},
+			Routes: []api.RouteParams{
+				{
+					Name:  "admin",
+					Hosts: []string{"myapp.example.com"},
+					Path:  "/admin",
+					Port:  "admin",
+					Request: api.RequestParams{
+						Timeout:            "120s",
+						MaxBodySize:        "1m",
+						ProxyBuffersNumber: 4,
+					},
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(templateData.Routes))
+		assert.Equal(t, "admin", templateData.Routes[0].Name)
+		assert.Equal(t, "/admin", templateData.Routes[0].Path)
+		assert.Equal(t, "Prefix", templateData.Routes[0].PathType)
+		assert.Equal(t, "admin", templateData.Routes[0].PortName)
+		assert.Equal(t, 75, templateData.Routes[0].ProxyConnectTimeout)
+		assert.Equal(t, 120, templateData.Routes[0].ProxyReadTimeout)
+		assert.Equal(t, "1m", templateData.Routes[0].ProxyBodySize)
+		assert.Equal(t, "4", templateData.Routes[0].ProxyBuffersNumber)
+	})
+
+	t.Run("SetsRoutesOnApigeeHostsWithApigeeIngressControllerIfVisibilityIsApigee", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility:   api.VisibilityApigee,
+			ApigeeSuffix: "apigee",
+			Hosts:        []string{"myapp.example.com"},
+			Routes: []api.RouteParams{
+				{
+					Name:  "api",
+					Hosts: []string{"myapp.example.com"},
+					Path:  "/api",
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "nginx-open", templateData.RoutesIngressController.Class)
+		assert.Equal(t, 1, len(templateData.Routes))
+		assert.Equal(t, []string{"myapp-apigee.example.com"}, templateData.Routes[0].Hosts)
+		assert.Equal(t, "HTTPS", templateData.Routes[0].BackendProtocol)
+	})
+
+	t.Run("KeepsRoutePathWithoutTrailingSlash", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityPrivate,
+			Routes: []api.RouteParams{
+				{
+					Name:  "health",
+					Hosts: []string{"myapp.example.com"},
+					Path:  "/healthz",
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "nginx-office", templateData.RoutesIngressController.Class)
+		assert.Equal(t, "/healthz", templateData.Routes[0].Path)
+		assert.Equal(t, "Prefix", templateData.Routes[0].PathType)
+	})
+
+	t.Run("AddsAdditionalPortTargetedByRouteToServicePorts", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityPrivate,
+			Container: api.ContainerParams{
+				AdditionalPorts: []*api.AdditionalPortParams{
+					{
+						Name:       "admin",
+						Port:       8081,
+						Protocol:   "TCP",
+						Visibility: api.VisibilityESP,
+					},
+				},
+			},
+			Routes: []api.RouteParams{
+				{
+					Name: "admin",
+					Path: "/admin",
+					Port: "admin",
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(templateData.AdditionalServicePorts))
+		assert.Equal(t, "admin", templateData.AdditionalServicePorts[0].Name)
+	})
+	t.Run("SetsHTTPSBackendProtocolsIfOpenrestySidecarIsUsed", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				Protocol: api.ContainerProtocolHTTP,
+			},
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeOpenresty,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "HTTPS", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, `{"https":"HTTPS"}`, templateData.ServiceAppProtocols)
+		assert.Equal(t, "httpGet", templateData.Container.Readiness.Type)
+		assert.Equal(t, "", templateData.Container.Readiness.Scheme)
+	})
+
+	t.Run("KeysServiceAppProtocolsByHTTPSPortIfGRPCSContainerIsBehindOpenrestySidecar", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				Protocol: api.ContainerProtocolGRPCS,
+			},
+			Sidecars: []*api.SidecarParams{
+				{
+					Type: api.SidecarTypeOpenresty,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, `{"https":"HTTP2"}`, templateData.ServiceAppProtocols)
+	})
+
+	t.Run("KeysServiceAppProtocolsByHTTPSPortIfHTTP2ContainerIsBehindESP", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityESP,
+			Container: api.ContainerParams{
+				Protocol: api.ContainerProtocolHTTP2,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, `{"https":"HTTP2"}`, templateData.ServiceAppProtocols)
+	})
+
+	t.Run("KeysServiceAppProtocolsByWebPortIfGRPCContainerHasNoProxySidecar", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				Protocol: api.ContainerProtocolGRPC,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, `{"web":"HTTP"}`, templateData.ServiceAppProtocols)
+	})
+
+	t.Run("SetsNoBackendProtocolForPlainHTTP", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				Port:     5000,
+				Protocol: api.ContainerProtocolHTTP,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UseHTTPS)
+		assert.Equal(t, "", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, "HTTPS", templateData.NginxIngressApigeeBackendProtocol)
+	})
+
+	t.Run("SetsGRPCBackendProtocolsAndProbesForProtocolGRPC", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				Port:     5000,
+				Protocol: api.ContainerProtocolGRPC,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UseHTTPS)
+		assert.Equal(t, "GRPC", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, "GRPC", templateData.NginxIngressApigeeBackendProtocol)
+		assert.Equal(t, "grpc", templateData.ServiceAppProtocol)
+		assert.Equal(t, "grpc", templateData.Container.Liveness.Type)
+		assert.Equal(t, "grpc", templateData.Container.Readiness.Type)
+	})
+
+	t.Run("SetsGRPCSBackendProtocolsAndTCPProbesForProtocolGRPCS", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				Port:     5000,
+				Protocol: api.ContainerProtocolGRPCS,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseHTTPS)
+		assert.Equal(t, "GRPCS", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, `{"web":"HTTP2"}`, templateData.ServiceAppProtocols)
+		assert.Equal(t, "tcpSocket", templateData.Container.Liveness.Type)
+		assert.Equal(t, "tcpSocket", templateData.Container.Readiness.Type)
+	})
+
+	t.Run("SetsHTTPSProbeSchemeAndHTTP2HealthCheckForProtocolHTTP2", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityIAP,
+			Container: api.ContainerParams{
+				Port:     8443,
+				Protocol: api.ContainerProtocolHTTP2,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "HTTPS", templateData.NginxIngressBackendProtocol)
+		assert.Equal(t, `{"web":"HTTP2"}`, templateData.ServiceAppProtocols)
+		assert.Equal(t, "httpGet", templateData.Container.Readiness.Type)
+		assert.Equal(t, "HTTPS", templateData.Container.Readiness.Scheme)
+		assert.Equal(t, "HTTP2", templateData.BackendConfigHealthCheckType)
+	})
+	t.Run("DoesNotMountServiceAccountSecretIfWorkloadIdentityIsEnabled", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		trueValue := true
+		params := api.Params{
+			UseGoogleCloudCredentials: true,
+			WorkloadIdentity: api.WorkloadIdentityParams{
+				Enabled:           &trueValue,
+				GCPServiceAccount: "myapp@myproject.iam.gserviceaccount.com",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.MountServiceAccountSecret)
+		assert.True(t, templateData.UseWorkloadIdentity)
+		assert.Equal(t, "myapp@myproject.iam.gserviceaccount.com", templateData.WorkloadIdentityGCPAccount)
+		_, hasCredentialsEnvvar := templateData.Container.EnvironmentVariables["GOOGLE_APPLICATION_CREDENTIALS"]
+		assert.False(t, hasCredentialsEnvvar)
+	})
+	t.Run("SetsEspBackendToOpenrestyByDefault", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility: api.VisibilityESPv2,
+			Hosts:      []string{"myapp.endpoints.myproject.cloud.goog"},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "http://127.0.0.1:80", templateData.EspBackend)
+	})
+	t.Run("SetsEspBackendToGRPCContainerPortWhenUsingGRPCServiceConfig", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		serviceConfigPath := filepath.Join(t.TempDir(), "api_config.yaml")
+		err = ioutil.WriteFile(serviceConfigPath, []byte("type: google.api.Service\nconfig_version: 3\nname: myapp.endpoints.myproject.cloud.goog\n"), 0644)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility:               api.VisibilityESPv2,
+			Hosts:                    []string{"myapp.endpoints.myproject.cloud.goog"},
+			EspServiceConfigYamlPath: serviceConfigPath,
+			EspDescriptorSetPath:     "api_descriptor.pb",
+			Container: api.ContainerParams{
+				Port:     5000,
+				Protocol: api.ContainerProtocolGRPC,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "grpc://127.0.0.1:5000", templateData.EspBackend)
+	})
+	t.Run("SetsEspServiceToNameInGRPCServiceConfig", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		serviceConfigPath := filepath.Join(t.TempDir(), "api_config.yaml")
+		err = ioutil.WriteFile(serviceConfigPath, []byte("type: google.api.Service\nconfig_version: 3\nname: myapp-grpc.endpoints.myproject.cloud.goog\n"), 0644)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Visibility:               api.VisibilityESPv2,
+			Hosts:                    []string{"myapp.example.com"},
+			EspServiceConfigYamlPath: serviceConfigPath,
+			EspDescriptorSetPath:     "api_descriptor.pb",
+			Container: api.ContainerParams{
+				Port:     5000,
+				Protocol: api.ContainerProtocolGRPC,
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "myapp-grpc.endpoints.myproject.cloud.goog", templateData.EspService)
+	})
+	t.Run("SetsSecretManagerVersionsAsJSONWithoutTheirValues", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				SecretEnvironmentVariables: map[string]interface{}{
+					"DB_PASSWORD": "s3cr3t",
+				},
+			},
+			SecretManagerVersions: map[string]string{
+				"container.secretEnv.DB_PASSWORD": "projects/123/secrets/db-password/versions/7",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.HasSecretManagerVersions)
+		assert.Equal(t, `{"container.secretEnv.DB_PASSWORD":"projects/123/secrets/db-password/versions/7"}`, templateData.SecretManagerVersions)
+		assert.NotContains(t, templateData.SecretManagerVersions, "s3cr3t")
+	})
+	t.Run("SetsContainerDigestIfImageIsPinned", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Container: api.ContainerParams{
+				ImageRepository: "estafette",
+				ImageName:       "my-app",
+				ImageTag:        "1.0.0",
+				ImageDigest:     "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "1.0.0", templateData.Container.Tag)
+		assert.Equal(t, "sha256:4300dc7d45600c428f4196009ee842c1c3bdd51aaa4f55361479f6fa60e78faf", templateData.Container.Digest)
+	})
+
+	t.Run("SetsCloudSQLProxyV2ArgsToSidecarSpecificProperties", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		instances := []*api.SQLProxyInstanceParams{
+			{
+				ConnectionName: "project:europe-west1:instance-a",
+				Port:           5432,
+			},
+			{
+				ConnectionName: "project:europe-west1:instance-b",
+				Port:           5433,
+			},
+		}
+		params := api.Params{
+			Sidecars: []*api.SidecarParams{
+				&api.SidecarParams{
+					Type:                    api.SidecarTypeCloudSQLProxy,
+					SQLProxyVersion:         api.CloudSQLProxyVersionV2,
+					SQLProxyInstances:       instances,
+					SQLProxyPrivateIP:       true,
+					SQLProxyAutoIAMAuthn:    true,
+					SQLProxyStructuredLogs:  true,
+					SQLProxyHealthCheckPort: 9801,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "v2", templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyversion"])
+		assert.Equal(t, instances, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyinstances"])
+		assert.Equal(t, true, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyprivateip"])
+		assert.Equal(t, true, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyautoiamauthn"])
+		assert.Equal(t, true, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxystructuredlogs"])
+		assert.Equal(t, 9801, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyhealthcheckport"])
+	})
+
+	t.Run("RendersContainerTemplateOfDeclaredSidecarTypeWithItsProperties", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App: "myapp",
+			SidecarTypes: []*api.SidecarTypeDefinition{
+				{
+					Type:       "logshipper",
+					Properties: map[string]interface{}{"logpath": "/var/log/app"},
+					Required:   []string{"output"},
+					Template:   "args: [\"-p\", \"path={{ index .Sidecar.SidecarSpecificProperties \"logpath\" }}\", \"-o\", \"{{ index .Sidecar.SidecarSpecificProperties \"output\" }}\", \"-p\", \"tag={{ .Deployment.Name }}\"]\n",
+				},
+			},
+			Sidecars: []*api.SidecarParams{
+				&api.SidecarParams{
+					Type: "logshipper",
+					CustomProperties: map[string]interface{}{
+						"logpath": "/var/log/app",
+						"output":  "stdout",
+						"securityContext": map[string]interface{}{
+							"runAsNonRoot": true,
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "/var/log/app", templateData.Sidecars[0].SidecarSpecificProperties["logpath"])
+		assert.Equal(t, "stdout", templateData.Sidecars[0].SidecarSpecificProperties["output"])
+		assert.Equal(t, "securityContext:\n  runAsNonRoot: true\n", templateData.Sidecars[0].CustomPropertiesYAML)
+		assert.Equal(t, "args: [\"-p\", \"path=/var/log/app\", \"-o\", \"stdout\", \"-p\", \"tag=myapp\"]", templateData.Sidecars[0].ContainerYAML)
+	})
+
+	t.Run("SetsUseNativeSidecarsIfKindIsJobAndClusterSupportsIt", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Kind:              api.KindJob,
+			NativeSidecars:    api.NativeSidecarsModeAuto,
+			KubernetesVersion: "v1.29.1",
+			Sidecars: []*api.SidecarParams{
+				&api.SidecarParams{
+					Type:            api.SidecarTypeCloudSQLProxy,
+					SQLProxyVersion: api.CloudSQLProxyVersionV2,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.True(t, templateData.UseNativeSidecars)
+		assert.Nil(t, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyadminport"])
+		assert.Nil(t, templateData.Container.EnvironmentVariables["CLOUD_SQL_PROXY_QUITQUITQUIT_URL"])
+	})
+
+	t.Run("EnablesCloudSQLProxyQuitEndpointIfKindIsJobWithoutNativeSidecars", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Kind:              api.KindCronJob,
+			NativeSidecars:    api.NativeSidecarsModeAuto,
+			KubernetesVersion: "v1.27.3",
+			Sidecars: []*api.SidecarParams{
+				&api.SidecarParams{
+					Type:            api.SidecarTypeCloudSQLProxy,
+					SQLProxyVersion: api.CloudSQLProxyVersionV2,
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.False(t, templateData.UseNativeSidecars)
+		assert.Equal(t, 9091, templateData.Sidecars[0].SidecarSpecificProperties["sqlproxyadminport"])
+		assert.Equal(t, "http://127.0.0.1:9091/quitquitquit", templateData.Container.EnvironmentVariables["CLOUD_SQL_PROXY_QUITQUITQUIT_URL"])
+	})
+
+	t.Run("AddsOpenTelemetryEnvironmentVariablesForStableTrack", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:          "my-app",
+			Action:       api.ActionDeployStable,
+			BuildVersion: "1.0.3",
+			Tracing: api.TracingParams{
+				Provider:            api.TracingProviderOpenTelemetry,
+				SamplingRatio:       "0.001",
+				CanarySamplingRatio: "0.1",
+			},
+			Container: api.ContainerParams{
+				EnvironmentVariables: map[string]interface{}{},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "15", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "my-app", templateData.Container.EnvironmentVariables["OTEL_SERVICE_NAME"])
+		assert.Equal(t, "http://$(OTEL_NODE_IP):4317", templateData.Container.EnvironmentVariables["OTEL_EXPORTER_OTLP_ENDPOINT"])
+		assert.Equal(t, "grpc", templateData.Container.EnvironmentVariables["OTEL_EXPORTER_OTLP_PROTOCOL"])
+		assert.Equal(t, "service.version=1.0.3,deployment.track=stable,estafette.release.id=15", templateData.Container.EnvironmentVariables["OTEL_RESOURCE_ATTRIBUTES"])
+		assert.Equal(t, "parentbased_traceidratio", templateData.Container.EnvironmentVariables["OTEL_TRACES_SAMPLER"])
+		assert.Equal(t, "0.001", templateData.Container.EnvironmentVariables["OTEL_TRACES_SAMPLER_ARG"])
+		assert.Nil(t, templateData.Container.EnvironmentVariables["JAEGER_SERVICE_NAME"])
+		assert.False(t, templateData.UseJaegerTracing)
+		assert.True(t, templateData.UseOpenTelemetryNodeIP)
+	})
+
+	t.Run("AddsSimpleTrackToOpenTelemetryResourceAttributesForSimpleRelease", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "my-app",
+			Action: api.ActionDeploySimple,
+			Tracing: api.TracingParams{
+				Provider:      api.TracingProviderOpenTelemetry,
+				SamplingRatio: "0.001",
+			},
+			Container: api.ContainerParams{
+				EnvironmentVariables: map[string]interface{}{},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "deployment.track=simple", templateData.Container.EnvironmentVariables["OTEL_RESOURCE_ATTRIBUTES"])
+	})
+
+	t.Run("AddsOpenTelemetryEnvironmentVariablesWithCanarySamplingRatioForCanaryTrack", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App:    "my-app",
+			Action: api.ActionDeployCanary,
+			Tracing: api.TracingParams{
+				Provider:            api.TracingProviderOpenTelemetry,
+				Endpoint:            "http://otel-collector.tracing:4317",
+				SamplingRatio:       "0.001",
+				CanarySamplingRatio: "0.1",
+			},
+			Container: api.ContainerParams{
+				EnvironmentVariables: map[string]interface{}{},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "http://otel-collector.tracing:4317", templateData.Container.EnvironmentVariables["OTEL_EXPORTER_OTLP_ENDPOINT"])
+		assert.Nil(t, templateData.Container.EnvironmentVariables["OTEL_EXPORTER_OTLP_PROTOCOL"])
+		assert.Equal(t, "deployment.track=canary", templateData.Container.EnvironmentVariables["OTEL_RESOURCE_ATTRIBUTES"])
+		assert.Equal(t, "0.1", templateData.Container.EnvironmentVariables["OTEL_TRACES_SAMPLER_ARG"])
+		assert.False(t, templateData.UseOpenTelemetryNodeIP)
+	})
+
+	t.Run("AddsNoTracingEnvironmentVariablesIfTracingProviderIsNone", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App: "my-app",
+			Tracing: api.TracingParams{
+				Provider: api.TracingProviderNone,
+			},
+			Container: api.ContainerParams{
+				EnvironmentVariables: map[string]interface{}{},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Nil(t, templateData.Container.EnvironmentVariables["JAEGER_SERVICE_NAME"])
+		assert.Nil(t, templateData.Container.EnvironmentVariables["OTEL_SERVICE_NAME"])
+		assert.False(t, templateData.UseJaegerTracing)
+		assert.False(t, templateData.UseOpenTelemetryNodeIP)
+	})
+
+	t.Run("SetsHpaMetricsFromAutoscaleMetrics", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			Autoscale: api.AutoscaleParams{
+				Metrics: []*api.AutoscaleMetricParams{
+					{
+						Type:               api.AutoscaleMetricTypeMemory,
+						AverageUtilization: 70,
+					},
+					{
+						Type:         api.AutoscaleMetricTypeExternal,
+						Name:         "pubsub.googleapis.com|subscription|num_undelivered_messages",
+						Selector:     map[string]string{"resource.labels.subscription_id": "my-sub"},
+						AverageValue: "30",
+					},
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, 2, len(templateData.HpaMetrics))
+		assert.Equal(t, map[string]interface{}{
+			"type": "Resource",
+			"resource": map[string]interface{}{
+				"name": "memory",
+				"target": map[string]interface{}{
+					"type":               "Utilization",
+					"averageUtilization": 70,
+				},
+			},
+		}, templateData.HpaMetrics[0])
+		assert.Equal(t, map[string]interface{}{
+			"type": "External",
+			"external": map[string]interface{}{
+				"metric": map[string]interface{}{
+					"name": "pubsub.googleapis.com|subscription|num_undelivered_messages",
+					"selector": map[string]interface{}{
+						"matchLabels": map[string]string{"resource.labels.subscription_id": "my-sub"},
+					},
+				},
+				"target": map[string]interface{}{
+					"type":         "AverageValue",
+					"averageValue": "30",
+				},
+			},
+		}, templateData.HpaMetrics[1])
+		assert.Nil(t, templateData.HpaBehavior)
+	})
+
+	t.Run("SetsHpaBehaviorFromAutoscaleBehavior", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		stabilizationWindowSeconds := 300
+		params := api.Params{
+			Autoscale: api.AutoscaleParams{
+				Behavior: api.AutoscaleBehaviorParams{
+					ScaleDown: &api.AutoscaleScalingRulesParams{
+						StabilizationWindowSeconds: &stabilizationWindowSeconds,
+						SelectPolicy:               "Min",
+						Policies: []*api.AutoscalePolicyParams{
+							{
+								Type:          "Pods",
+								Value:         2,
+								PeriodSeconds: 60,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(templateData.HpaMetrics))
+		assert.Equal(t, map[string]interface{}{
+			"scaleDown": map[string]interface{}{
+				"stabilizationWindowSeconds": 300,
+				"selectPolicy":               "Min",
+				"policies": []interface{}{
+					map[string]interface{}{
+						"type":          "Pods",
+						"value":         2,
+						"periodSeconds": 60,
+					},
+				},
+			},
+		}, templateData.HpaBehavior)
+	})
+
+	t.Run("DoesNotFallBackToAutoscalingV1IfOnlyAutoscalingV1IsServed", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			ServedAPIVersions: []string{
+				"autoscaling/v1",
+			},
+		}
+
+		// act
+		templateData, err := service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "autoscaling/v2", templateData.APIVersions.HorizontalPodAutoscaler)
+	})
+
+	t.Run("ReturnsErrorIfContainerTemplateOfDeclaredSidecarTypeFailsToRender", func(t *testing.T) {
+
+		ctx := context.Background()
+		service, err := NewService(ctx)
+		assert.Nil(t, err)
+
+		params := api.Params{
+			App: "myapp",
+			SidecarTypes: []*api.SidecarTypeDefinition{
+				{
+					Type:     "logshipper",
+					Template: "args: [\"-p\", \"tag={{ .Deployment.UnknownField }}\"]\n",
+				},
+			},
+			Sidecars: []*api.SidecarParams{
+				&api.SidecarParams{
+					Type: "logshipper",
+				},
+			},
+		}
+
+		// act
+		_, err = service.GenerateTemplateData(params, -1, "github.com", "estafette", "estafette-extension-gke", "master", "02770946ad015b34da9e9980007bf81308c41aec", "", "")
+
+		assert.NotNil(t, err)
+	})
 }
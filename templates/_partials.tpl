@@ -0,0 +1,131 @@
+{{- /* named templates shared between the other templates, rendered at the left margin so they can be included at any indentation */}}
+{{- define "cloudsqlproxy-command" }}
+{{- $deployment := .Deployment }}
+{{- with .Sidecar }}
+{{- if eq (index .SidecarSpecificProperties "sqlproxyversion") "v1" -}}
+command: ["/cloud_sql_proxy",
+          "-instances={{ range $i, $instance := index .SidecarSpecificProperties "sqlproxyinstances" }}{{ if $i }},{{ end }}{{ $instance.ConnectionName }}=tcp:{{ $instance.Port }}{{ end }}",
+          {{- if $deployment.MountServiceAccountSecret }}
+          "-credential_file=/gcp-service-account/service-account-key.json",
+          {{- end }}
+          "-term_timeout={{ index .SidecarSpecificProperties "sqlproxyterminationtimeoutseconds" }}s"]
+{{- else -}}
+command: ["/cloud-sql-proxy"]
+args:
+{{- range index .SidecarSpecificProperties "sqlproxyinstances" }}
+- "{{ .ConnectionName }}?port={{ .Port }}"
+{{- end }}
+{{- if $deployment.MountServiceAccountSecret }}
+- "--credentials-file=/gcp-service-account/service-account-key.json"
+{{- end }}
+{{- if index .SidecarSpecificProperties "sqlproxyprivateip" }}
+- "--private-ip"
+{{- end }}
+{{- if index .SidecarSpecificProperties "sqlproxyautoiamauthn" }}
+- "--auto-iam-authn"
+{{- end }}
+{{- if index .SidecarSpecificProperties "sqlproxystructuredlogs" }}
+- "--structured-logs"
+{{- end }}
+{{- if index .SidecarSpecificProperties "sqlproxyadminport" }}
+- "--quitquitquit"
+- "--admin-port={{ index .SidecarSpecificProperties "sqlproxyadminport" }}"
+{{- end }}
+- "--health-check"
+- "--http-address=0.0.0.0"
+- "--http-port={{ index .SidecarSpecificProperties "sqlproxyhealthcheckport" }}"
+- "--exit-zero-on-sigterm"
+- "--max-sigterm-delay={{ index .SidecarSpecificProperties "sqlproxyterminationtimeoutseconds" }}s"
+ports:
+- name: sqlproxy-health
+  containerPort: {{ index .SidecarSpecificProperties "sqlproxyhealthcheckport" }}
+startupProbe:
+  httpGet:
+    path: /startup
+    port: sqlproxy-health
+  periodSeconds: 1
+  timeoutSeconds: 5
+  failureThreshold: 60
+readinessProbe:
+  httpGet:
+    path: /readiness
+    port: sqlproxy-health
+  periodSeconds: 10
+  timeoutSeconds: 5
+  failureThreshold: 6
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- define "job-sidecar" }}
+{{- $deployment := .Deployment }}
+{{- $native := .Native }}
+{{- with .Sidecar -}}
+- name: {{$deployment.Name}}-{{ if eq .Type "cloudsqlproxy" }}cloudsql-proxy{{ else }}{{.Type}}{{ end }}
+  image: {{.Image}}
+  {{- if $native }}
+  restartPolicy: Always
+  {{- end }}
+  {{- if .HasEnvironmentVariables }}
+  env:
+  {{- range $key, $value := .EnvironmentVariables }}
+  - name: {{ $key | quote }}
+    {{- if (call $deployment.IsSimpleEnvvarValue $value) }}
+    value: {{ $value | quote }}
+    {{- else }}
+{{(call $deployment.RenderToYAML $value $deployment) | indent 4}}
+    {{- end }}
+  {{- end }}
+  {{- range $key, $value := .SecretEnvironmentVariables }}
+  - name: {{ $key | quote }}
+    valueFrom:
+      secretKeyRef:
+        name: {{$deployment.Name}}-secrets
+        key: {{ $key }}
+  {{- end }}
+  {{- end }}
+  resources:
+    requests:
+      cpu: {{.CPURequest}}
+      memory: {{.MemoryRequest}}
+    limits:
+      {{- if .CPULimit}}
+      cpu: {{.CPULimit}}
+      {{- end }}
+      memory: {{.MemoryLimit}}
+  {{- if eq .Type "cloudsqlproxy" }}
+{{ include "cloudsqlproxy-command" (dict "Sidecar" . "Deployment" $deployment) | indent 2 }}
+  {{- if $deployment.MountServiceAccountSecret }}
+  volumeMounts:
+  - name: gcp-service-account
+    mountPath: /gcp-service-account
+  {{- end }}
+  {{- else }}
+  {{- if or $deployment.MountApplicationSecrets $deployment.MountConfigmap $deployment.MountServiceAccountSecret $deployment.MountAdditionalVolumes }}
+  volumeMounts:
+  {{- if $deployment.MountApplicationSecrets }}
+  - name: app-secrets
+    mountPath: {{$deployment.SecretMountPath}}
+  {{- end }}
+  {{- if $deployment.MountConfigmap }}
+  - name: app-configs
+    mountPath: {{$deployment.ConfigMountPath}}
+  {{- end }}
+  {{- if $deployment.MountServiceAccountSecret }}
+  - name: gcp-service-account
+    mountPath: /gcp-service-account
+  {{- end }}
+  {{- range $deployment.AdditionalVolumeMounts}}
+  - name: {{.Name}}
+    mountPath: {{.MountPath}}
+  {{- end}}
+  {{- end}}
+  {{- end }}
+  {{- if .HasCustomProperties }}
+{{.CustomPropertiesYAML | indent 2}}
+  {{- end }}
+  {{- if .ContainerYAML }}
+{{.ContainerYAML | indent 2}}
+  {{- end }}
+{{- end }}
+{{- end }}